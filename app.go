@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"embed"
 
 	"github.com/labstack/echo/v4"
@@ -11,6 +12,8 @@ import (
 	"github.com/onetooler/bistory-backend/middleware"
 	"github.com/onetooler/bistory-backend/migration"
 	"github.com/onetooler/bistory-backend/routes"
+	"github.com/onetooler/bistory-backend/seed"
+	"github.com/onetooler/bistory-backend/service"
 	"github.com/onetooler/bistory-backend/util"
 )
 
@@ -20,13 +23,42 @@ type Server struct {
 	StaticFile  embed.FS
 	EmailFile   embed.FS
 	PropsFile   embed.FS
+	SeedFile    embed.FS
 }
 
-func (s Server) Run() {
+// Run starts the server.
+func (s Server) Run(conf *config.Config, env string) {
 	e := echo.New()
 
-	conf, env := config.LoadAppConfig(s.YamlFile)
-	logger := logger.InitLogger(env, s.ZapYamlFile)
+	container, closeContainer := s.newContainer(conf, env)
+	defer closeContainer()
+	logger := container.GetLogger()
+
+	migration.Init(container)
+	seed.Init(container, s.SeedFile)
+	if err := service.NewBootstrapService(container).BootstrapAdmin(context.Background()); err != nil {
+		logger.GetZapLogger().Errorf("Failed to bootstrap the admin account: %s", err.Error())
+	}
+	routes.Init(e, container)
+	middleware.Init(e, container, s.StaticFile)
+
+	if err := service.RegisterJobs(container); err != nil {
+		logger.GetZapLogger().Errorf("Failed to register the jobs: %s", err.Error())
+	}
+	if conf.Scheduler.Enabled {
+		container.GetScheduler().Start()
+		defer container.GetScheduler().Stop()
+	}
+
+	if err := e.Start(":8080"); err != nil {
+		logger.GetZapLogger().Errorf(err.Error())
+	}
+}
+
+// newContainer connects to the infrastructure by the configuration, which is shared by the server and the subcommands.
+// The returned function closes the connections.
+func (s Server) newContainer(conf *config.Config, env string) (container.Container, func()) {
+	logger := logger.InitLogger(env, s.ZapYamlFile, conf)
 	logger.GetZapLogger().Infof("Loaded this configuration : application." + env + ".yml")
 
 	messages := config.LoadMessagesConfig(s.PropsFile)
@@ -35,18 +67,18 @@ func (s Server) Run() {
 	templates := config.LoadEmailTemplates(s.EmailFile)
 	logger.GetZapLogger().Infof("Loaded email templates.")
 
+	shutdownTracing := infrastructure.InitTracing(logger, conf)
+
 	email := infrastructure.NewEmailSender(logger, conf, templates)
 	sess := infrastructure.NewSession(logger, conf)
+	storage := infrastructure.NewStorage(logger, conf)
 	rep := infrastructure.NewRepository(logger, conf)
-	defer util.Check(rep.Close)
-
-	container := container.NewContainer(rep, sess, email, conf, messages, logger, env)
+	scheduler := infrastructure.NewScheduler(logger, rep)
+	idempotency := infrastructure.NewIdempotencyStore(logger, conf, rep)
 
-	migration.Init(container)
-	routes.Init(e, container)
-	middleware.Init(e, container, s.StaticFile)
-
-	if err := e.Start(":8080"); err != nil {
-		logger.GetZapLogger().Errorf(err.Error())
+	container := container.NewContainer(rep, sess, email, storage, scheduler, idempotency, conf, messages, logger, env)
+	return container, func() {
+		util.Check(rep.Close)
+		util.Check(shutdownTracing)
 	}
 }
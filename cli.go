@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/logger"
+	"github.com/onetooler/bistory-backend/migration"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/seed"
+	"github.com/onetooler/bistory-backend/service"
+	"gopkg.in/yaml.v3"
+)
+
+// command is a subcommand of the command line. It runs with the container unless it is a plain command.
+type command struct {
+	usage string
+	plain func(conf *config.Config, args []string) error
+	run   func(container container.Container, args []string) error
+}
+
+// commands are the subcommands of the command line, by their names and the names of their actions.
+var commands = map[string]command{
+	"migrate up":           {usage: "add the missing tables and columns, and seed the roles", run: migrateUp},
+	"migrate down":         {usage: "drop all tables with their data. -yes is required", run: migrateDown},
+	"migrate status":       {usage: "print the tables and the columns to be added", run: migrateStatus},
+	"account create":       {usage: "create an account. -login, -email, -password and -admin", run: accountCreate},
+	"account unlock":       {usage: "activate the account locked by the failed logins. <loginId>", run: accountUnlock},
+	"account set-password": {usage: "replace the password of the account. <loginId> -password", run: accountSetPassword},
+	"seed load":            {usage: "apply the fixture files of a directory. <dir>, and -overwrite to update the existing records", run: seedLoad},
+	"config print":         {usage: "print the configuration whose secrets are masked", plain: configPrint},
+	"email send-test":      {usage: "send a test email. <to>", run: emailSendTest},
+}
+
+// Main runs a given subcommand, or starts the server if it is serve or omitted, and returns the exit status.
+// The flags before the subcommand, such as -env, are parsed by the configuration loading.
+func (s Server) Main() int {
+	conf, env := config.LoadAppConfig(s.YamlFile)
+	args := flag.Args()
+	if len(args) == 0 || args[0] == "serve" {
+		s.Run(conf, env)
+		return 0
+	}
+
+	name := args[0]
+	if len(args) > 1 {
+		name += " " + args[1]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		printUsage(os.Stderr)
+		return config.ErrExitStatus
+	}
+
+	var err error
+	if cmd.plain != nil {
+		err = cmd.plain(conf, args[2:])
+	} else {
+		container, closeContainer := s.newContainer(conf, env)
+		err = cmd.run(container, args[2:])
+		closeContainer()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, err.Error())
+		return config.ErrExitStatus
+	}
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: bistory-backend [-env name] [command] [args]")
+	fmt.Fprintln(w, "  serve                  start the server. It is the default command")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-22s %s\n", name, commands[name].usage)
+	}
+}
+
+func migrateUp(container container.Container, _ []string) error {
+	if err := migration.Up(container); err != nil {
+		return err
+	}
+	fmt.Println("Migrated the schema.")
+	return nil
+}
+
+func migrateDown(container container.Container, args []string) error {
+	flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "confirm to drop all tables")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !*yes {
+		return errors.New("all data will be lost. run with -yes to confirm")
+	}
+	if err := migration.Down(container); err != nil {
+		return err
+	}
+	fmt.Println("Dropped all tables.")
+	return nil
+}
+
+func migrateStatus(container container.Container, _ []string) error {
+	statuses, err := migration.Status(container)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tSTATUS")
+	for _, status := range statuses {
+		switch {
+		case !status.Exists:
+			fmt.Fprintf(w, "%s\tmissing\n", status.Table)
+		case len(status.MissingColumns) > 0:
+			fmt.Fprintf(w, "%s\tmissing columns: %s\n", status.Table, strings.Join(status.MissingColumns, ", "))
+		default:
+			fmt.Fprintf(w, "%s\tup to date\n", status.Table)
+		}
+	}
+	return w.Flush()
+}
+
+func seedLoad(container container.Container, args []string) error {
+	flags := flag.NewFlagSet("seed load", flag.ContinueOnError)
+	overwrite := flags.Bool("overwrite", false, "update the existing records to the fixtures")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: seed load [-overwrite] <dir>")
+	}
+	dir := flags.Arg(0)
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+	mode := seed.CreateMissing
+	if *overwrite {
+		mode = seed.Overwrite
+	}
+	if err := seed.Load(context.Background(), container, os.DirFS(dir), mode); err != nil {
+		return err
+	}
+	fmt.Printf("Loaded the fixtures of %s.\n", dir)
+	return nil
+}
+
+func accountCreate(container container.Container, args []string) error {
+	flags := flag.NewFlagSet("account create", flag.ContinueOnError)
+	data := dto.NewCreateAccountDto()
+	flags.StringVar(&data.LoginId, "login", "", "login id")
+	flags.StringVar(&data.Email, "email", "", "email address")
+	flags.StringVar(&data.Password, "password", "", "password. It is read from the standard input if omitted")
+	admin := flags.Bool("admin", false, "grant the admin role")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if data.LoginId == "" || data.Email == "" {
+		return errors.New("-login and -email are required")
+	}
+	if err := readPassword(&data.Password); err != nil {
+		return err
+	}
+
+	accountService := service.NewAccountService(container)
+	create := accountService.CreateAccount
+	if *admin {
+		create = accountService.CreateAdminAccount
+	}
+	account, err := create(context.Background(), data)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created the account %s, id %d.\n", account.LoginId, account.ID)
+	return nil
+}
+
+func accountUnlock(container container.Container, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: account unlock <loginId>")
+	}
+	if err := service.NewAccountService(container).UnlockAccount(context.Background(), args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Unlocked the account %s.\n", args[0])
+	return nil
+}
+
+func accountSetPassword(container container.Container, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("usage: account set-password <loginId> [-password password]")
+	}
+	loginId := args[0]
+	flags := flag.NewFlagSet("account set-password", flag.ContinueOnError)
+	password := flags.String("password", "", "new password. It is read from the standard input if omitted")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if err := readPassword(password); err != nil {
+		return err
+	}
+	if err := service.NewAccountService(container).SetAccountPassword(context.Background(), loginId, *password); err != nil {
+		return err
+	}
+	fmt.Printf("Changed the password of the account %s.\n", loginId)
+	return nil
+}
+
+// readPassword reads the first line of the standard input if the password is not given by the flag,
+// so that it is not left in the shell history.
+func readPassword(password *string) error {
+	if *password != "" {
+		return nil
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	*password = strings.TrimRight(line, "\r\n")
+	if *password == "" {
+		return errors.New("password is required")
+	}
+	return nil
+}
+
+// configPrint prints the loaded configuration. The passwords, tokens, secrets and keys are masked by the redactor of the logs.
+func configPrint(conf *config.Config, _ []string) error {
+	bytes, err := yaml.Marshal(conf)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if err := yaml.Unmarshal(bytes, &value); err != nil {
+		return err
+	}
+
+	redactConf := *conf
+	redactConf.Log.RedactFields = append([]string{"key"}, conf.Log.RedactFields...)
+	redactConf.Log.ShowEmails = true
+	encoder := yaml.NewEncoder(os.Stdout)
+	if err := encoder.Encode(logger.NewRedactor(&redactConf).Value(value)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func emailSendTest(container container.Container, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: email send-test <to>")
+	}
+	hostname, _ := os.Hostname()
+	body := map[string]string{"Host": hostname, "Env": container.GetEnv(), "SentAt": time.Now().Format(time.RFC3339)}
+	// TODO: Change to Constant
+	subject := "[Bistory] 테스트 메일"
+	if err := container.GetEmailSender().SendEmail(context.Background(), args[0], subject, config.TestEmailTemplate, body); err != nil {
+		return err
+	}
+	fmt.Printf("Sent a test email to %s.\n", args[0])
+	return nil
+}
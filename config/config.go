@@ -7,6 +7,7 @@ import (
 	"html/template"
 	"io/fs"
 	"os"
+	"time"
 
 	"github.com/onetooler/bistory-backend/util"
 	"gopkg.in/yaml.v3"
@@ -14,14 +15,35 @@ import (
 
 // Config represents the composition of yml settings.
 type Config struct {
+	Server struct {
+		// BaseURL is the public URL of this application. It is used for the links in emails.
+		BaseURL string `yaml:"base_url" default:"http://localhost:8080"`
+	}
 	Database struct {
 		Dialect   string `default:"sqlite3"`
 		Host      string `default:"develop.db"`
 		Port      string
 		Dbname    string
-		LoginId   string
+		LoginId   string `yaml:"loginId"`
 		Password  string
 		Migration bool `default:"false"`
+		// QueryTimeout limits the database work of a request. RouteTimeouts overrides it by "METHOD /path" of the routes.
+		QueryTimeout  time.Duration            `yaml:"query_timeout" default:"10s"`
+		RouteTimeouts map[string]time.Duration `yaml:"route_timeouts"`
+		// SlowThreshold is the elapsed time of a SQL statement to log it as a slow query. A negative value disables it.
+		SlowThreshold time.Duration `yaml:"slow_threshold" default:"200ms"`
+		// SSLMode is sslmode of PostgreSQL, or tls of MySQL such as "true", "skip-verify" and "preferred".
+		SSLMode string `yaml:"ssl_mode" default:"disable"`
+		// Options are added to the DSN, such as connect_timeout of PostgreSQL or charset of MySQL.
+		Options map[string]string
+		Pool    struct {
+			MaxOpenConns    int           `yaml:"max_open_conns"`
+			MaxIdleConns    int           `yaml:"max_idle_conns"`
+			ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+			ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+		}
+		// Replicas serve the reads outside of the transactions. The writes and the transactions go to the primary.
+		Replicas []DatabaseReplica
 	}
 	Redis struct {
 		Enabled            bool `default:"false"`
@@ -29,6 +51,10 @@ type Config struct {
 		Host               string
 		Port               string
 	}
+	Idempotency struct {
+		// TTL is the period to replay the response of a POST request for the retries with the same Idempotency-Key.
+		TTL time.Duration `yaml:"ttl" default:"24h"`
+	}
 	Email struct {
 		Enabled  bool `default:"false"`
 		Account  string
@@ -37,12 +63,82 @@ type Config struct {
 		Username string
 		Password string
 	}
+	Account struct {
+		// DeletionGraceDays is the days until a deleted account is purged. It can be restored until then.
+		DeletionGraceDays int `yaml:"deletion_grace_days" default:"30"`
+		// RestoreURL is the page to restore a deleted account. The restore token is appended as the token query parameter.
+		RestoreURL string `yaml:"restore_url"`
+	}
+	// Bootstrap creates the first admin account at boot while no admin exists. It never runs once an admin exists.
+	// If the admin is not configured, a one-time setup token is printed to the log instead, to create it by the API.
+	Bootstrap struct {
+		// Admin is overridden by the environment variables BISTORY_ADMIN_LOGIN_ID, BISTORY_ADMIN_EMAIL and BISTORY_ADMIN_PASSWORD.
+		// The password must be changed at the first login.
+		Admin struct {
+			LoginId  string `yaml:"loginId"`
+			Email    string
+			Password string
+		}
+		// SetupTokenLifetime is the period the setup token is valid.
+		SetupTokenLifetime time.Duration `yaml:"setup_token_lifetime" default:"24h"`
+	}
+	// Seed loads the fixture files at startup, after the migration.
+	Seed struct {
+		Enabled bool `default:"false"`
+		// Dir is the directory of the fixture files. The embedded fixtures of the environment are used if it is empty.
+		Dir string
+	}
+	Scheduler struct {
+		// Enabled runs the due jobs in this instance. The jobs can be scheduled even if it is disabled.
+		Enabled bool `default:"false"`
+	}
+	Storage struct {
+		// Type is the backend of storage. "local" or "s3".
+		Type string `default:"local"`
+		// SigningKey is the key to sign the download URLs of the local storage.
+		SigningKey string `yaml:"signing_key"`
+		Local      struct {
+			Root string `default:"storage"`
+		}
+		S3 struct {
+			Endpoint  string
+			Region    string
+			Bucket    string
+			AccessKey string `yaml:"access_key"`
+			SecretKey string `yaml:"secret_key"`
+			UseSSL    bool   `yaml:"use_ssl" default:"false"`
+		}
+	}
 	Extension struct {
-		MasterGenerator bool `yaml:"master_generator" default:"false"`
-		CorsEnabled     bool `yaml:"cors_enabled" default:"false"`
+		CorsEnabled bool `yaml:"cors_enabled" default:"false"`
 	}
 	Log struct {
-		RequestLogFormat string `yaml:"request_log_format" default:"${remote_ip} ${account_loginid} ${uri} ${method} ${status}"`
+		RequestLogFormat string `yaml:"request_log_format" default:"${remote_ip} ${account_loginid} ${uri} ${method} ${status} ${request_id}"`
+		// RedactFields are the JSON fields, query parameters and SQL columns whose values are masked in the logs,
+		// in addition to password, token, secret and signature.
+		RedactFields []string `yaml:"redact_fields"`
+		// RedactHeaders are the headers whose values are masked in the logs, in addition to Authorization and Cookie.
+		RedactHeaders []string `yaml:"redact_headers"`
+		// ShowEmails logs the email addresses as they are. They are masked by default.
+		ShowEmails bool `yaml:"show_emails" default:"false"`
+		// BodyMaxSize is the bytes of the request and response bodies to log. The rest is truncated.
+		BodyMaxSize int `yaml:"body_max_size" default:"4096"`
+	}
+	Tracing struct {
+		// Enabled records the spans of the routes, SQL statements, sessions, emails and password hashing.
+		Enabled bool `default:"false"`
+		// Exporter is the destination of the spans. "otlp" to the OTLP/HTTP collector, or "stdout".
+		Exporter string `default:"stdout"`
+		// Endpoint is the host and port of the OTLP/HTTP collector.
+		Endpoint string `default:"localhost:4318"`
+		Insecure bool   `default:"false"`
+		// SampleRatio is the ratio of the traces started by this application to record, from 0 to 1.
+		SampleRatio float64 `yaml:"sample_ratio" default:"1"`
+		ServiceName string  `yaml:"service_name" default:"bistory-backend"`
+	}
+	API struct {
+		// Versions are the deprecations of the old API versions by their names, such as v1.
+		Versions map[string]APIVersion
 	}
 	StaticContents struct {
 		Enabled bool `default:"false"`
@@ -51,12 +147,27 @@ type Config struct {
 		Enabled bool `default:"false"`
 		Path    string
 	}
-	Security struct {
-		AuthPath    []string `yaml:"auth_path"`
-		ExcludePath []string `yaml:"exclude_path"`
-		UserPath    []string `yaml:"user_path"`
-		AdminPath   []string `yaml:"admin_path"`
-	}
+}
+
+// DatabaseReplica represents a read replica of the database.
+// The database name, login id and password of the primary are used if they are empty.
+type DatabaseReplica struct {
+	Host     string
+	Port     string
+	Dbname   string
+	LoginId  string `yaml:"loginId"`
+	Password string
+}
+
+// APIVersion represents the deprecation of an API version, which is announced by the headers of its responses.
+// The zero time omits the header.
+type APIVersion struct {
+	// Deprecation is the time when the version is deprecated. It is sent in the Deprecation header.
+	Deprecation time.Time
+	// Sunset is the time when the version will be removed. It is sent in the Sunset header.
+	Sunset time.Time
+	// Link is the URL of the migration guide. It is sent in the Link header.
+	Link string
 }
 
 const (
@@ -70,12 +181,13 @@ const (
 
 // LoadAppConfig reads the settings written to the yml file
 func LoadAppConfig(yamlFile embed.FS) (*Config, string) {
-	var env *string
+	// the flags are parsed even if the environment variable is set, to leave the subcommand in flag.Args()
+	env := flag.String("env", "develop", "To switch configurations.")
+	if !flag.Parsed() {
+		flag.Parse()
+	}
 	if value := os.Getenv("WEB_APP_ENV"); value != "" {
 		env = &value
-	} else {
-		env = flag.String("env", "develop", "To switch configurations.")
-		flag.Parse()
 	}
 
 	file, err := yamlFile.ReadFile(fmt.Sprintf(AppConfigPath, *env))
@@ -89,10 +201,25 @@ func LoadAppConfig(yamlFile embed.FS) (*Config, string) {
 		fmt.Printf("Failed to read application.%s.yml: %s", *env, err)
 		os.Exit(ErrExitStatus)
 	}
+	loadBootstrapEnv(config)
 
 	return config, *env
 }
 
+// loadBootstrapEnv overrides the first admin account by the environment variables,
+// so that its password is not written to the yml file.
+func loadBootstrapEnv(config *Config) {
+	if value := os.Getenv(EnvBootstrapAdminLoginId); value != "" {
+		config.Bootstrap.Admin.LoginId = value
+	}
+	if value := os.Getenv(EnvBootstrapAdminEmail); value != "" {
+		config.Bootstrap.Admin.Email = value
+	}
+	if value := os.Getenv(EnvBootstrapAdminPassword); value != "" {
+		config.Bootstrap.Admin.Password = value
+	}
+}
+
 // LoadMessagesConfig loads the messages.properties.
 func LoadMessagesConfig(propsFile embed.FS) map[string]string {
 	messages := util.ReadPropertiesFile(propsFile, MessagesConfigPath)
@@ -9,26 +9,168 @@ const (
 	EmailTemplatesPath        = "resources/email"
 	FindLoginIdTemplate       = "find-login-id.html"
 	EmailVerificationTemplate = "email-verification.html"
+	AccountRestoreTemplate    = "account-restore.html"
+	ExportReadyTemplate       = "export-ready.html"
+	TestEmailTemplate         = "test-email.html"
 
 	AppConfigPath      = "resources/config/application.%s.yml"
 	MessagesConfigPath = "resources/config/messages.properties"
 	LoggerConfigPath   = "resources/config/zaplogger.%s.yml"
+	SeedFixturesPath   = "resources/seed"
 )
 
 // Constant about account&auth domain
 const (
 	PasswordHashCost               int           = 10
+	PasswordMinLength              int           = 8
+	PasswordMaxLength              int           = 72
+	LoginIdMaxLength               int           = 32
+	EmailMaxLength                 int           = 254
 	MaxLoginAttempts               int           = 5
 	EmailVerificationTokenLength   int           = 6
 	EmailVerificationTokenLifetime time.Duration = 3 * time.Minute
 	EmailVerificationLifetime      time.Duration = 3 * time.Minute
+	DisplayNameMaxLength           int           = 50
+	BioMaxLength                   int           = 500
+	AccountDeletionGraceDays       int           = 30
+	AccountRestoreTokenLength      int           = 32
+	AccountPurgeInterval           time.Duration = time.Hour
+	SetupTokenLength               int           = 32
+	SetupTokenLifetime             time.Duration = 24 * time.Hour
+)
+
+// The environment variables of the first admin account, which override the bootstrap configuration.
+const (
+	EnvBootstrapAdminLoginId  string = "BISTORY_ADMIN_LOGIN_ID"
+	EnvBootstrapAdminEmail    string = "BISTORY_ADMIN_EMAIL"
+	EnvBootstrapAdminPassword string = "BISTORY_ADMIN_PASSWORD"
+)
+
+// Constant about personal access token
+const (
+	AccessTokenPrefix        string = "bst_"
+	AccessTokenLength        int    = 40
+	AccessTokenNameMaxLength int    = 100
+)
+
+// Constant about background jobs
+const (
+	SchedulerPollInterval time.Duration = 10 * time.Second
+	SchedulerBatchSize    int           = 10
+	JobLockTimeout        time.Duration = 10 * time.Minute
+	JobLockHeartbeat      time.Duration = time.Minute
+	JobMaxAttempts        uint          = 3
+	JobRetryDelay         time.Duration = time.Minute
+)
+
+// Constant about database
+const (
+	DefaultQueryTimeout  time.Duration = 10 * time.Second
+	DefaultSlowThreshold time.Duration = 200 * time.Millisecond
+
+	DatabaseSSLModeDisable string = "disable"
+)
+
+// Constant about idempotent requests
+const (
+	HeaderIdempotencyKey      string        = "Idempotency-Key"
+	HeaderIdempotentReplayed  string        = "Idempotent-Replayed"
+	IdempotencyKeyMaxLength   int           = 255
+	IdempotencyTTL            time.Duration = 24 * time.Hour
+	IdempotencyLockTimeout    time.Duration = time.Minute
+	IdempotencyRedisKeyPrefix string        = "idempotency:"
+)
+
+// Constant about session
+const (
+	SessionIndexRedisKeyPrefix string = "session-index:account:"
+)
+
+// Constant about conditional requests
+const (
+	HeaderETag        string = "ETag"
+	HeaderIfMatch     string = "If-Match"
+	HeaderIfNoneMatch string = "If-None-Match"
+)
+
+// Constant about the deprecation of API versions
+const (
+	HeaderDeprecation string = "Deprecation"
+	HeaderSunset      string = "Sunset"
+	HeaderLink        string = "Link"
+)
+
+// Constant about request tracing
+const (
+	RequestIdLength    int = 32
+	RequestIdMaxLength int = 128
+)
+
+// Constant about logging
+const (
+	LogBodyMaxSize int = 4096
+
+	// The components of the logger, whose levels are changed individually at runtime.
+	LogComponentApp     string = "app"
+	LogComponentHTTP    string = "http"
+	LogComponentGorm    string = "gorm"
+	LogComponentSession string = "session"
+	LogComponentEmail   string = "email"
+)
+
+// Constant about tracing
+const (
+	TracerName              string        = "github.com/onetooler/bistory-backend"
+	TracingExporterOTLP     string        = "otlp"
+	TracingExporterStdout   string        = "stdout"
+	TracingSampleRatio      float64       = 1
+	TracingServiceName      string        = "bistory-backend"
+	TracingShutdownDeadline time.Duration = 5 * time.Second
+)
+
+// Constant about list endpoints
+const (
+	PageDefaultSize  int    = 20
+	PageMaxSize      int    = 100
+	QueryParamPage   string = "page"
+	QueryParamSize   string = "size"
+	QueryParamCursor string = "cursor"
+	QueryParamSort   string = "sort"
+)
+
+// Constant about file storage
+const (
+	StorageTypeLocal    string        = "local"
+	StorageTypeS3       string        = "s3"
+	SignedURLLifetime   time.Duration = 15 * time.Minute
+	AvatarMaxSize       int64         = 5 << 20
+	AvatarMaxDimension  int           = 4096
+	AvatarFormField     string        = "avatar"
+	AvatarSizeLarge     int           = 256
+	AvatarSizeSmall     int           = 64
+	AvatarStoragePrefix string        = "avatars"
+)
+
+// Constant about personal data export
+const (
+	ExportStoragePrefix string        = "exports"
+	ExportLinkLifetime  time.Duration = 24 * time.Hour
+	ExportRetention     time.Duration = 7 * 24 * time.Hour
 )
 
 const (
 	// API represents the group of API.
+	// The routes are declared by the paths under it, and registered under the path of each version, such as /api/v1.
 	API = "/api"
+
+	// APIVersion1 is also served at the paths without the version for the clients before the versioning.
+	APIVersion1 = "v1"
+	APIVersion2 = "v2"
 )
 
+// APIVersions are the versions of API from the oldest. The last one is the latest.
+var APIVersions = []string{APIVersion1, APIVersion2}
+
 const (
 	// APIAuth represents the group of auth management API.
 	// APIAuth is a part of account but separate API for concentrate scope
@@ -37,6 +179,7 @@ const (
 	APIAuthLoginAccount = APIAuth + "/loginAccount"
 	APIAuthLogin        = APIAuth + "/login"
 	APIAuthLogout       = APIAuth + "/logout"
+	APIAuthSetup        = APIAuth + "/setup"
 
 	APIAuthEmailVerificationTokenSend = APIAuth + "/email-verification/token-generate"
 	APIAuthVerifyEmail                = APIAuth + "/email-verification/token-verify"
@@ -46,10 +189,54 @@ const (
 	// APIAccount represents the group of account management API.
 	APIAccount               = API + "/account"
 	APIAccountFindLoginId    = APIAccount + "/find-login-id"
+	APIAccountRestore        = APIAccount + "/restore"
 	APIAccountIdParam        = "id"
 	APIAccountLoginIdParam   = "loginid"
 	APIAccountIdPath         = APIAccount + "/:" + APIAccountIdParam
 	APIAccountChangePassword = APIAccountIdPath + "/change-password"
+
+	APIAccountTokens       = APIAccountIdPath + "/tokens"
+	APIAccountTokenIdParam = "tokenId"
+	APIAccountTokenIdPath  = APIAccountTokens + "/:" + APIAccountTokenIdParam
+
+	APIAccountAvatar = APIAccountIdPath + "/avatar"
+
+	APIAccountExports        = APIAccountIdPath + "/export"
+	APIAccountExportIdParam  = "exportId"
+	APIAccountExportIdPath   = APIAccountExports + "/:" + APIAccountExportIdParam
+	APIAccountExportDownload = APIAccountExportIdPath + "/download"
+
+	APIAccountRoles         = APIAccountIdPath + "/roles"
+	APIAccountRoleNameParam = "role"
+	APIAccountRoleNamePath  = APIAccountRoles + "/:" + APIAccountRoleNameParam
+)
+
+const (
+	// APIFiles represents the API to download the files of the local storage by signed URLs.
+	APIFiles         = API + "/files"
+	APIFilesKeyParam = "*"
+	APIFilesKeyPath  = APIFiles + "/" + APIFilesKeyParam
+)
+
+const (
+	// APIRole represents the group of role management API.
+	APIRole = API + "/role"
+)
+
+const (
+	// APIAdmin represents the group of administration API.
+	APIAdmin       = API + "/admin"
+	APIAdminRoutes = APIAdmin + "/routes"
+
+	APIAdminLogLevels             = APIAdmin + "/log-levels"
+	APIAdminLogComponentParam     = "component"
+	APIAdminLogLevelComponentPath = APIAdminLogLevels + "/:" + APIAdminLogComponentParam
+
+	APIAdminJobs       = APIAdmin + "/jobs"
+	APIAdminJobIdParam = "jobId"
+	APIAdminJobIdPath  = APIAdminJobs + "/:" + APIAdminJobIdParam
+	APIAdminJobRuns    = APIAdminJobIdPath + "/runs"
+	APIAdminJobTrigger = APIAdminJobIdPath + "/trigger"
 )
 
 const (
@@ -11,6 +11,9 @@ type Container interface {
 	GetRepository() infrastructure.Repository
 	GetSession() infrastructure.Session
 	GetEmailSender() infrastructure.EmailSender
+	GetStorage() infrastructure.Storage
+	GetScheduler() infrastructure.Scheduler
+	GetIdempotencyStore() infrastructure.IdempotencyStore
 	GetConfig() *config.Config
 	GetMessages() map[string]string
 	GetLogger() logger.Logger
@@ -22,6 +25,9 @@ type container struct {
 	rep         infrastructure.Repository
 	session     infrastructure.Session
 	emailSender infrastructure.EmailSender
+	storage     infrastructure.Storage
+	scheduler   infrastructure.Scheduler
+	idempotency infrastructure.IdempotencyStore
 	config      *config.Config
 	messages    map[string]string
 	logger      logger.Logger
@@ -33,6 +39,9 @@ func NewContainer(
 	rep infrastructure.Repository,
 	session infrastructure.Session,
 	emailSender infrastructure.EmailSender,
+	storage infrastructure.Storage,
+	scheduler infrastructure.Scheduler,
+	idempotency infrastructure.IdempotencyStore,
 	config *config.Config,
 	messages map[string]string,
 	logger logger.Logger,
@@ -42,6 +51,9 @@ func NewContainer(
 		rep:         rep,
 		session:     session,
 		emailSender: emailSender,
+		storage:     storage,
+		scheduler:   scheduler,
+		idempotency: idempotency,
 		config:      config,
 		messages:    messages,
 		logger:      logger,
@@ -63,6 +75,21 @@ func (c *container) GetEmailSender() infrastructure.EmailSender {
 	return c.emailSender
 }
 
+// GetStorage returns the object of file storage.
+func (c *container) GetStorage() infrastructure.Storage {
+	return c.storage
+}
+
+// GetScheduler returns the object of background job scheduler.
+func (c *container) GetScheduler() infrastructure.Scheduler {
+	return c.scheduler
+}
+
+// GetIdempotencyStore returns the object of the store of the idempotency keys.
+func (c *container) GetIdempotencyStore() infrastructure.IdempotencyStore {
+	return c.idempotency
+}
+
 // GetConfig returns the object of configuration.
 func (c *container) GetConfig() *config.Config {
 	return c.config
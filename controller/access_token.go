@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/service"
+	"github.com/onetooler/bistory-backend/util"
+)
+
+// AccessTokenController is a controller for managing personal access tokens.
+type AccessTokenController interface {
+	GetAccessTokens(c echo.Context) error
+	CreateAccessToken(c echo.Context) error
+	RevokeAccessToken(c echo.Context) error
+}
+
+type accessTokenController struct {
+	container container.Container
+	service   service.AccessTokenService
+}
+
+// NewAccessTokenController is constructor.
+func NewAccessTokenController(container container.Container) AccessTokenController {
+	return &accessTokenController{container: container, service: service.NewAccessTokenService(container)}
+}
+
+// GetAccessTokens returns the access tokens of the account.
+// @Summary Get the access tokens of a account
+// @Description Get the access tokens of a account. The token values are not included.
+// @Tags AccessToken
+// @Accept  json
+// @Produce  json
+// @Param accountId path int true "Account ID"
+// @Param page query int false "Page number, starting from 1"
+// @Param size query int false "Page size, up to 100"
+// @Param cursor query string false "Cursor of the next page. An empty cursor requests the first page in the cursor mode."
+// @Param sort query string false "Field to sort by, descending with - prefix"
+// @Success 200 {object} dto.Page[model.AccessToken] "Success to fetch data."
+// @Failure 400 {string} message "Failed to fetch data."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Router /account/{accountId}/tokens [get]
+func (controller *accessTokenController) GetAccessTokens(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	if accountId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	query, err := infrastructure.ParseQuery(c.QueryParams(), service.AccessTokenQuerySpec)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	tokens, err := controller.service.GetAccessTokens(accountId, query)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// CreateAccessToken creates a new access token by http post.
+// @Summary Create a new access token
+// @Description Create a new access token. The token value is returned only once.
+// @Tags AccessToken
+// @Accept  json
+// @Produce  json
+// @Param accountId path int true "Account ID"
+// @Param data body dto.CreateAccessTokenDto true "a new access token data for creating"
+// @Success 200 {object} dto.CreatedAccessTokenDto "Success to create a new access token."
+// @Failure 400 {string} message "Failed to create a new access token."
+// @Failure 403 {boolean} bool "Failed to the authorization, or the scopes are not granted to the access token of the caller. Returns false."
+// @Failure 422 {object} APIError "The request is not valid. Returns the errors of the fields."
+// @Router /account/{accountId}/tokens [post]
+func (controller *accessTokenController) CreateAccessToken(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	if accountId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	data := dto.NewCreateAccessTokenDto()
+	if err := c.Bind(data); err != nil {
+		return bindError(c, err)
+	}
+	token, err := controller.service.CreateAccessToken(accountId, data, controller.container.GetSession().GetAccount(c))
+	if errors.Is(err, service.ErrScopeNotGranted) {
+		return c.JSON(http.StatusForbidden, false)
+	} else if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	// the token value must not be stored by the caches or for the retries
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	return c.JSON(http.StatusOK, token)
+}
+
+// RevokeAccessToken revokes the existing access token by http delete.
+// @Summary Revoke the existing access token
+// @Description Revoke the existing access token
+// @Tags AccessToken
+// @Accept  json
+// @Produce  json
+// @Param accountId path int true "Account ID"
+// @Param tokenId path int true "Access token ID"
+// @Success 200 {boolean} bool "Success to revoke the access token."
+// @Failure 400 {string} message "Failed to revoke the access token."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Failure 404 {string} message "The access token is not found."
+// @Router /account/{accountId}/tokens/{tokenId} [delete]
+func (controller *accessTokenController) RevokeAccessToken(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	tokenId := util.ConvertToUint(c.Param(config.APIAccountTokenIdParam))
+	if accountId == 0 || tokenId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	err := controller.service.RevokeAccessToken(accountId, tokenId)
+	if errors.Is(err, infrastructure.ErrNotFound) {
+		return c.String(http.StatusNotFound, "access token not found")
+	}
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, true)
+}
@@ -0,0 +1,300 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/middleware"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAccessTokenService struct {
+	createAccessToken func(uint, *dto.CreateAccessTokenDto, *infrastructure.Account) (*dto.CreatedAccessTokenDto, error)
+	getAccessTokens   func(uint, *infrastructure.Query) (*dto.Page[model.AccessToken], error)
+	revokeAccessToken func(uint, uint) error
+}
+
+func (m *mockAccessTokenService) CreateAccessToken(accountId uint, createAccessTokenDto *dto.CreateAccessTokenDto, caller *infrastructure.Account) (*dto.CreatedAccessTokenDto, error) {
+	return m.createAccessToken(accountId, createAccessTokenDto, caller)
+}
+
+func (m *mockAccessTokenService) GetAccessTokens(accountId uint, query *infrastructure.Query) (*dto.Page[model.AccessToken], error) {
+	return m.getAccessTokens(accountId, query)
+}
+
+func (m *mockAccessTokenService) RevokeAccessToken(accountId uint, tokenId uint) error {
+	return m.revokeAccessToken(accountId, tokenId)
+}
+
+func (m *mockAccessTokenService) DeleteExpiredAccessTokens(now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestCreateAccessToken_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	token := accessTokenController{
+		container,
+		&mockAccessTokenService{
+			createAccessToken: func(accountId uint, createAccessTokenDto *dto.CreateAccessTokenDto, caller *infrastructure.Account) (*dto.CreatedAccessTokenDto, error) {
+				accessToken, plain := model.NewAccessToken(accountId, createAccessTokenDto.Name, createAccessTokenDto.Scopes, nil)
+				accessToken.ID = 1
+				return &dto.CreatedAccessTokenDto{AccessToken: accessToken, Token: plain}, nil
+			},
+		},
+	}
+	router.POST(config.APIAccountTokens, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return token.CreateAccessToken(c)
+	})
+
+	param := dto.CreateAccessTokenDto{Name: "script", Scopes: []string{model.ScopeAccountRead}}
+	req := testutil.NewJSONRequest(http.MethodPost, accessTokensPath(testAccount.ID), param)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-store", rec.Header().Get(echo.HeaderCacheControl))
+
+	body := map[string]any{}
+	err := json.Unmarshal(rec.Body.Bytes(), &body)
+	assert.Nil(t, err)
+	assert.Equal(t, "script", body["name"])
+	assert.True(t, strings.HasPrefix(body["token"].(string), config.AccessTokenPrefix))
+	assert.NotContains(t, body, "TokenHash")
+}
+
+func TestCreateAccessToken_ScopeNotGrantedFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+	testAccount, plain := createAccessTokenAccount(container, []string{model.ScopeTokenWrite}, nil)
+
+	token := NewAccessTokenController(container)
+	policy := middleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionTokenWrite).WithScope(model.ScopeTokenWrite)
+	router.POST(config.APIAccountTokens, func(c echo.Context) error { return token.CreateAccessToken(c) },
+		middleware.NewRouteTable().Declare(container, http.MethodPost, config.APIAccountTokens, policy))
+
+	statuses := map[string]int{model.ScopeAccountWrite: http.StatusForbidden, model.ScopeTokenWrite: http.StatusOK}
+	for scope, status := range statuses {
+		param := dto.CreateAccessTokenDto{Name: "script", Scopes: []string{scope}}
+		req := testutil.NewJSONRequest(http.MethodPost, accessTokensPath(testAccount.ID), param)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+plain)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, status, rec.Code, scope)
+	}
+}
+
+func TestGetAccessTokens_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	token := accessTokenController{
+		container,
+		&mockAccessTokenService{
+			getAccessTokens: func(accountId uint, query *infrastructure.Query) (*dto.Page[model.AccessToken], error) {
+				accessToken, _ := model.NewAccessToken(accountId, "script", []string{model.ScopeAccountRead}, nil)
+				return &dto.Page[model.AccessToken]{Items: []model.AccessToken{*accessToken}, Total: 1, Page: query.Page, Size: query.Size}, nil
+			},
+		},
+	}
+	router.GET(config.APIAccountTokens, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return token.GetAccessTokens(c)
+	})
+
+	req := testutil.NewJSONRequest(http.MethodGet, accessTokensPath(testAccount.ID), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	body := dto.Page[model.AccessToken]{}
+	err := json.Unmarshal(rec.Body.Bytes(), &body)
+	assert.Nil(t, err)
+	assert.Len(t, body.Items, 1)
+	assert.Equal(t, int64(1), body.Total)
+	assert.Equal(t, 1, body.Page)
+	assert.Equal(t, config.PageDefaultSize, body.Size)
+	assert.Empty(t, body.Items[0].TokenHash)
+}
+
+func TestGetAccessTokens_InvalidQueryFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	token := accessTokenController{container, &mockAccessTokenService{}}
+	router.GET(config.APIAccountTokens, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return token.GetAccessTokens(c)
+	})
+
+	req := testutil.NewJSONRequest(http.MethodGet, accessTokensPath(testAccount.ID)+"?sort=tokenHash", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRevokeAccessToken_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	token := accessTokenController{
+		container,
+		&mockAccessTokenService{
+			revokeAccessToken: func(accountId uint, tokenId uint) error {
+				return nil
+			},
+		},
+	}
+	router.DELETE(config.APIAccountTokenIdPath, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return token.RevokeAccessToken(c)
+	})
+
+	req := testutil.NewJSONRequest(http.MethodDelete, fmt.Sprintf("%s/%d", accessTokensPath(testAccount.ID), 1), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRevokeAccessToken_NotFoundFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	token := accessTokenController{
+		container,
+		&mockAccessTokenService{
+			revokeAccessToken: func(accountId uint, tokenId uint) error {
+				return infrastructure.ErrNotFound
+			},
+		},
+	}
+	router.DELETE(config.APIAccountTokenIdPath, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return token.RevokeAccessToken(c)
+	})
+
+	req := testutil.NewJSONRequest(http.MethodDelete, fmt.Sprintf("%s/%d", accessTokensPath(testAccount.ID), 1), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAccessTokenAuthentication_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+	testAccount, plain := createAccessTokenAccount(container, []string{model.ScopeAccountRead}, nil)
+
+	account := accountController{
+		container,
+		&mockService{
+			getAccount: func(accountId uint) (*model.Account, error) {
+				return testAccount, nil
+			},
+		},
+	}
+	router.GET(config.APIAccountIdPath, func(c echo.Context) error { return account.GetAccount(c) }, getAccountPolicy(container))
+
+	req := testutil.NewJSONRequest(http.MethodGet, fmt.Sprintf("%s/%d", config.APIAccount, testAccount.ID), nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+plain)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, testutil.GetCookie(rec, "GSESSION"))
+
+	token := model.AccessToken{}
+	container.GetRepository().First(&token)
+	assert.NotNil(t, token.LastUsedAt)
+}
+
+func TestAccessTokenAuthentication_ScopeFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+	testAccount, plain := createAccessTokenAccount(container, []string{model.ScopeTokenRead}, nil)
+
+	account := accountController{container, &mockService{}}
+	router.GET(config.APIAccountIdPath, func(c echo.Context) error { return account.GetAccount(c) }, getAccountPolicy(container))
+
+	req := testutil.NewJSONRequest(http.MethodGet, fmt.Sprintf("%s/%d", config.APIAccount, testAccount.ID), nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+plain)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestAccessTokenAuthentication_ExpiredFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+	expiresAt := time.Now().Add(-time.Minute)
+	testAccount, plain := createAccessTokenAccount(container, []string{model.ScopeAccountRead}, &expiresAt)
+
+	account := accountController{container, &mockService{}}
+	router.GET(config.APIAccountIdPath, func(c echo.Context) error { return account.GetAccount(c) }, getAccountPolicy(container))
+
+	req := testutil.NewJSONRequest(http.MethodGet, fmt.Sprintf("%s/%d", config.APIAccount, testAccount.ID), nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+plain)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAccessTokenAuthentication_UnknownTokenFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	account := accountController{container, &mockService{}}
+	router.GET(config.APIAccountIdPath, func(c echo.Context) error { return account.GetAccount(c) }, getAccountPolicy(container))
+
+	req := testutil.NewJSONRequest(http.MethodGet, fmt.Sprintf("%s/%d", config.APIAccount, 1), nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+config.AccessTokenPrefix+"unknown")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func getAccountPolicy(testcontainer container.Container) echo.MiddlewareFunc {
+	policy := middleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountRead).WithScope(model.ScopeAccountRead)
+	return middleware.NewRouteTable().Declare(testcontainer, http.MethodGet, config.APIAccountIdPath, policy)
+}
+
+func accessTokensPath(accountId uint) string {
+	return strings.Replace(config.APIAccountTokens, ":"+config.APIAccountIdParam, strconv.Itoa(int(accountId)), 1)
+}
+
+func createAccessTokenAccount(testcontainer container.Container, scopes []string, expiresAt *time.Time) (*model.Account, string) {
+	repo := testcontainer.GetRepository()
+	account, _ := model.NewAccountWithPasswordEncrypt(context.Background(), "tokenTest", "tokenTest@example.com", "tokenTestTest", model.AuthorityUser)
+	repo.Create(account)
+	token, plain := model.NewAccessToken(account.ID, "script", scopes, expiresAt)
+	repo.Create(token)
+	return account, plain
+}
@@ -1,11 +1,14 @@
 package controller
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	"github.com/onetooler/bistory-backend/config"
 	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
 	"github.com/onetooler/bistory-backend/model"
 	"github.com/onetooler/bistory-backend/model/dto"
 	"github.com/onetooler/bistory-backend/service"
@@ -15,9 +18,12 @@ import (
 // AccountController is a controller for managing accounts.
 type AccountController interface {
 	GetAccount(c echo.Context) error
+	GetAccounts(c echo.Context) error
 	CreateAccount(c echo.Context) error
 	ChangeAccountPassword(c echo.Context) error
+	UpdateAccountProfile(c echo.Context) error
 	DeleteAccount(c echo.Context) error
+	RestoreAccount(c echo.Context) error
 	FindLoginId(c echo.Context) error
 }
 
@@ -38,24 +44,65 @@ func NewAccountController(container container.Container) AccountController {
 // @Accept  json
 // @Produce  json
 // @Param accountId path int true "Account ID"
+// @Param If-None-Match header string false "ETag of the account which the client has cached"
 // @Success 200 {object} model.Account "Success to fetch data."
+// @Success 304 "The account is not modified since the ETag."
+// @Header 200,304 {string} ETag "ETag of the account"
 // @Failure 400 {string} message "Failed to fetch data."
 // @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 404 {string} message "The account is not found."
+// @Failure 503 {object} APIError "The database is unavailable."
+// @Failure 504 {object} APIError "The request timed out."
 // @Router /account/{accountId} [get]
 func (controller *accountController) GetAccount(c echo.Context) error {
 	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
 	if accountId == 0 {
 		return c.String(http.StatusBadRequest, "failed to parse id")
 	}
-	if !controller.container.GetSession().HasAuthorizationTo(c, accountId, uint(model.AuthorityUser)) {
-		return c.JSON(http.StatusForbidden, false)
+
+	account, err := controller.service.GetAccount(c.Request().Context(), accountId)
+	if errors.Is(err, infrastructure.ErrNotFound) {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return serviceError(c, http.StatusBadRequest, err)
 	}
+	setETag(c, account.ETag())
+	if notModified(c, account.ETag()) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSON(http.StatusOK, account)
+}
 
-	account, err := controller.service.GetAccount(accountId)
+// GetAccounts returns a page of the accounts.
+// @Summary Get the accounts
+// @Description Get a page of the accounts
+// @Tags Account
+// @Accept  json
+// @Produce  json
+// @Param status query string false "Statuses to filter by, separated by commas"
+// @Param authority query string false "Authorities to filter by, separated by commas"
+// @Param page query int false "Page number, starting from 1"
+// @Param size query int false "Page size, up to 100"
+// @Param cursor query string false "Cursor of the next page. An empty cursor requests the first page in the cursor mode."
+// @Param sort query string false "Field to sort by, descending with - prefix"
+// @Success 200 {object} dto.Page[model.Account] "Success to fetch data."
+// @Failure 400 {string} message "Failed to fetch data."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Failure 503 {object} APIError "The database is unavailable."
+// @Failure 504 {object} APIError "The request timed out."
+// @Router /account [get]
+func (controller *accountController) GetAccounts(c echo.Context) error {
+	query, err := infrastructure.ParseQuery(c.QueryParams(), service.AccountQuerySpec)
 	if err != nil {
 		return c.String(http.StatusBadRequest, err.Error())
 	}
-	return c.JSON(http.StatusOK, account)
+	accounts, err := controller.service.GetAccounts(c.Request().Context(), query)
+	if err != nil {
+		return serviceError(c, http.StatusBadRequest, err)
+	}
+	return c.JSON(http.StatusOK, accounts)
 }
 
 // CreateAccount create a new account by http post.
@@ -66,8 +113,12 @@ func (controller *accountController) GetAccount(c echo.Context) error {
 // @Produce  json
 // @Param data body dto.CreateAccountDto true "a new account data for creating"
 // @Success 200 {object} model.Account "Success to create a new account."
+// @Header 200 {string} ETag "ETag of the account"
 // @Failure 400 {string} message "Failed to the registration."
 // @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 422 {object} APIError "The request is not valid. Returns the errors of the fields."
+// @Failure 503 {object} APIError "The database is unavailable."
+// @Failure 504 {object} APIError "The request timed out."
 // @Router /account [post]
 func (controller *accountController) CreateAccount(c echo.Context) error {
 	if controller.container.GetSession().GetAccount(c) != nil {
@@ -75,18 +126,19 @@ func (controller *accountController) CreateAccount(c echo.Context) error {
 	}
 	data := dto.NewCreateAccountDto()
 	if err := c.Bind(data); err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return bindError(c, err)
 	}
 	_, err := controller.container.GetSession().IsVerifiedEmail(c, data.Email)
 	if err != nil {
 		return c.String(http.StatusBadRequest, err.Error())
 	}
-	account, err := controller.service.CreateAccount(data)
+	account, err := controller.service.CreateAccount(c.Request().Context(), data)
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return serviceError(c, http.StatusBadRequest, err)
 	}
 	_ = controller.container.GetSession().SetEmailVerification(c, nil)
 	_ = controller.container.GetSession().Delete(c)
+	setETag(c, account.ETag())
 	return c.JSON(http.StatusOK, account)
 }
 
@@ -98,26 +150,41 @@ func (controller *accountController) CreateAccount(c echo.Context) error {
 // @Produce  json
 // @Param accountId path int true "Account ID"
 // @Param data body dto.ChangeAccountPasswordDto true "the account password data for updating"
+// @Param If-Match header string false "ETag of the account which the client has read"
 // @Success 200 {object} model.Account "Success to change the account password."
+// @Header 200 {string} ETag "ETag of the updated account"
 // @Failure 400 {string} message "Failed to the update."
 // @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 412 {string} message "The account has been modified since the ETag."
+// @Failure 422 {object} APIError "The request is not valid. Returns the errors of the fields."
+// @Failure 503 {object} APIError "The database is unavailable."
+// @Failure 504 {object} APIError "The request timed out."
 // @Router /account/{accountId}/ [post]
 func (controller *accountController) ChangeAccountPassword(c echo.Context) error {
 	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
 	if accountId == 0 {
 		return c.String(http.StatusBadRequest, "failed to parse id")
 	}
-	if !controller.container.GetSession().HasAuthorizationTo(c, accountId, uint(model.AuthorityUser)) {
-		return c.JSON(http.StatusForbidden, false)
-	}
 
 	data := dto.NewChangeAccountPasswordDto()
 	if err := c.Bind(data); err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return bindError(c, err)
+	}
+	current, err := controller.checkIfMatch(c, accountId)
+	if errors.Is(err, errPreconditionFailed) {
+		return c.String(http.StatusPreconditionFailed, err.Error())
+	} else if err != nil {
+		return serviceError(c, http.StatusBadRequest, err)
+	}
+	if current != nil {
+		data.Version = current.Version
+	}
+	account, err := controller.service.ChangeAccountPassword(c.Request().Context(), accountId, data)
+	if errors.Is(err, service.ErrVersionConflict) {
+		return c.String(http.StatusPreconditionFailed, errPreconditionFailed.Error())
 	}
-	account, err := controller.service.ChangeAccountPassword(accountId, data)
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return serviceError(c, http.StatusBadRequest, err)
 	}
 
 	err = controller.container.GetSession().Logout(c)
@@ -125,47 +192,150 @@ func (controller *accountController) ChangeAccountPassword(c echo.Context) error
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
+	setETag(c, account.ETag())
+	return c.JSON(http.StatusOK, account)
+}
+
+// UpdateAccountProfile updates the profile fields present in the request by http patch.
+// @Summary Update account profile
+// @Description Update account profile. Only the fields present in the body are changed, and the version must match the current one.
+// @Description If the If-Match header is given, it is checked instead of the version.
+// @Tags Account
+// @Accept  json
+// @Produce  json
+// @Param accountId path int true "Account ID"
+// @Param data body dto.UpdateAccountProfileDto true "the account profile data for updating"
+// @Param If-Match header string false "ETag of the account which the client has read"
+// @Success 200 {object} model.Account "Success to update the account profile."
+// @Header 200 {string} ETag "ETag of the updated account"
+// @Failure 400 {string} message "Failed to the update."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 409 {string} message "The account has been modified by another request."
+// @Failure 412 {string} message "The account has been modified since the ETag."
+// @Failure 422 {object} APIError "The request is not valid. Returns the errors of the fields."
+// @Failure 503 {object} APIError "The database is unavailable."
+// @Failure 504 {object} APIError "The request timed out."
+// @Router /account/{accountId} [patch]
+func (controller *accountController) UpdateAccountProfile(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	if accountId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	data := dto.NewUpdateAccountProfileDto()
+	if err := c.Bind(data); err != nil {
+		return bindError(c, err)
+	}
+	current, err := controller.checkIfMatch(c, accountId)
+	if errors.Is(err, errPreconditionFailed) {
+		return c.String(http.StatusPreconditionFailed, err.Error())
+	} else if err != nil {
+		return serviceError(c, http.StatusBadRequest, err)
+	}
+	if current != nil {
+		// the version of the matched account is updated, so a concurrent update after the check also fails the precondition
+		data.Version = current.Version
+	}
+	account, err := controller.service.UpdateAccountProfile(c.Request().Context(), accountId, data)
+	if errors.Is(err, service.ErrVersionConflict) && current != nil {
+		return c.String(http.StatusPreconditionFailed, errPreconditionFailed.Error())
+	}
+	if errors.Is(err, service.ErrVersionConflict) {
+		return c.String(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return serviceError(c, http.StatusBadRequest, err)
+	}
+	setETag(c, account.ETag())
 	return c.JSON(http.StatusOK, account)
 }
 
-// DeleteAccount deletes the existing account by http delete.
+// DeleteAccount schedules the deletion of the existing account by http delete.
 // @Summary Delete the existing account
-// @Description Delete the existing account
+// @Description Schedule the deletion of the existing account. It can be restored by logging in or by the restore token sent by email until it is purged.
 // @Tags Account
 // @Accept  json
 // @Produce  json
 // @Param accountId path int true "Account ID"
 // @Param data body dto.DeleteAccountDto true "the account password data for updating"
+// @Param If-Match header string false "ETag of the account which the client has read"
 // @Success 200 {boolean} bool "Success to delete the existing account."
 // @Failure 400 {string} message "Failed to the delete."
 // @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 412 {string} message "The account has been modified since the ETag."
+// @Failure 422 {object} APIError "The request is not valid. Returns the errors of the fields."
+// @Failure 503 {object} APIError "The database is unavailable."
+// @Failure 504 {object} APIError "The request timed out."
 // @Router /account/{accountId} [delete]
 func (controller *accountController) DeleteAccount(c echo.Context) error {
 	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
 	if accountId == 0 {
 		return c.String(http.StatusBadRequest, "failed to parse id")
 	}
-	if !controller.container.GetSession().HasAuthorizationTo(c, accountId, uint(model.AuthorityUser)) {
-		return c.JSON(http.StatusForbidden, false)
-	}
 
 	data := dto.NewDeleteAccountDto()
 	if err := c.Bind(data); err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return bindError(c, err)
 	}
-	err := controller.service.DeleteAccount(accountId, data)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+	current, err := controller.checkIfMatch(c, accountId)
+	if errors.Is(err, errPreconditionFailed) {
+		return c.String(http.StatusPreconditionFailed, err.Error())
+	} else if err != nil {
+		return serviceError(c, http.StatusBadRequest, err)
+	}
+	if current != nil {
+		data.Version = current.Version
+	}
+	// the deletion is rolled back if the session fails to log out
+	var logoutErr error
+	err = infrastructure.InTransaction(c.Request().Context(), controller.container.GetRepository(), func(ctx context.Context) error {
+		if err := controller.service.DeleteAccount(ctx, accountId, data); err != nil {
+			return err
+		}
+		logoutErr = controller.container.GetSession().Logout(c)
+		return logoutErr
+	})
+	if logoutErr != nil {
+		return c.String(http.StatusInternalServerError, logoutErr.Error())
+	}
+	if errors.Is(err, service.ErrVersionConflict) {
+		return c.String(http.StatusPreconditionFailed, errPreconditionFailed.Error())
 	}
-
-	err = controller.container.GetSession().Logout(c)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return serviceError(c, http.StatusBadRequest, err)
 	}
 
 	return c.JSON(http.StatusOK, nil)
 }
 
+// RestoreAccount cancels the deletion of the account by the restore token sent by email.
+// @Summary Restore the account pending deletion
+// @Description Restore the account pending deletion
+// @Tags Account
+// @Accept  json
+// @Produce  json
+// @Param data body dto.RestoreAccountDto true "the restore token sent by email"
+// @Success 200 {object} model.Account "Success to restore the account."
+// @Header 200 {string} ETag "ETag of the account"
+// @Failure 400 {string} message "Failed to restore the account."
+// @Failure 422 {object} APIError "The request is not valid. Returns the errors of the fields."
+// @Failure 503 {object} APIError "The database is unavailable."
+// @Failure 504 {object} APIError "The request timed out."
+// @Router /account/restore [post]
+func (controller *accountController) RestoreAccount(c echo.Context) error {
+	data := dto.NewRestoreAccountDto()
+	if err := c.Bind(data); err != nil {
+		return bindError(c, err)
+	}
+
+	account, err := controller.service.RestoreAccount(c.Request().Context(), data)
+	if err != nil {
+		return serviceError(c, http.StatusBadRequest, err)
+	}
+	setETag(c, account.ETag())
+	return c.JSON(http.StatusOK, account)
+}
+
 // FindLoginId send email that contains account's login id to account's email address.
 // @Summary Find LoginId By Email
 // @Description Find LoginId By Email
@@ -176,6 +346,9 @@ func (controller *accountController) DeleteAccount(c echo.Context) error {
 // @Success 200 {boolean} bool "Success to send email."
 // @Failure 400 {string} message "Failed to send email."
 // @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 422 {object} APIError "The request is not valid. Returns the errors of the fields."
+// @Failure 503 {object} APIError "The database is unavailable."
+// @Failure 504 {object} APIError "The request timed out."
 // @Router /account/find-login-id [post]
 func (controller *accountController) FindLoginId(c echo.Context) error {
 	if controller.container.GetSession().GetAccount(c) != nil {
@@ -184,12 +357,28 @@ func (controller *accountController) FindLoginId(c echo.Context) error {
 
 	data := dto.NewFindLoginIdDto()
 	if err := c.Bind(data); err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return bindError(c, err)
 	}
 
-	err := controller.service.FindAccountByEmail(data)
+	err := controller.service.FindAccountByEmail(c.Request().Context(), data)
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return serviceError(c, http.StatusBadRequest, err)
 	}
 	return c.JSON(http.StatusOK, true)
 }
+
+// checkIfMatch returns the current account if the request has the If-Match header, or nil if it does not.
+// It returns errPreconditionFailed if the header does not match the account.
+func (controller *accountController) checkIfMatch(c echo.Context, accountId uint) (*model.Account, error) {
+	if !hasIfMatch(c) {
+		return nil, nil
+	}
+	account, err := controller.service.GetAccount(c.Request().Context(), accountId)
+	if err != nil {
+		return nil, err
+	}
+	if !matchesIfMatch(c, account.ETag()) {
+		return nil, errPreconditionFailed
+	}
+	return account, nil
+}
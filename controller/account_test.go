@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"context"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -14,8 +16,10 @@ import (
 	"github.com/onetooler/bistory-backend/config"
 	"github.com/onetooler/bistory-backend/container"
 	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/middleware"
 	"github.com/onetooler/bistory-backend/model"
 	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/service"
 	"github.com/onetooler/bistory-backend/testutil"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
@@ -24,31 +28,62 @@ import (
 type mockService struct {
 	createAccount         func(*dto.CreateAccountDto) (*model.Account, error)
 	changeAccountPassword func(uint, *dto.ChangeAccountPasswordDto) (*model.Account, error)
+	updateAccountProfile  func(uint, *dto.UpdateAccountProfileDto) (*model.Account, error)
 	deleteAccount         func(uint, *dto.DeleteAccountDto) error
+	restoreAccount        func(*dto.RestoreAccountDto) (*model.Account, error)
 	getAccount            func(uint) (*model.Account, error)
+	getAccounts           func(*infrastructure.Query) (*dto.Page[model.Account], error)
 	findAccountByEmail    func(*dto.FindLoginIdDto) error
 }
 
-func (m *mockService) CreateAccount(createAccountDto *dto.CreateAccountDto) (*model.Account, error) {
+func (m *mockService) CreateAccount(ctx context.Context, createAccountDto *dto.CreateAccountDto) (*model.Account, error) {
 	return m.createAccount(createAccountDto)
 }
 
-func (m *mockService) ChangeAccountPassword(id uint, UpdatePasswordDto *dto.ChangeAccountPasswordDto) (*model.Account, error) {
+func (m *mockService) ChangeAccountPassword(ctx context.Context, id uint, UpdatePasswordDto *dto.ChangeAccountPasswordDto) (*model.Account, error) {
 	return m.changeAccountPassword(id, UpdatePasswordDto)
 }
 
-func (m *mockService) DeleteAccount(id uint, dto *dto.DeleteAccountDto) error {
+func (m *mockService) UpdateAccountProfile(ctx context.Context, id uint, updateAccountProfileDto *dto.UpdateAccountProfileDto) (*model.Account, error) {
+	return m.updateAccountProfile(id, updateAccountProfileDto)
+}
+
+func (m *mockService) DeleteAccount(ctx context.Context, id uint, dto *dto.DeleteAccountDto) error {
 	return m.deleteAccount(id, dto)
 }
 
-func (m *mockService) GetAccount(id uint) (*model.Account, error) {
+func (m *mockService) RestoreAccount(ctx context.Context, restoreAccountDto *dto.RestoreAccountDto) (*model.Account, error) {
+	return m.restoreAccount(restoreAccountDto)
+}
+
+func (m *mockService) PurgeAccounts(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *mockService) GetAccount(ctx context.Context, id uint) (*model.Account, error) {
 	return m.getAccount(id)
 }
 
-func (m *mockService) FindAccountByEmail(dto *dto.FindLoginIdDto) error {
+func (m *mockService) GetAccounts(ctx context.Context, query *infrastructure.Query) (*dto.Page[model.Account], error) {
+	return m.getAccounts(query)
+}
+
+func (m *mockService) FindAccountByEmail(ctx context.Context, dto *dto.FindLoginIdDto) error {
 	return m.findAccountByEmail(dto)
 }
 
+func (m *mockService) CreateAdminAccount(ctx context.Context, createAccountDto *dto.CreateAccountDto) (*model.Account, error) {
+	return m.createAccount(createAccountDto)
+}
+
+func (m *mockService) UnlockAccount(ctx context.Context, loginId string) error {
+	return nil
+}
+
+func (m *mockService) SetAccountPassword(ctx context.Context, loginId string, password string) error {
+	return nil
+}
+
 func TestCreateAccount_Success(t *testing.T) {
 	router, container := testutil.PrepareForControllerTest(false)
 
@@ -101,27 +136,26 @@ func TestCreateAccount_Success(t *testing.T) {
 	assert.Empty(t, body.Password)
 }
 
-func TestCreateAccount_WrongPasswordFailure(t *testing.T) {
+func TestCreateAccount_ValidationFailure(t *testing.T) {
 	router, container := testutil.PrepareForControllerTest(false)
 
+	created := false
 	account := accountController{
 		container,
 		&mockService{
 			createAccount: func(createAccountDto *dto.CreateAccountDto) (*model.Account, error) {
-				return nil, fmt.Errorf("password must be at least 8 characters")
+				created = true
+				return nil, nil
 			},
 		},
 	}
 	router.POST(config.APIAccount, func(c echo.Context) error {
-		_ = container.GetSession().SetEmailVerification(c,
-			&infrastructure.EmailVerification{Email: "newTest@example.com", VerifiedAt: time.Now()},
-		)
 		return account.CreateAccount(c)
 	})
 
 	dto := dto.CreateAccountDto{
-		LoginId:  "newTest",
-		Email:    "newTest@example.com",
+		LoginId:  "new test",
+		Email:    "New Test <newTest@example.com>",
 		Password: "newTest",
 	}
 	req := testutil.NewJSONRequest(http.MethodPost, config.APIAccount, dto)
@@ -129,7 +163,37 @@ func TestCreateAccount_WrongPasswordFailure(t *testing.T) {
 
 	router.ServeHTTP(rec, req)
 
-	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.False(t, created)
+
+	body := APIError{}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, http.StatusUnprocessableEntity, body.Code)
+	fields := []string{}
+	for _, err := range body.Errors {
+		fields = append(fields, err.Field)
+	}
+	assert.Equal(t, []string{"loginId", "email", "password"}, fields)
+}
+
+func TestCreateAccount_EmptyBodyFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	account := NewAccountController(container)
+	router.POST(config.APIAccount, func(c echo.Context) error {
+		return account.CreateAccount(c)
+	})
+
+	for _, body := range []string{"", "{}"} {
+		req := httptest.NewRequest(http.MethodPost, config.APIAccount, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), "loginId is required")
+	}
 }
 
 func TestCreateAccount_DuplicatedUniqueValueFailure(t *testing.T) {
@@ -240,7 +304,7 @@ func TestGetAccount_Success(t *testing.T) {
 	assert.Empty(t, body.Password)
 }
 
-func TestGetAccount_NoLoginFailure(t *testing.T) {
+func TestGetAccount_NotModified(t *testing.T) {
 	router, container := testutil.PrepareForControllerTest(false)
 
 	testAccount := newTestUserAccount()
@@ -253,21 +317,77 @@ func TestGetAccount_NoLoginFailure(t *testing.T) {
 		},
 	}
 	router.GET(config.APIAccountIdPath, func(c echo.Context) error {
+		login(container, c, testAccount)
 		return account.GetAccount(c)
 	})
 
-	req := testutil.NewJSONRequest(http.MethodGet, fmt.Sprintf("%s/%d", config.APIAccount, testAccount.ID), nil)
+	req := testutil.NewJSONRequest(http.MethodGet, accountPath(testAccount.ID), nil)
 	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get(config.HeaderETag)
+	assert.Equal(t, testAccount.ETag(), etag)
 
+	req = testutil.NewJSONRequest(http.MethodGet, accountPath(testAccount.ID), nil)
+	req.Header.Set(config.HeaderIfNoneMatch, "W/"+etag)
+	rec = httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, etag, rec.Header().Get(config.HeaderETag))
+
+	testAccount.Version++
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get(config.HeaderETag))
+}
 
-	assert.Equal(t, http.StatusForbidden, rec.Code)
+func TestGetAccounts_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	account := accountController{
+		container,
+		&mockService{
+			getAccounts: func(query *infrastructure.Query) (*dto.Page[model.Account], error) {
+				return &dto.Page[model.Account]{Items: []model.Account{testAccount}, Total: 1, Size: query.Size, NextCursor: "next"}, nil
+			},
+		},
+	}
+	router.GET(config.APIAccount, func(c echo.Context) error { return account.GetAccounts(c) })
+
+	req := testutil.NewJSONRequest(http.MethodGet, config.APIAccount+"?cursor=&sort=-createdAt", nil)
+	rec := httptest.NewRecorder()
 
-	bodyBool, _ := strconv.ParseBool(rec.Body.String())
-	assert.False(t, bodyBool)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	body := dto.Page[model.Account]{}
+	err := json.Unmarshal(rec.Body.Bytes(), &body)
+	assert.Nil(t, err)
+	assert.Len(t, body.Items, 1)
+	assert.Equal(t, "newTest", body.Items[0].LoginId)
+	assert.Equal(t, "next", body.NextCursor)
+	assert.Zero(t, body.Page)
+}
+
+func TestGetAccounts_InvalidQueryFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	account := accountController{container, &mockService{}}
+	router.GET(config.APIAccount, func(c echo.Context) error { return account.GetAccounts(c) })
+
+	req := testutil.NewJSONRequest(http.MethodGet, config.APIAccount+"?page=1&cursor=", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
 
-func TestGetAccount_NoAuthorizationFailure(t *testing.T) {
+func TestGetAccount_NotFoundFailure(t *testing.T) {
 	router, container := testutil.PrepareForControllerTest(false)
 
 	testAccount := newTestUserAccount()
@@ -275,7 +395,7 @@ func TestGetAccount_NoAuthorizationFailure(t *testing.T) {
 		container,
 		&mockService{
 			getAccount: func(accountId uint) (*model.Account, error) {
-				return &testAccount, nil
+				return nil, infrastructure.ErrNotFound
 			},
 		},
 	}
@@ -284,15 +404,60 @@ func TestGetAccount_NoAuthorizationFailure(t *testing.T) {
 		return account.GetAccount(c)
 	})
 
-	req := testutil.NewJSONRequest(http.MethodGet, fmt.Sprintf("%s/%d", config.APIAccount, testAccount.ID+1), nil)
+	req := testutil.NewJSONRequest(http.MethodGet, accountPath(testAccount.ID), nil)
 	rec := httptest.NewRecorder()
 
 	router.ServeHTTP(rec, req)
 
-	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGetAccount_QueryTimeoutFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+	container.GetConfig().Database.RouteTimeouts = map[string]time.Duration{
+		http.MethodGet + " " + config.APIAccountIdPath: time.Nanosecond,
+	}
+	router.Use(middleware.QueryTimeoutMiddleware(container))
+
+	testAccount := newTestUserAccount()
+	account := accountController{container, service.NewAccountService(container)}
+	router.GET(config.APIAccountIdPath, func(c echo.Context) error {
+		login(container, c, testAccount)
+		time.Sleep(time.Millisecond)
+		return account.GetAccount(c)
+	})
+
+	req := testutil.NewJSONRequest(http.MethodGet, accountPath(testAccount.ID), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
 
-	bodyBool, _ := strconv.ParseBool(rec.Body.String())
-	assert.False(t, bodyBool)
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+}
+
+func TestGetAccount_DatabaseUnavailableFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	account := accountController{
+		container,
+		&mockService{
+			getAccount: func(accountId uint) (*model.Account, error) {
+				return nil, driver.ErrBadConn
+			},
+		},
+	}
+	router.GET(config.APIAccountIdPath, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return account.GetAccount(c)
+	})
+
+	req := testutil.NewJSONRequest(http.MethodGet, accountPath(testAccount.ID), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
 }
 
 func TestChangeAccountPassword_Success(t *testing.T) {
@@ -312,7 +477,8 @@ func TestChangeAccountPassword_Success(t *testing.T) {
 		return account.ChangeAccountPassword(c)
 	})
 
-	req := testutil.NewJSONRequest(http.MethodPost, strings.Replace(config.APIAccountChangePassword, ":"+config.APIAccountIdParam, strconv.Itoa(int(testAccount.ID)), 1), nil)
+	param := &dto.ChangeAccountPasswordDto{OldPassword: "oldPassword", NewPassword: "newPassword"}
+	req := testutil.NewJSONRequest(http.MethodPost, strings.Replace(config.APIAccountChangePassword, ":"+config.APIAccountIdParam, strconv.Itoa(int(testAccount.ID)), 1), param)
 	rec := httptest.NewRecorder()
 
 	router.ServeHTTP(rec, req)
@@ -331,30 +497,139 @@ func TestChangeAccountPassword_Success(t *testing.T) {
 	assert.Empty(t, body.Password)
 }
 
-func TestChangeAccountPassword_NoAuthorizationFailure(t *testing.T) {
+func TestUpdateAccountProfile_Success(t *testing.T) {
 	router, container := testutil.PrepareForControllerTest(false)
 
 	testAccount := newTestUserAccount()
 	account := accountController{
 		container,
 		&mockService{
-			changeAccountPassword: func(accountId uint, changeAccountPasswordDto *dto.ChangeAccountPasswordDto) (*model.Account, error) {
-				return &testAccount, nil
+			updateAccountProfile: func(accountId uint, updateAccountProfileDto *dto.UpdateAccountProfileDto) (*model.Account, error) {
+				updated := testAccount
+				updated.DisplayName = *updateAccountProfileDto.DisplayName
+				updated.Version = updateAccountProfileDto.Version + 1
+				return &updated, nil
 			},
 		},
 	}
-	router.POST(config.APIAccountChangePassword, func(c echo.Context) error {
-		return account.ChangeAccountPassword(c)
+	router.PATCH(config.APIAccountIdPath, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return account.UpdateAccountProfile(c)
 	})
 
-	req := testutil.NewJSONRequest(http.MethodPost, strings.Replace(config.APIAccountChangePassword, ":"+config.APIAccountIdParam, strconv.Itoa(int(testAccount.ID)), 1), nil)
+	displayName := "New Test"
+	param := &dto.UpdateAccountProfileDto{DisplayName: &displayName, Version: 1}
+	req := testutil.NewJSONRequest(http.MethodPatch, accountPath(testAccount.ID), param)
 	rec := httptest.NewRecorder()
 
 	router.ServeHTTP(rec, req)
-	assert.Equal(t, http.StatusForbidden, rec.Code)
 
-	bodyBool, _ := strconv.ParseBool(rec.Body.String())
-	assert.False(t, bodyBool)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	body := model.Account{}
+	err := json.Unmarshal(rec.Body.Bytes(), &body)
+	assert.Nil(t, err)
+	assert.Equal(t, displayName, body.DisplayName)
+	assert.Equal(t, uint(2), body.Version)
+}
+
+func TestUpdateAccountProfile_VersionConflictFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	account := accountController{
+		container,
+		&mockService{
+			updateAccountProfile: func(accountId uint, updateAccountProfileDto *dto.UpdateAccountProfileDto) (*model.Account, error) {
+				return nil, service.ErrVersionConflict
+			},
+		},
+	}
+	router.PATCH(config.APIAccountIdPath, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return account.UpdateAccountProfile(c)
+	})
+
+	bio := "hello"
+	param := &dto.UpdateAccountProfileDto{Bio: &bio, Version: 1}
+	req := testutil.NewJSONRequest(http.MethodPatch, accountPath(testAccount.ID), param)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, service.ErrVersionConflict.Error(), rec.Body.String())
+}
+
+func TestUpdateAccountProfile_IfMatch(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	testAccount.Version = 3
+	account := accountController{
+		container,
+		&mockService{
+			getAccount: func(accountId uint) (*model.Account, error) {
+				return &testAccount, nil
+			},
+			updateAccountProfile: func(accountId uint, updateAccountProfileDto *dto.UpdateAccountProfileDto) (*model.Account, error) {
+				if updateAccountProfileDto.Version != testAccount.Version {
+					return nil, service.ErrVersionConflict
+				}
+				updated := testAccount
+				updated.Version++
+				return &updated, nil
+			},
+		},
+	}
+	router.PATCH(config.APIAccountIdPath, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return account.UpdateAccountProfile(c)
+	})
+
+	bio := "hello"
+	req := testutil.NewJSONRequest(http.MethodPatch, accountPath(testAccount.ID), &dto.UpdateAccountProfileDto{Bio: &bio})
+	req.Header.Set(config.HeaderIfMatch, testAccount.ETag())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"account-2-4"`, rec.Header().Get(config.HeaderETag))
+}
+
+func TestUpdateAccountProfile_PreconditionFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	testAccount.Version = 3
+	updated := false
+	account := accountController{
+		container,
+		&mockService{
+			getAccount: func(accountId uint) (*model.Account, error) {
+				return &testAccount, nil
+			},
+			updateAccountProfile: func(accountId uint, updateAccountProfileDto *dto.UpdateAccountProfileDto) (*model.Account, error) {
+				updated = true
+				return &testAccount, nil
+			},
+		},
+	}
+	router.PATCH(config.APIAccountIdPath, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return account.UpdateAccountProfile(c)
+	})
+
+	bio := "hello"
+	for _, etag := range []string{`"account-2-2"`, "W/" + testAccount.ETag()} {
+		req := testutil.NewJSONRequest(http.MethodPatch, accountPath(testAccount.ID), &dto.UpdateAccountProfileDto{Bio: &bio, Version: 3})
+		req.Header.Set(config.HeaderIfMatch, etag)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	}
+	assert.False(t, updated)
 }
 
 func TestDeleteAccount_Success(t *testing.T) {
@@ -375,7 +650,7 @@ func TestDeleteAccount_Success(t *testing.T) {
 	})
 
 	dto := dto.DeleteAccountDto{
-		Password: testAccount.Password,
+		Password: "password",
 	}
 	req := testutil.NewJSONRequest(http.MethodDelete, fmt.Sprintf("%s/%d", config.APIAccount, testAccount.ID), dto)
 	rec := httptest.NewRecorder()
@@ -385,14 +660,19 @@ func TestDeleteAccount_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 }
 
-func TestDeleteAccount_NoAuthorizationFailure(t *testing.T) {
+func TestDeleteAccount_PreconditionFailure(t *testing.T) {
 	router, container := testutil.PrepareForControllerTest(false)
 
 	testAccount := newTestUserAccount()
+	deleted := false
 	account := accountController{
 		container,
 		&mockService{
+			getAccount: func(accountId uint) (*model.Account, error) {
+				return &testAccount, nil
+			},
 			deleteAccount: func(accountId uint, dto *dto.DeleteAccountDto) error {
+				deleted = true
 				return nil
 			},
 		},
@@ -402,17 +682,76 @@ func TestDeleteAccount_NoAuthorizationFailure(t *testing.T) {
 		return account.DeleteAccount(c)
 	})
 
-	dto := dto.DeleteAccountDto{
-		Password: testAccount.Password,
+	req := testutil.NewJSONRequest(http.MethodDelete, accountPath(testAccount.ID), dto.DeleteAccountDto{Password: "password"})
+	req.Header.Set(config.HeaderIfMatch, `"account-2-100"`)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.False(t, deleted)
+}
+
+func TestDeleteAccount_VersionConflictFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	testAccount.Version = 3
+	var version uint
+	account := accountController{
+		container,
+		&mockService{
+			getAccount: func(accountId uint) (*model.Account, error) {
+				return &testAccount, nil
+			},
+			// the account is updated between the check of If-Match and the deletion
+			deleteAccount: func(accountId uint, dto *dto.DeleteAccountDto) error {
+				version = dto.Version
+				return service.ErrVersionConflict
+			},
+		},
 	}
-	req := testutil.NewJSONRequest(http.MethodDelete, fmt.Sprintf("%s/%d", config.APIAccount, testAccount.ID+1), dto)
+	router.DELETE(config.APIAccountIdPath, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return account.DeleteAccount(c)
+	})
+
+	req := testutil.NewJSONRequest(http.MethodDelete, accountPath(testAccount.ID), dto.DeleteAccountDto{Password: "password"})
+	req.Header.Set(config.HeaderIfMatch, testAccount.ETag())
 	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
 
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t, testAccount.Version, version)
+}
+
+func TestRestoreAccount_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	account := accountController{
+		container,
+		&mockService{
+			restoreAccount: func(restoreAccountDto *dto.RestoreAccountDto) (*model.Account, error) {
+				if restoreAccountDto.Token != "token" {
+					return nil, fmt.Errorf("restore token is not valid")
+				}
+				return &testAccount, nil
+			},
+		},
+	}
+	router.POST(config.APIAccountRestore, func(c echo.Context) error {
+		return account.RestoreAccount(c)
+	})
+
+	req := testutil.NewJSONRequest(http.MethodPost, config.APIAccountRestore, dto.RestoreAccountDto{Token: "token"})
+	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
-	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, http.StatusOK, rec.Code)
 
-	bodyBool, _ := strconv.ParseBool(rec.Body.String())
-	assert.False(t, bodyBool)
+	req = testutil.NewJSONRequest(http.MethodPost, config.APIAccountRestore, dto.RestoreAccountDto{Token: "invalid"})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
 
 func TestFindLoginId_Success(t *testing.T) {
@@ -472,13 +811,19 @@ func TestFindLoginId_NoExistAccountFailure(t *testing.T) {
 func login(testcontainer container.Container, c echo.Context, account model.Account) {
 	_ = testcontainer.GetSession().Login(c,
 		&infrastructure.Account{
-			Id:        account.ID,
-			LoginId:   account.LoginId,
-			Authority: uint(account.Authority),
+			Id:                 account.ID,
+			LoginId:            account.LoginId,
+			Roles:              account.RoleNames(),
+			Permissions:        account.Permissions(),
+			MustChangePassword: account.MustChangePassword,
 		},
 	)
 }
 
+func accountPath(id uint) string {
+	return strings.Replace(config.APIAccountIdPath, ":"+config.APIAccountIdParam, strconv.Itoa(int(id)), 1)
+}
+
 func newTestUserAccount() model.Account {
 	return model.Account{
 		Model: gorm.Model{
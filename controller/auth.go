@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -17,20 +18,23 @@ type AuthController interface {
 	GetLoginAccount(c echo.Context) error
 	Login(c echo.Context) error
 	Logout(c echo.Context) error
+	SetupAdmin(c echo.Context) error
 	EmailVerificationTokenSend(c echo.Context) error
 	EmailVerificationTokenVerify(c echo.Context) error
 }
 
 type authController struct {
-	container container.Container
-	service   service.AuthService
+	container        container.Container
+	service          service.AuthService
+	bootstrapService service.BootstrapService
 }
 
 // NewAuthController is constructor.
 func NewAuthController(container container.Container) AuthController {
 	return &authController{
-		container: container,
-		service:   service.NewAuthService(container),
+		container:        container,
+		service:          service.NewAuthService(container),
+		bootstrapService: service.NewBootstrapService(container),
 	}
 }
 
@@ -69,11 +73,14 @@ func (controller *authController) GetLoginAccount(c echo.Context) error {
 // @Param data body dto.LoginDto true "User name and Password for logged-in."
 // @Success 200 {object} model.Account "Success to the authentication."
 // @Failure 401 {boolean} bool "Failed to the authentication."
+// @Failure 422 {object} APIError "The request is not valid. Returns the errors of the fields."
+// @Failure 503 {object} APIError "The database is unavailable."
+// @Failure 504 {object} APIError "The request timed out."
 // @Router /auth/login [post]
 func (controller *authController) Login(c echo.Context) error {
 	dto := dto.NewLoginDto()
 	if err := c.Bind(dto); err != nil {
-		return c.JSON(http.StatusBadRequest, dto)
+		return bindError(c, err)
 	}
 
 	sess := controller.container.GetSession()
@@ -81,15 +88,17 @@ func (controller *authController) Login(c echo.Context) error {
 		return c.JSON(http.StatusOK, account)
 	}
 
-	account, err := controller.service.AuthenticateByLoginIdAndPassword(dto.LoginId, dto.Password)
+	account, err := controller.service.AuthenticateByLoginIdAndPassword(c.Request().Context(), dto.LoginId, dto.Password)
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return serviceError(c, http.StatusBadRequest, err)
 	}
 	err = sess.Login(c,
 		&infrastructure.Account{
-			Id:        account.ID,
-			LoginId:   account.LoginId,
-			Authority: uint(account.Authority),
+			Id:                 account.ID,
+			LoginId:            account.LoginId,
+			Roles:              account.RoleNames(),
+			Permissions:        account.Permissions(),
+			MustChangePassword: account.MustChangePassword,
 		},
 	)
 	if err != nil {
@@ -115,6 +124,39 @@ func (controller *authController) Logout(c echo.Context) error {
 	return c.NoContent(http.StatusOK)
 }
 
+// SetupAdmin creates the first admin account with the setup token printed to the log by http post.
+// @Summary Create the first admin account.
+// @Description Create the first admin account with the one-time setup token, which is printed to the log at boot while no admin exists.
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param data body dto.SetupAdminDto true "Setup token and the admin account to create."
+// @Success 200 {object} model.Account "Success to create the admin account."
+// @Failure 400 {string} message "Failed to create the admin account."
+// @Failure 403 {string} message "The setup token is invalid or expired."
+// @Failure 409 {string} message "An admin account already exists."
+// @Failure 422 {object} APIError "The request is not valid. Returns the errors of the fields."
+// @Failure 503 {object} APIError "The database is unavailable."
+// @Failure 504 {object} APIError "The request timed out."
+// @Router /auth/setup [post]
+func (controller *authController) SetupAdmin(c echo.Context) error {
+	data := dto.NewSetupAdminDto()
+	if err := c.Bind(data); err != nil {
+		return bindError(c, err)
+	}
+
+	account, err := controller.bootstrapService.SetupAdmin(c.Request().Context(), data)
+	switch {
+	case errors.Is(err, service.ErrInvalidSetupToken):
+		return c.String(http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrAdminExists):
+		return c.String(http.StatusConflict, err.Error())
+	case err != nil:
+		return serviceError(c, http.StatusBadRequest, err)
+	}
+	return c.JSON(http.StatusOK, account)
+}
+
 // EmailVerificationTokenSend is the method to email verify using token.
 // @Summary EmailVerificationTokenSend generate token and send it to email.
 // @Description EmailVerificationTokenSend generate token and send it to email.
@@ -124,11 +166,14 @@ func (controller *authController) Logout(c echo.Context) error {
 // @Param data body dto.EmailVerificationTokenSendDto true "Email for verification."
 // @Success 200
 // @Failure 400 {string} message "Failed to send verification token."
+// @Failure 422 {object} APIError "The request is not valid. Returns the errors of the fields."
+// @Failure 503 {object} APIError "The database is unavailable."
+// @Failure 504 {object} APIError "The request timed out."
 // @Router /auth/email-verification/token-generate [post]
 func (controller *authController) EmailVerificationTokenSend(c echo.Context) error {
 	dto := dto.NewEmailVerificationTokenSendDto()
 	if err := c.Bind(dto); err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return bindError(c, err)
 	}
 
 	sess := controller.container.GetSession()
@@ -136,9 +181,9 @@ func (controller *authController) EmailVerificationTokenSend(c echo.Context) err
 		return c.String(http.StatusBadRequest, "already logged-in")
 	}
 
-	token, err := controller.service.EmailVerificationTokenSend(dto.Email)
+	token, err := controller.service.EmailVerificationTokenSend(c.Request().Context(), dto.Email)
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return serviceError(c, http.StatusBadRequest, err)
 	}
 
 	emailVerification := &infrastructure.EmailVerification{
@@ -163,11 +208,12 @@ func (controller *authController) EmailVerificationTokenSend(c echo.Context) err
 // @Param data body dto.EmailVerificationTokenVerifyDto true "Token for verification."
 // @Success 200
 // @Failure 400 {string} message "Failed to verify token."
+// @Failure 422 {object} APIError "The request is not valid. Returns the errors of the fields."
 // @Router /auth/email-verification/token-verify [post]
 func (controller *authController) EmailVerificationTokenVerify(c echo.Context) error {
 	dto := dto.NewEmailVerificationTokenVerifyDto()
 	if err := c.Bind(dto); err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return bindError(c, err)
 	}
 
 	sess := controller.container.GetSession()
@@ -3,6 +3,7 @@ package controller
 import (
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	smtpmock "github.com/mocktools/go-smtp-mock/v2"
 	"github.com/onetooler/bistory-backend/config"
 	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
 	"github.com/onetooler/bistory-backend/model/dto"
 	"github.com/onetooler/bistory-backend/testutil"
 	"github.com/onetooler/bistory-backend/util"
@@ -77,6 +79,77 @@ func TestLogin_AuthenticationFailure(t *testing.T) {
 	assert.Empty(t, testutil.GetCookie(rec, "GSESSION"))
 }
 
+func TestLogin_ConcurrentAuthenticationFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	auth := NewAuthController(container)
+	router.POST(config.APIAuthLogin, func(c echo.Context) error { return auth.Login(c) })
+
+	const requests = 20
+	var wg sync.WaitGroup
+	codes := make(chan int, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, testutil.NewJSONRequest("POST", config.APIAuthLogin, createLoginFailureAccount()))
+			codes <- rec.Code
+		}()
+	}
+
+	// the right password sent after the guesses have used up the attempts is rejected,
+	// even while they are still being compared and the account is not deactivated yet.
+	account := model.Account{}
+	assert.Eventually(t, func() bool {
+		container.GetRepository().Where(&model.Account{LoginId: "test"}).First(&account)
+		return account.BadAttempt == uint(config.MaxLoginAttempts)
+	}, 5*time.Second, time.Millisecond)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, testutil.NewJSONRequest("POST", config.APIAuthLogin, createLoginSuccessAccount()))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "account is not active", rec.Body.String())
+
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		assert.Equal(t, http.StatusBadRequest, code)
+	}
+
+	container.GetRepository().Where(&model.Account{LoginId: "test"}).First(&account)
+	assert.Equal(t, uint(config.MaxLoginAttempts), account.BadAttempt)
+	assert.Equal(t, model.StatusInactive, account.Status)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, testutil.NewJSONRequest("POST", config.APIAuthLogin, createLoginSuccessAccount()))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "account is not active", rec.Body.String())
+}
+
+func TestLogin_SuccessResetsAttempts(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	auth := NewAuthController(container)
+	router.POST(config.APIAuthLogin, func(c echo.Context) error { return auth.Login(c) })
+
+	before := model.Account{}
+	container.GetRepository().Where(&model.Account{LoginId: "test"}).First(&before)
+	for i := 0; i < config.MaxLoginAttempts-1; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), testutil.NewJSONRequest("POST", config.APIAuthLogin, createLoginFailureAccount()))
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, testutil.NewJSONRequest("POST", config.APIAuthLogin, createLoginSuccessAccount()))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	// the counter is not a part of the representation, whose version is left as it is
+	assert.NotContains(t, rec.Body.String(), "badAttempt")
+
+	account := model.Account{}
+	container.GetRepository().Where(&model.Account{LoginId: "test"}).First(&account)
+	assert.Equal(t, uint(0), account.BadAttempt)
+	assert.Equal(t, model.StatusActive, account.Status)
+	assert.Equal(t, before.Version, account.Version)
+}
+
 func TestLogout_Success(t *testing.T) {
 	router, container := testutil.PrepareForControllerTest(false)
 
@@ -92,6 +165,42 @@ func TestLogout_Success(t *testing.T) {
 	assert.Empty(t, testutil.GetCookie(rec, "GSESSION"))
 }
 
+func TestSetupAdmin_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+	container.GetRepository().Exec("DELETE FROM account_role")
+	token, plain := model.NewSetupToken(time.Now().Add(time.Hour))
+	container.GetRepository().Create(token)
+
+	auth := NewAuthController(container)
+	router.POST(config.APIAuthSetup, func(c echo.Context) error { return auth.SetupAdmin(c) })
+
+	param := &dto.SetupAdminDto{Token: plain, LoginId: "admin", Email: "admin@example.com", Password: "adminAdmin"}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, testutil.NewJSONRequest("POST", config.APIAuthSetup, param))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// the token is used only once
+	param.LoginId = "other"
+	param.Email = "other@example.com"
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, testutil.NewJSONRequest("POST", config.APIAuthSetup, param))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestSetupAdmin_AdminExistsFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+	token, plain := model.NewSetupToken(time.Now().Add(time.Hour))
+	container.GetRepository().Create(token)
+
+	auth := NewAuthController(container)
+	router.POST(config.APIAuthSetup, func(c echo.Context) error { return auth.SetupAdmin(c) })
+
+	param := &dto.SetupAdminDto{Token: plain, LoginId: "admin", Email: "admin@example.com", Password: "adminAdmin"}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, testutil.NewJSONRequest("POST", config.APIAuthSetup, param))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
 func TestEmailVerificationTokenSend_Success(t *testing.T) {
 	mailServer := smtpmock.New(smtpmock.ConfigurationAttr{
 		LogToStdout:       true,
@@ -160,7 +269,7 @@ func createLoginSuccessAccount() *dto.LoginDto {
 	return &dto.LoginDto{
 		LoginId:  "test",
 		Email:    "test@example.com",
-		Password: "test",
+		Password: "testPassword",
 	}
 }
 
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/service"
+	"github.com/onetooler/bistory-backend/util"
+)
+
+// multipartOverhead is the allowance for the multipart boundaries and headers of an avatar upload.
+const multipartOverhead int64 = 64 << 10
+
+// AvatarController is a controller for managing the avatars of accounts.
+type AvatarController interface {
+	GetAvatar(c echo.Context) error
+	UploadAvatar(c echo.Context) error
+	DeleteAvatar(c echo.Context) error
+}
+
+type avatarController struct {
+	container container.Container
+	service   service.AvatarService
+}
+
+// NewAvatarController is constructor.
+func NewAvatarController(container container.Container) AvatarController {
+	return &avatarController{container: container, service: service.NewAvatarService(container)}
+}
+
+// GetAvatar returns the signed URLs of the avatar thumbnails.
+// @Summary Get the avatar of account
+// @Description Get the signed URLs of the avatar thumbnails
+// @Tags Avatar
+// @Accept  json
+// @Produce  json
+// @Param accountId path int true "Account ID"
+// @Success 200 {object} dto.AvatarDto "Success to fetch the avatar."
+// @Failure 400 {string} message "Failed to fetch the avatar."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 404 {string} message "The account has no avatar."
+// @Router /account/{accountId}/avatar [get]
+func (controller *avatarController) GetAvatar(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	if accountId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	avatar, err := controller.service.GetAvatar(accountId)
+	if errors.Is(err, service.ErrAvatarNotFound) {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, avatar)
+}
+
+// UploadAvatar uploads a new avatar by multipart form and replaces the previous one.
+// @Summary Upload the avatar of account
+// @Description Upload a png, jpeg, gif or webp image. It is cropped to a square and resized to the fixed thumbnails.
+// @Tags Avatar
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param accountId path int true "Account ID"
+// @Param avatar formData file true "the avatar image"
+// @Success 200 {object} dto.AvatarDto "Success to upload the avatar."
+// @Failure 400 {string} message "Failed to upload the avatar."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 413 {string} message "The avatar is too large."
+// @Failure 415 {string} message "The avatar is not a supported image."
+// @Router /account/{accountId}/avatar [post]
+func (controller *avatarController) UploadAvatar(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	if accountId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, config.AvatarMaxSize+multipartOverhead)
+	fileHeader, err := c.FormFile(config.AvatarFormField)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return c.String(http.StatusRequestEntityTooLarge, service.ErrAvatarTooLarge.Error())
+		}
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if fileHeader.Size > config.AvatarMaxSize {
+		return c.String(http.StatusRequestEntityTooLarge, service.ErrAvatarTooLarge.Error())
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	defer file.Close()
+
+	avatar, err := controller.service.UploadAvatar(accountId, file)
+	switch {
+	case errors.Is(err, service.ErrAvatarTooLarge):
+		return c.String(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, service.ErrUnsupportedAvatarType):
+		return c.String(http.StatusUnsupportedMediaType, err.Error())
+	case err != nil:
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, avatar)
+}
+
+// DeleteAvatar deletes the avatar of account.
+// @Summary Delete the avatar of account
+// @Description Delete the avatar of account
+// @Tags Avatar
+// @Accept  json
+// @Produce  json
+// @Param accountId path int true "Account ID"
+// @Success 200 {boolean} bool "Success to delete the avatar."
+// @Failure 400 {string} message "Failed to delete the avatar."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 404 {string} message "The account has no avatar."
+// @Router /account/{accountId}/avatar [delete]
+func (controller *avatarController) DeleteAvatar(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	if accountId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	err := controller.service.DeleteAvatar(accountId)
+	if errors.Is(err, service.ErrAvatarNotFound) {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, true)
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/service"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAvatarService struct {
+	uploadAvatar func(uint, io.Reader) (*dto.AvatarDto, error)
+	getAvatar    func(uint) (*dto.AvatarDto, error)
+	deleteAvatar func(uint) error
+}
+
+func (m *mockAvatarService) UploadAvatar(accountId uint, file io.Reader) (*dto.AvatarDto, error) {
+	return m.uploadAvatar(accountId, file)
+}
+
+func (m *mockAvatarService) GetAvatar(accountId uint) (*dto.AvatarDto, error) {
+	return m.getAvatar(accountId)
+}
+
+func (m *mockAvatarService) DeleteAvatar(accountId uint) error {
+	return m.deleteAvatar(accountId)
+}
+
+func TestUploadAvatar_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	var uploaded []byte
+	avatar := avatarController{
+		container,
+		&mockAvatarService{
+			uploadAvatar: func(accountId uint, file io.Reader) (*dto.AvatarDto, error) {
+				uploaded, _ = io.ReadAll(file)
+				return &dto.AvatarDto{Large: "/large", Small: "/small", ExpiresAt: time.Now()}, nil
+			},
+		},
+	}
+	router.POST(config.APIAccountAvatar, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return avatar.UploadAvatar(c)
+	})
+
+	req := newAvatarRequest(t, avatarPath(testAccount.ID), []byte("image"))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []byte("image"), uploaded)
+
+	body := dto.AvatarDto{}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "/large", body.Large)
+	assert.Equal(t, "/small", body.Small)
+}
+
+func TestUploadAvatar_UnsupportedTypeFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	avatar := avatarController{
+		container,
+		&mockAvatarService{
+			uploadAvatar: func(accountId uint, file io.Reader) (*dto.AvatarDto, error) {
+				return nil, service.ErrUnsupportedAvatarType
+			},
+		},
+	}
+	router.POST(config.APIAccountAvatar, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return avatar.UploadAvatar(c)
+	})
+
+	req := newAvatarRequest(t, avatarPath(testAccount.ID), []byte("text"))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
+
+func TestUploadAvatar_TooLargeFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	avatar := avatarController{container, &mockAvatarService{}}
+	router.POST(config.APIAccountAvatar, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return avatar.UploadAvatar(c)
+	})
+
+	req := newAvatarRequest(t, avatarPath(testAccount.ID), make([]byte, config.AvatarMaxSize+1))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestGetAvatar_NotFoundFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	avatar := avatarController{
+		container,
+		&mockAvatarService{
+			getAvatar: func(accountId uint) (*dto.AvatarDto, error) {
+				return nil, service.ErrAvatarNotFound
+			},
+		},
+	}
+	router.GET(config.APIAccountAvatar, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return avatar.GetAvatar(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, avatarPath(testAccount.ID), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestDeleteAvatar_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	avatar := avatarController{
+		container,
+		&mockAvatarService{
+			deleteAvatar: func(accountId uint) error {
+				return nil
+			},
+		},
+	}
+	router.DELETE(config.APIAccountAvatar, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return avatar.DeleteAvatar(c)
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, avatarPath(testAccount.ID), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func avatarPath(id uint) string {
+	return strings.Replace(config.APIAccountAvatar, config.APIAccountIdPath, accountPath(id), 1)
+}
+
+func newAvatarRequest(t *testing.T, target string, file []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(config.AvatarFormField, "avatar.png")
+	assert.Nil(t, err)
+	_, err = part.Write(file)
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	return req
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/service"
+	"github.com/onetooler/bistory-backend/util"
+)
+
+// DataExportController is a controller for exporting the personal data of accounts.
+type DataExportController interface {
+	RequestExport(c echo.Context) error
+	GetExports(c echo.Context) error
+	DownloadExport(c echo.Context) error
+}
+
+type dataExportController struct {
+	container container.Container
+	service   service.DataExportService
+}
+
+// NewDataExportController is constructor.
+func NewDataExportController(container container.Container) DataExportController {
+	return &dataExportController{container: container, service: service.NewDataExportService(container)}
+}
+
+// RequestExport requests the export of all personal data of the account.
+// @Summary Request a personal data export
+// @Description Request a zip archive of all data held about the account. The download link is sent by email when it is ready.
+// @Tags DataExport
+// @Accept  json
+// @Produce  json
+// @Param accountId path int true "Account ID"
+// @Success 202 {object} model.DataExport "Success to request the export."
+// @Failure 400 {string} message "Failed to request the export."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 409 {string} message "An export is already in progress."
+// @Router /account/{accountId}/export [post]
+func (controller *dataExportController) RequestExport(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	if accountId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	export, err := controller.service.RequestExport(c.Request().Context(), accountId)
+	if errors.Is(err, service.ErrExportInProgress) {
+		return c.String(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusAccepted, export)
+}
+
+// GetExports returns the exports of the account.
+// @Summary Get the personal data exports
+// @Description Get the personal data exports of the account
+// @Tags DataExport
+// @Accept  json
+// @Produce  json
+// @Param accountId path int true "Account ID"
+// @Param status query string false "Statuses to filter by, separated by commas"
+// @Param page query int false "Page number, starting from 1"
+// @Param size query int false "Page size, up to 100"
+// @Param cursor query string false "Cursor of the next page. An empty cursor requests the first page in the cursor mode."
+// @Param sort query string false "Field to sort by, descending with - prefix"
+// @Success 200 {object} dto.Page[model.DataExport] "Success to fetch the exports."
+// @Failure 400 {string} message "Failed to fetch the exports."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Router /account/{accountId}/export [get]
+func (controller *dataExportController) GetExports(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	if accountId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	query, err := infrastructure.ParseQuery(c.QueryParams(), service.DataExportQuerySpec)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	var exports *dto.Page[model.DataExport]
+	exports, err = controller.service.GetExports(c.Request().Context(), accountId, query)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, exports)
+}
+
+// DownloadExport redirects to the time-limited URL of the archive.
+// @Summary Download a personal data export
+// @Description Redirect to the time-limited URL of the archive
+// @Tags DataExport
+// @Param accountId path int true "Account ID"
+// @Param exportId path int true "Export ID"
+// @Success 302 "Redirect to the archive."
+// @Failure 400 {string} message "Failed to fetch the export."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 404 {string} message "The export is not ready or has expired."
+// @Router /account/{accountId}/export/{exportId}/download [get]
+func (controller *dataExportController) DownloadExport(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	exportId := util.ConvertToUint(c.Param(config.APIAccountExportIdParam))
+	if accountId == 0 || exportId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	download, err := controller.service.GetExportDownload(c.Request().Context(), accountId, exportId)
+	if errors.Is(err, service.ErrExportNotReady) {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.Redirect(http.StatusFound, download.URL)
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/service"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDataExportService struct {
+	requestExport     func(uint) (*model.DataExport, error)
+	getExports        func(uint, *infrastructure.Query) (*dto.Page[model.DataExport], error)
+	getExportDownload func(uint, uint) (*dto.ExportDownloadDto, error)
+}
+
+func (m *mockDataExportService) RequestExport(ctx context.Context, accountId uint) (*model.DataExport, error) {
+	return m.requestExport(accountId)
+}
+
+func (m *mockDataExportService) GetExports(ctx context.Context, accountId uint, query *infrastructure.Query) (*dto.Page[model.DataExport], error) {
+	return m.getExports(accountId, query)
+}
+
+func (m *mockDataExportService) GetExportDownload(ctx context.Context, accountId uint, exportId uint) (*dto.ExportDownloadDto, error) {
+	return m.getExportDownload(accountId, exportId)
+}
+
+func (m *mockDataExportService) DeleteExpiredExports(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *mockDataExportService) BuildExport(ctx context.Context, exportId uint) error {
+	return nil
+}
+
+func TestRequestExport_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	export := dataExportController{
+		container,
+		&mockDataExportService{
+			requestExport: func(accountId uint) (*model.DataExport, error) {
+				return &model.DataExport{AccountID: accountId, Status: model.ExportStatusPending}, nil
+			},
+		},
+	}
+	router.POST(config.APIAccountExports, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return export.RequestExport(c)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, exportsPath(testAccount.ID), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	body := model.DataExport{}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, testAccount.ID, body.AccountID)
+	assert.Equal(t, model.ExportStatusPending, body.Status)
+}
+
+func TestRequestExport_InProgressFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	export := dataExportController{
+		container,
+		&mockDataExportService{
+			requestExport: func(accountId uint) (*model.DataExport, error) {
+				return nil, service.ErrExportInProgress
+			},
+		},
+	}
+	router.POST(config.APIAccountExports, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return export.RequestExport(c)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, exportsPath(testAccount.ID), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestDownloadExport_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	export := dataExportController{
+		container,
+		&mockDataExportService{
+			getExportDownload: func(accountId uint, exportId uint) (*dto.ExportDownloadDto, error) {
+				return &dto.ExportDownloadDto{URL: "http://localhost/archive.zip", ExpiresAt: time.Now()}, nil
+			},
+		},
+	}
+	router.GET(config.APIAccountExportDownload, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return export.DownloadExport(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, exportsPath(testAccount.ID)+"/1/download", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "http://localhost/archive.zip", rec.Header().Get(echo.HeaderLocation))
+}
+
+func TestDownloadExport_NotReadyFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	testAccount := newTestUserAccount()
+	export := dataExportController{
+		container,
+		&mockDataExportService{
+			getExportDownload: func(accountId uint, exportId uint) (*dto.ExportDownloadDto, error) {
+				return nil, service.ErrExportNotReady
+			},
+		},
+	}
+	router.GET(config.APIAccountExportDownload, func(c echo.Context) error {
+		login(container, c, testAccount)
+		return export.DownloadExport(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, exportsPath(testAccount.ID)+"/1/download", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func exportsPath(id uint) string {
+	return strings.Replace(config.APIAccountExports, ":"+config.APIAccountIdParam, strconv.Itoa(int(id)), 1)
+}
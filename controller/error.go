@@ -1,16 +1,25 @@
 package controller
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/logger"
+	"github.com/onetooler/bistory-backend/model/dto"
 )
 
-// APIError has a error code and a message.
+// APIError has a error code, a message and the id of the request to correlate with the logs.
+// Errors are the field errors of the request which has failed the validation.
 type APIError struct {
-	Code    int
-	Message string
+	Code      int
+	Message   string
+	RequestId string               `json:",omitempty"`
+	Errors    dto.ValidationErrors `json:",omitempty"`
 }
 
 // ErrorController is a controller for handling errors.
@@ -29,7 +38,7 @@ func NewErrorController(container container.Container) ErrorController {
 
 // JSONError is custom error handler
 func (controller *errorController) JSONError(err error, c echo.Context) {
-	logger := controller.container.GetLogger()
+	log := controller.container.GetLogger().GetZapLoggerFromContext(c.Request().Context())
 	code := http.StatusInternalServerError
 	msg := http.StatusText(code)
 
@@ -41,11 +50,39 @@ func (controller *errorController) JSONError(err error, c echo.Context) {
 	var apierr APIError
 	apierr.Code = code
 	apierr.Message = msg
+	apierr.RequestId = logger.RequestIdFromContext(c.Request().Context())
 
 	if !c.Response().Committed {
 		if reserr := c.JSON(code, apierr); reserr != nil {
-			logger.GetZapLogger().Errorf(reserr.Error())
+			log.Errorf(reserr.Error())
 		}
 	}
-	logger.GetZapLogger().Debugf(err.Error())
+	log.Debugf(controller.container.GetLogger().GetRedactor().Text(err.Error()))
+}
+
+// serviceError responds the error returned by a service with a given status code.
+// The errors caused by the request timeout or the unavailable database are responded
+// with 504 or 503 instead, since the request itself may succeed when retried.
+func serviceError(c echo.Context, code int, err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(c.Request().Context().Err(), context.DeadlineExceeded):
+		return echo.NewHTTPError(http.StatusGatewayTimeout, "the request timed out")
+	case errors.Is(err, context.Canceled) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone):
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "the database is unavailable")
+	}
+	return c.String(code, err.Error())
+}
+
+// bindError responds the error of binding the request. The validation errors are responded by 422 with all the fields.
+func bindError(c echo.Context, err error) error {
+	var errs dto.ValidationErrors
+	if errors.As(err, &errs) {
+		return c.JSON(http.StatusUnprocessableEntity, APIError{
+			Code:      http.StatusUnprocessableEntity,
+			Message:   "the request is not valid",
+			RequestId: logger.RequestIdFromContext(c.Request().Context()),
+			Errors:    errs,
+		})
+	}
+	return c.String(http.StatusBadRequest, err.Error())
 }
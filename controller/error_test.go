@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/labstack/echo/v4"
 	"github.com/onetooler/bistory-backend/testutil"
 	"github.com/stretchr/testify/assert"
 )
@@ -16,10 +17,11 @@ func TestJSONError(t *testing.T) {
 	router.HTTPErrorHandler = errorHandler.JSONError
 
 	req := httptest.NewRequest("GET", "/api/movies/1", nil)
+	req.Header.Set(echo.HeaderXRequestID, "test-request-id")
 	rec := httptest.NewRecorder()
 
 	router.ServeHTTP(rec, req)
 
 	assert.Equal(t, http.StatusNotFound, rec.Code)
-	assert.JSONEq(t, `{"Code":404,"Message":"Not Found"}`, rec.Body.String())
+	assert.JSONEq(t, `{"Code":404,"Message":"Not Found","RequestId":"test-request-id"}`, rec.Body.String())
 }
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/util"
+)
+
+// FileController is a controller for downloading the files of the storage by signed URLs.
+type FileController interface {
+	GetFile(c echo.Context) error
+}
+
+type fileController struct {
+	container container.Container
+}
+
+// NewFileController is constructor.
+func NewFileController(container container.Container) FileController {
+	return &fileController{container: container}
+}
+
+// GetFile returns the file of a signed URL.
+// @Summary Get a file
+// @Description Get a file of the local storage by the signed URL
+// @Tags File
+// @Produce  octet-stream
+// @Param key path string true "File key"
+// @Param expires query int true "Expiration time of the URL"
+// @Param signature query string true "Signature of the URL"
+// @Success 200 {file} file "Success to fetch the file."
+// @Failure 403 {string} message "The signature is invalid or expired."
+// @Failure 404 {string} message "The file is not found."
+// @Router /files/{key} [get]
+func (controller *fileController) GetFile(c echo.Context) error {
+	verifier, ok := controller.container.GetStorage().(infrastructure.SignedURLVerifier)
+	if !ok {
+		return c.String(http.StatusNotFound, infrastructure.ErrFileNotFound.Error())
+	}
+
+	key := c.Param(config.APIFilesKeyParam)
+	if err := verifier.VerifySignedURL(key, c.QueryParams()); err != nil {
+		return c.String(http.StatusForbidden, err.Error())
+	}
+
+	file, err := controller.container.GetStorage().Get(key)
+	if errors.Is(err, infrastructure.ErrFileNotFound) {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	defer util.Check(file.Close)
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = echo.MIMEOctetStream
+	}
+	c.Response().Header().Set(echo.HeaderCacheControl, "private, max-age=60")
+	return c.Stream(http.StatusOK, contentType, file)
+}
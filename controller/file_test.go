@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFile_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	file := NewFileController(container)
+	router.GET(config.APIFilesKeyPath, func(c echo.Context) error { return file.GetFile(c) })
+
+	storage := container.GetStorage()
+	assert.Nil(t, storage.Put("test/hello.txt", strings.NewReader("hello"), 5, "text/plain"))
+	signedURL, err := storage.SignedURL("test/hello.txt", time.Minute)
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, signedURL, nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "hello", rec.Body.String())
+	assert.True(t, strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), "text/plain"))
+}
+
+func TestGetFile_InvalidSignatureFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	file := NewFileController(container)
+	router.GET(config.APIFilesKeyPath, func(c echo.Context) error { return file.GetFile(c) })
+
+	storage := container.GetStorage()
+	assert.Nil(t, storage.Put("test/secret.txt", strings.NewReader("secret"), 6, "text/plain"))
+	signedURL, err := storage.SignedURL("test/hello.txt", time.Minute)
+	assert.Nil(t, err)
+
+	// the signature of another file
+	req := httptest.NewRequest(http.MethodGet, strings.Replace(signedURL, "hello", "secret", 1), nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// the expired signature
+	signedURL, err = storage.SignedURL("test/secret.txt", -time.Minute)
+	assert.Nil(t, err)
+	req = httptest.NewRequest(http.MethodGet, signedURL, nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
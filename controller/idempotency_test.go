@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/middleware"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type idempotencyTestBody struct {
+	Name string `json:"name"`
+}
+
+// prepareForIdempotencyTest registers a POST route responding with a given status and counting its calls.
+func prepareForIdempotencyTest(status int) (*echo.Echo, *int) {
+	router, container := testutil.PrepareForControllerTest(false)
+	router.Use(middleware.IdempotencyMiddleware(container))
+	calls := 0
+	router.POST(config.API+"idempotency", func(c echo.Context) error {
+		calls++
+		body := idempotencyTestBody{}
+		if err := c.Bind(&body); err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderLocation, config.API+"idempotency/"+body.Name)
+		switch body.Name {
+		case "secret":
+			c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+		case "cookie":
+			c.SetCookie(&http.Cookie{Name: "session", Value: body.Name})
+		}
+		return c.JSON(status, map[string]interface{}{"name": body.Name, "calls": calls})
+	})
+	return router, &calls
+}
+
+func newIdempotentRequest(key string, name string) *http.Request {
+	req := testutil.NewJSONRequest("POST", config.API+"idempotency", &idempotencyTestBody{Name: name})
+	if key != "" {
+		req.Header.Set(config.HeaderIdempotencyKey, key)
+	}
+	return req
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	router, calls := prepareForIdempotencyTest(http.StatusCreated)
+
+	rec1 := httptest.NewRecorder()
+	router.ServeHTTP(rec1, newIdempotentRequest("key", "test"))
+	rec2 := httptest.NewRecorder()
+	router.ServeHTTP(rec2, newIdempotentRequest("key", "test"))
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusCreated, rec2.Code)
+	assert.Equal(t, rec1.Body.String(), rec2.Body.String())
+	assert.Equal(t, rec1.Header().Get(echo.HeaderContentType), rec2.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, rec1.Header().Get(echo.HeaderLocation), rec2.Header().Get(echo.HeaderLocation))
+	assert.Empty(t, rec1.Header().Get(config.HeaderIdempotentReplayed))
+	assert.Equal(t, "true", rec2.Header().Get(config.HeaderIdempotentReplayed))
+}
+
+func TestIdempotency_DifferentBodyFailure(t *testing.T) {
+	router, calls := prepareForIdempotencyTest(http.StatusCreated)
+
+	router.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest("key", "test"))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newIdempotentRequest("key", "other"))
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestIdempotency_ServerErrorNotStored(t *testing.T) {
+	router, calls := prepareForIdempotencyTest(http.StatusInternalServerError)
+
+	router.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest("key", "test"))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newIdempotentRequest("key", "test"))
+
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Empty(t, rec.Header().Get(config.HeaderIdempotentReplayed))
+}
+
+func TestIdempotency_WithoutKey(t *testing.T) {
+	router, calls := prepareForIdempotencyTest(http.StatusCreated)
+
+	router.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest("", "test"))
+	router.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest("", "test"))
+
+	assert.Equal(t, 2, *calls)
+}
+
+func TestIdempotency_TooLongKeyFailure(t *testing.T) {
+	router, calls := prepareForIdempotencyTest(http.StatusCreated)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newIdempotentRequest(strings.Repeat("a", config.IdempotencyKeyMaxLength+1), "test"))
+
+	assert.Equal(t, 0, *calls)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestIdempotency_NoStoreNotStored(t *testing.T) {
+	router, calls := prepareForIdempotencyTest(http.StatusCreated)
+
+	router.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest("key", "secret"))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newIdempotentRequest("key", "secret"))
+
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get(config.HeaderIdempotentReplayed))
+}
+
+func TestIdempotency_SetCookieNotStored(t *testing.T) {
+	router, calls := prepareForIdempotencyTest(http.StatusCreated)
+
+	router.ServeHTTP(httptest.NewRecorder(), newIdempotentRequest("key", "cookie"))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, newIdempotentRequest("key", "cookie"))
+
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get(config.HeaderIdempotentReplayed))
+}
+
+func TestIdempotency_AnonymousClientsNotShared(t *testing.T) {
+	router, calls := prepareForIdempotencyTest(http.StatusCreated)
+
+	req1 := newIdempotentRequest("key", "test")
+	req1.RemoteAddr = "192.0.2.1:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req1)
+	req2 := newIdempotentRequest("key", "test")
+	req2.RemoteAddr = "192.0.2.2:1234"
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req2)
+
+	assert.Equal(t, 2, *calls)
+	assert.Empty(t, rec.Header().Get(config.HeaderIdempotentReplayed))
+}
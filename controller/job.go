@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/service"
+	"github.com/onetooler/bistory-backend/util"
+)
+
+// JobController is a controller for managing the background jobs.
+type JobController interface {
+	GetJobs(c echo.Context) error
+	GetJobRuns(c echo.Context) error
+	TriggerJob(c echo.Context) error
+}
+
+type jobController struct {
+	container container.Container
+	service   service.JobService
+}
+
+// NewJobController is constructor.
+func NewJobController(container container.Container) JobController {
+	return &jobController{container: container, service: service.NewJobService(container)}
+}
+
+// GetJobs returns the background jobs.
+// @Summary Get the background jobs
+// @Description Get the recurring and one-off background jobs
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param status query string false "Statuses to filter by, separated by commas"
+// @Param handler query string false "Handlers to filter by, separated by commas"
+// @Param page query int false "Page number, starting from 1"
+// @Param size query int false "Page size, up to 100"
+// @Param cursor query string false "Cursor of the next page. An empty cursor requests the first page in the cursor mode."
+// @Param sort query string false "Field to sort by, descending with - prefix"
+// @Success 200 {object} dto.Page[model.Job] "Success to fetch data."
+// @Failure 400 {string} message "Failed to fetch data."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Router /admin/jobs [get]
+func (controller *jobController) GetJobs(c echo.Context) error {
+	query, err := infrastructure.ParseQuery(c.QueryParams(), service.JobQuerySpec)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	var jobs *dto.Page[model.Job]
+	jobs, err = controller.service.GetJobs(query)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, jobs)
+}
+
+// GetJobRuns returns the latest runs of a job.
+// @Summary Get the runs of a job
+// @Description Get the latest runs of a background job
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param jobId path int true "Job ID"
+// @Param status query string false "Statuses to filter by, separated by commas"
+// @Param page query int false "Page number, starting from 1"
+// @Param size query int false "Page size, up to 100"
+// @Param cursor query string false "Cursor of the next page. An empty cursor requests the first page in the cursor mode."
+// @Param sort query string false "Field to sort by, descending with - prefix"
+// @Success 200 {object} dto.Page[model.JobRun] "Success to fetch data."
+// @Failure 400 {string} message "Failed to fetch data."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Failure 404 {string} message "The job is not found."
+// @Router /admin/jobs/{jobId}/runs [get]
+func (controller *jobController) GetJobRuns(c echo.Context) error {
+	jobId := util.ConvertToUint(c.Param(config.APIAdminJobIdParam))
+	if jobId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	query, err := infrastructure.ParseQuery(c.QueryParams(), service.JobRunQuerySpec)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	var runs *dto.Page[model.JobRun]
+	runs, err = controller.service.GetJobRuns(jobId, query)
+	if errors.Is(err, infrastructure.ErrJobNotFound) {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, runs)
+}
+
+// TriggerJob makes a job run at the next poll of the scheduler.
+// @Summary Trigger a job
+// @Description Make a background job run as soon as possible
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param jobId path int true "Job ID"
+// @Success 202 {boolean} bool "Success to trigger the job."
+// @Failure 400 {string} message "Failed to trigger the job."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Failure 404 {string} message "The job is not found."
+// @Router /admin/jobs/{jobId}/trigger [post]
+func (controller *jobController) TriggerJob(c echo.Context) error {
+	jobId := util.ConvertToUint(c.Param(config.APIAdminJobIdParam))
+	if jobId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	err := controller.service.TriggerJob(jobId)
+	if errors.Is(err, infrastructure.ErrJobNotFound) {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusAccepted, true)
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockJobService struct {
+	getJobs    func(*infrastructure.Query) (*dto.Page[model.Job], error)
+	getJobRuns func(uint, *infrastructure.Query) (*dto.Page[model.JobRun], error)
+	triggerJob func(uint) error
+}
+
+func (m *mockJobService) GetJobs(query *infrastructure.Query) (*dto.Page[model.Job], error) {
+	return m.getJobs(query)
+}
+
+func (m *mockJobService) GetJobRuns(jobId uint, query *infrastructure.Query) (*dto.Page[model.JobRun], error) {
+	return m.getJobRuns(jobId, query)
+}
+
+func (m *mockJobService) TriggerJob(jobId uint) error {
+	return m.triggerJob(jobId)
+}
+
+func TestGetJobs_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	job := jobController{
+		container,
+		&mockJobService{
+			getJobs: func(query *infrastructure.Query) (*dto.Page[model.Job], error) {
+				return &dto.Page[model.Job]{Items: []model.Job{{Name: "token.cleanup", Handler: "token.cleanup", Schedule: "@daily"}}, Total: 1, Size: query.Size}, nil
+			},
+		},
+	}
+	router.GET(config.APIAdminJobs, func(c echo.Context) error { return job.GetJobs(c) })
+
+	req := httptest.NewRequest(http.MethodGet, config.APIAdminJobs+"?size=5", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := dto.Page[model.Job]{}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Len(t, body.Items, 1)
+	assert.Equal(t, 5, body.Size)
+	assert.Equal(t, "token.cleanup", body.Items[0].Name)
+}
+
+func TestGetJobRuns_NotFoundFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	job := jobController{
+		container,
+		&mockJobService{
+			getJobRuns: func(jobId uint, query *infrastructure.Query) (*dto.Page[model.JobRun], error) {
+				return nil, infrastructure.ErrJobNotFound
+			},
+		},
+	}
+	router.GET(config.APIAdminJobRuns, func(c echo.Context) error { return job.GetJobRuns(c) })
+
+	req := httptest.NewRequest(http.MethodGet, jobPath(config.APIAdminJobRuns, 9999), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestTriggerJob_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	var triggered uint
+	job := jobController{
+		container,
+		&mockJobService{
+			triggerJob: func(jobId uint) error {
+				triggered = jobId
+				return nil
+			},
+		},
+	}
+	router.POST(config.APIAdminJobTrigger, func(c echo.Context) error { return job.TriggerJob(c) })
+
+	req := httptest.NewRequest(http.MethodPost, jobPath(config.APIAdminJobTrigger, 1), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, uint(1), triggered)
+}
+
+func TestTriggerJob_InvalidIdFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	job := jobController{container, &mockJobService{}}
+	router.POST(config.APIAdminJobTrigger, func(c echo.Context) error { return job.TriggerJob(c) })
+
+	req := httptest.NewRequest(http.MethodPost, strings.Replace(config.APIAdminJobTrigger, ":"+config.APIAdminJobIdParam, "abc", 1), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func jobPath(path string, id uint) string {
+	return strings.Replace(path, ":"+config.APIAdminJobIdParam, strconv.Itoa(int(id)), 1)
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/logger"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogLevelController is a controller for changing the log levels of the components at runtime.
+type LogLevelController interface {
+	GetLogLevels(c echo.Context) error
+	UpdateLogLevel(c echo.Context) error
+}
+
+type logLevelController struct {
+	container container.Container
+}
+
+// NewLogLevelController is constructor.
+func NewLogLevelController(container container.Container) LogLevelController {
+	return &logLevelController{container: container}
+}
+
+// GetLogLevels returns the log levels of the components.
+// @Summary Get the log levels
+// @Description Get the current log levels of the components, such as http, gorm, session and email
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} logger.ComponentLevel "Success to fetch data."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Router /admin/log-levels [get]
+func (controller *logLevelController) GetLogLevels(c echo.Context) error {
+	return c.JSON(http.StatusOK, controller.container.GetLogger().GetLevels().Get())
+}
+
+// UpdateLogLevel changes the log level of a component.
+// @Summary Change the log level of a component
+// @Description Change the log level of a component, and restore the previous level after revertAfter if it is given
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Param component path string true "Component name"
+// @Param data body dto.UpdateLogLevelDto true "Level and the duration until the revert, such as 15m"
+// @Success 200 {array} logger.ComponentLevel "Success to change the level."
+// @Failure 400 {string} message "Failed to change the level."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Failure 404 {string} message "The component is not found."
+// @Failure 422 {object} APIError "The request is not valid. Returns the errors of the fields."
+// @Router /admin/log-levels/{component} [put]
+func (controller *logLevelController) UpdateLogLevel(c echo.Context) error {
+	data := dto.NewUpdateLogLevelDto()
+	if err := c.Bind(data); err != nil {
+		return bindError(c, err)
+	}
+	// the level and revertAfter have been validated by the binding
+	level, _ := zapcore.ParseLevel(data.Level)
+	var revertAfter time.Duration
+	if data.RevertAfter != "" {
+		revertAfter, _ = time.ParseDuration(data.RevertAfter)
+	}
+
+	component := c.Param(config.APIAdminLogComponentParam)
+	levels := controller.container.GetLogger().GetLevels()
+	err := levels.Set(component, level, revertAfter)
+	if errors.Is(err, logger.ErrUnknownComponent) {
+		return c.String(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	controller.container.GetLogger().GetZapLoggerFromContext(c.Request().Context()).
+		Infof("Changed the log level of %s to %s, revert after %s", component, level, revertAfter)
+	return c.JSON(http.StatusOK, levels.Get())
+}
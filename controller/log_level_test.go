@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/logger"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestGetLogLevels_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	logLevel := NewLogLevelController(container)
+	router.GET(config.APIAdminLogLevels, func(c echo.Context) error { return logLevel.GetLogLevels(c) })
+
+	req := httptest.NewRequest(http.MethodGet, config.APIAdminLogLevels, nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := []logger.ComponentLevel{}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	components := []string{}
+	for _, level := range body {
+		components = append(components, level.Component)
+		assert.Equal(t, "debug", level.Level)
+	}
+	assert.Subset(t, components, []string{config.LogComponentHTTP, config.LogComponentGorm, config.LogComponentSession, config.LogComponentEmail})
+}
+
+func TestUpdateLogLevel_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	logLevel := NewLogLevelController(container)
+	router.PUT(config.APIAdminLogLevelComponentPath, func(c echo.Context) error { return logLevel.UpdateLogLevel(c) })
+
+	param := &dto.UpdateLogLevelDto{Level: "warn", RevertAfter: "15m"}
+	req := testutil.NewJSONRequest(http.MethodPut, strings.Replace(config.APIAdminLogLevelComponentPath, ":"+config.APIAdminLogComponentParam, config.LogComponentGorm, 1), param)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := []logger.ComponentLevel{}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	for _, level := range body {
+		if level.Component == config.LogComponentGorm {
+			assert.Equal(t, "warn", level.Level)
+			assert.NotNil(t, level.RevertAt)
+		}
+	}
+	assert.False(t, container.GetLogger().Component(config.LogComponentGorm).GetZapLogger().Desugar().Core().Enabled(zapcore.InfoLevel))
+}
+
+func TestUpdateLogLevel_UnknownComponentFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	logLevel := NewLogLevelController(container)
+	router.PUT(config.APIAdminLogLevelComponentPath, func(c echo.Context) error { return logLevel.UpdateLogLevel(c) })
+
+	param := &dto.UpdateLogLevelDto{Level: "warn"}
+	req := testutil.NewJSONRequest(http.MethodPut, config.APIAdminLogLevels+"/unknown", param)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestUpdateLogLevel_InvalidParamFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	logLevel := NewLogLevelController(container)
+	router.PUT(config.APIAdminLogLevelComponentPath, func(c echo.Context) error { return logLevel.UpdateLogLevel(c) })
+
+	for _, param := range []*dto.UpdateLogLevelDto{{Level: "verbose"}, {Level: "info", RevertAfter: "soon"}, {Level: "info", RevertAfter: "-1m"}} {
+		req := testutil.NewJSONRequest(http.MethodPut, config.APIAdminLogLevels+"/"+config.LogComponentHTTP, param)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	}
+}
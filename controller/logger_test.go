@@ -7,6 +7,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/logger"
 	"github.com/onetooler/bistory-backend/testutil"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap/zaptest/observer"
@@ -29,6 +30,63 @@ func TestLogging(t *testing.T) {
 	assert.True(t, assertLogger("None /api/health GET 200", allLogs))
 }
 
+func TestLogging_RequestId(t *testing.T) {
+	router, container, logs := testutil.PrepareForLoggerTest()
+
+	health := NewHealthController(container)
+	router.GET(config.APIHealth, func(c echo.Context) error { return health.GetHealthCheck(c) })
+
+	req := httptest.NewRequest("GET", config.APIHealth, nil)
+	req.Header.Set(echo.HeaderXRequestID, "test-request-id")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, "test-request-id", rec.Header().Get(echo.HeaderXRequestID))
+	assert.True(t, assertLogger("None /api/health GET 200 test-request-id", logs.All()))
+	for _, l := range logs.FilterMessageSnippet("/api/health").All() {
+		assert.Equal(t, "test-request-id", l.ContextMap()[logger.RequestIdField])
+	}
+}
+
+func TestLogging_InvalidRequestId(t *testing.T) {
+	router, container, logs := testutil.PrepareForLoggerTest()
+
+	health := NewHealthController(container)
+	router.GET(config.APIHealth, func(c echo.Context) error { return health.GetHealthCheck(c) })
+
+	req := httptest.NewRequest("GET", config.APIHealth, nil)
+	req.Header.Set(echo.HeaderXRequestID, "invalid request id")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	requestId := rec.Header().Get(echo.HeaderXRequestID)
+	assert.Len(t, requestId, config.RequestIdLength)
+	assert.True(t, assertLogger("None /api/health GET 200 "+requestId, logs.All()))
+}
+
+func TestLogging_Redaction(t *testing.T) {
+	router, container, logs := testutil.PrepareForLoggerTest()
+
+	auth := NewAuthController(container)
+	router.POST(config.APIAuthLogin, func(c echo.Context) error { return auth.Login(c) })
+
+	req := testutil.NewJSONRequest("POST", config.APIAuthLogin, createLoginSuccessAccount())
+	req.Header.Set(echo.HeaderCookie, "other=bst_secret")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.True(t, assertLogger(`"password":"[REDACTED]"`, logs.All()))
+	assert.True(t, assertLogger("Cookie:[[REDACTED]]", logs.All()))
+	assert.True(t, assertLogger(`"email":"t***@example.com"`, logs.All()))
+	for _, l := range logs.All() {
+		assert.NotContains(t, l.Message, "bst_secret")
+		assert.NotContains(t, l.Message, "$2a$")
+	}
+}
+
 func assertLogger(message string, logs []observer.LoggedEntry) bool {
 	for _, l := range logs {
 		if strings.Contains(l.Message, message) {
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+)
+
+// errPreconditionFailed is the error of the state-changing request whose If-Match does not match the current resource.
+var errPreconditionFailed = errors.New("the resource has been modified since it was read")
+
+// notModified returns true if the If-None-Match header of the request matches a given entity tag.
+// The tags are compared weakly, as RFC 9110 requires for If-None-Match.
+func notModified(c echo.Context, etag string) bool {
+	header := c.Request().Header.Get(config.HeaderIfNoneMatch)
+	if header == "" {
+		return false
+	}
+	for _, tag := range parseETags(header) {
+		if tag == "*" || weakMatch(tag, etag) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasIfMatch returns true if the request has the If-Match header.
+func hasIfMatch(c echo.Context) bool {
+	return c.Request().Header.Get(config.HeaderIfMatch) != ""
+}
+
+// matchesIfMatch returns true if the If-Match header of the request is absent or matches a given entity tag.
+// The tags are compared strongly, as RFC 9110 requires for If-Match, so a weak tag never matches.
+func matchesIfMatch(c echo.Context, etag string) bool {
+	if !hasIfMatch(c) {
+		return true
+	}
+	for _, tag := range parseETags(c.Request().Header.Get(config.HeaderIfMatch)) {
+		if tag == "*" || !strings.HasPrefix(tag, "W/") && tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// weakMatch compares the entity tags weakly, ignoring the weak prefixes.
+func weakMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// setETag sets the entity tag of the resource in the response.
+func setETag(c echo.Context, etag string) {
+	c.Response().Header().Set(config.HeaderETag, etag)
+}
+
+func parseETags(header string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
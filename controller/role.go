@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/service"
+	"github.com/onetooler/bistory-backend/util"
+)
+
+// RoleController is a controller for managing roles.
+type RoleController interface {
+	GetRoles(c echo.Context) error
+	GetAccountRoles(c echo.Context) error
+	AssignRole(c echo.Context) error
+	UnassignRole(c echo.Context) error
+}
+
+type roleController struct {
+	container container.Container
+	service   service.RoleService
+}
+
+// NewRoleController is constructor.
+func NewRoleController(container container.Container) RoleController {
+	return &roleController{container: container, service: service.NewRoleService(container)}
+}
+
+// GetRoles returns all roles with their permissions.
+// @Summary Get all roles
+// @Description Get all roles with their permissions
+// @Tags Role
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} model.Role "Success to fetch data."
+// @Failure 400 {string} message "Failed to fetch data."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Router /role [get]
+func (controller *roleController) GetRoles(c echo.Context) error {
+	roles, err := controller.service.GetRoles(c.Request().Context())
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, roles)
+}
+
+// GetAccountRoles returns the roles assigned to the account.
+// @Summary Get the roles of a account
+// @Description Get the roles of a account
+// @Tags Role
+// @Accept  json
+// @Produce  json
+// @Param accountId path int true "Account ID"
+// @Success 200 {array} model.Role "Success to fetch data."
+// @Failure 400 {string} message "Failed to fetch data."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Router /account/{accountId}/roles [get]
+func (controller *roleController) GetAccountRoles(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	if accountId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	roles, err := controller.service.GetAccountRoles(c.Request().Context(), accountId)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, roles)
+}
+
+// AssignRole assigns the role to the account by http put.
+// @Summary Assign a role to a account
+// @Description Assign a role to a account. It takes effect from the next login of the account.
+// @Tags Role
+// @Accept  json
+// @Produce  json
+// @Param accountId path int true "Account ID"
+// @Param role path string true "Role name"
+// @Success 200 {boolean} bool "Success to assign the role."
+// @Failure 400 {string} message "Failed to assign the role."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Router /account/{accountId}/roles/{role} [put]
+func (controller *roleController) AssignRole(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	if accountId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	if err := controller.service.AssignRole(c.Request().Context(), accountId, c.Param(config.APIAccountRoleNameParam)); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, true)
+}
+
+// UnassignRole unassigns the role from the account by http delete.
+// @Summary Unassign a role from a account
+// @Description Unassign a role from a account. It takes effect from the next login of the account.
+// @Tags Role
+// @Accept  json
+// @Produce  json
+// @Param accountId path int true "Account ID"
+// @Param role path string true "Role name"
+// @Success 200 {boolean} bool "Success to unassign the role."
+// @Failure 400 {string} message "Failed to unassign the role."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Router /account/{accountId}/roles/{role} [delete]
+func (controller *roleController) UnassignRole(c echo.Context) error {
+	accountId := util.ConvertToUint(c.Param(config.APIAccountIdParam))
+	if accountId == 0 {
+		return c.String(http.StatusBadRequest, "failed to parse id")
+	}
+
+	if err := controller.service.UnassignRole(c.Request().Context(), accountId, c.Param(config.APIAccountRoleNameParam)); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, true)
+}
@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRoleService struct {
+	getRoles        func() ([]model.Role, error)
+	getAccountRoles func(uint) ([]model.Role, error)
+	assignRole      func(uint, string) error
+	unassignRole    func(uint, string) error
+}
+
+func (m *mockRoleService) GetRoles(ctx context.Context) ([]model.Role, error) {
+	return m.getRoles()
+}
+
+func (m *mockRoleService) GetAccountRoles(ctx context.Context, accountId uint) ([]model.Role, error) {
+	return m.getAccountRoles(accountId)
+}
+
+func (m *mockRoleService) AssignRole(ctx context.Context, accountId uint, roleName string) error {
+	return m.assignRole(accountId, roleName)
+}
+
+func (m *mockRoleService) UnassignRole(ctx context.Context, accountId uint, roleName string) error {
+	return m.unassignRole(accountId, roleName)
+}
+
+func TestGetRoles_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	admin := newTestAccountWithRole(model.RoleAdmin)
+	role := roleController{
+		container,
+		&mockRoleService{
+			getRoles: func() ([]model.Role, error) {
+				return model.DefaultRoles(), nil
+			},
+		},
+	}
+	router.GET(config.APIRole, func(c echo.Context) error {
+		login(container, c, admin)
+		return role.GetRoles(c)
+	})
+
+	req := testutil.NewJSONRequest(http.MethodGet, config.APIRole, nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAssignRole_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	admin := newTestAccountWithRole(model.RoleAdmin)
+	assigned := ""
+	role := roleController{
+		container,
+		&mockRoleService{
+			assignRole: func(accountId uint, roleName string) error {
+				assigned = roleName
+				return nil
+			},
+		},
+	}
+	router.PUT(config.APIAccountRoleNamePath, func(c echo.Context) error {
+		login(container, c, admin)
+		return role.AssignRole(c)
+	})
+
+	req := testutil.NewJSONRequest(http.MethodPut, accountRolePath(admin.ID+1, model.RoleSupport), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, model.RoleSupport, assigned)
+}
+
+func accountRolePath(accountId uint, roleName string) string {
+	path := strings.Replace(config.APIAccountRoleNamePath, ":"+config.APIAccountIdParam, strconv.Itoa(int(accountId)), 1)
+	return strings.Replace(path, ":"+config.APIAccountRoleNameParam, roleName, 1)
+}
+
+func newTestAccountWithRole(roleName string) model.Account {
+	account := newTestUserAccount()
+	account.ID = 10
+	account.LoginId = roleName + "Test"
+	for _, role := range model.DefaultRoles() {
+		if role.Name == roleName {
+			account.Roles = []model.Role{role}
+		}
+	}
+	return account
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/middleware"
+)
+
+// RouteController is a controller returns the routes and their authorization policies.
+type RouteController interface {
+	GetRoutes(c echo.Context) error
+}
+
+type routeController struct {
+	container container.Container
+	table     middleware.RouteTable
+}
+
+// NewRouteController is constructor.
+func NewRouteController(container container.Container, table middleware.RouteTable) RouteController {
+	return &routeController{container: container, table: table}
+}
+
+// GetRoutes returns the effective route and permission table.
+// @Summary Get the route table
+// @Description Get the routes with their authorization policies and access token scopes
+// @Tags Admin
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} middleware.RoutePolicy "Success to fetch data."
+// @Failure 401 {boolean} bool "Failed to the authentication. Returns false."
+// @Failure 403 {boolean} bool "Failed to the authorization. Returns false."
+// @Router /admin/routes [get]
+func (controller *routeController) GetRoutes(c echo.Context) error {
+	return c.JSON(http.StatusOK, controller.table.Entries())
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/middleware"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRoutes_Success(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	table := middleware.NewRouteTable()
+	route := NewRouteController(container, table)
+	router.GET(config.APIHealth, func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+		table.Declare(container, http.MethodGet, config.APIHealth, middleware.Public()))
+	router.GET(config.APIAdminRoutes, func(c echo.Context) error { return route.GetRoutes(c) },
+		table.Declare(container, http.MethodGet, config.APIAdminRoutes, middleware.RequirePermission(model.PermissionSystemAdmin)))
+
+	// the account of the session is reloaded at every request, so it must be stored
+	admin := model.Account{}
+	assert.Nil(t, container.GetRepository().Where("login_id = ?", "test").Preload("Roles.Permissions").First(&admin).Error)
+	router.POST(config.APIAuthLogin, func(c echo.Context) error {
+		login(container, c, admin)
+		return c.NoContent(http.StatusOK)
+	})
+	loginRec := httptest.NewRecorder()
+	router.ServeHTTP(loginRec, testutil.NewJSONRequest(http.MethodPost, config.APIAuthLogin, nil))
+
+	req := testutil.NewJSONRequest(http.MethodGet, config.APIAdminRoutes, nil)
+	for _, cookie := range loginRec.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	body := []middleware.RoutePolicy{}
+	err := json.Unmarshal(rec.Body.Bytes(), &body)
+	assert.Nil(t, err)
+	assert.Equal(t, []middleware.RoutePolicy{
+		{Method: http.MethodGet, Path: config.APIAdminRoutes, Policy: "permission(system:admin)"},
+		{Method: http.MethodGet, Path: config.APIHealth, Policy: "public"},
+	}, body)
+}
+
+func TestGetRoutes_NoLoginFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	table := middleware.NewRouteTable()
+	route := NewRouteController(container, table)
+	router.GET(config.APIAdminRoutes, func(c echo.Context) error { return route.GetRoutes(c) },
+		table.Declare(container, http.MethodGet, config.APIAdminRoutes, middleware.RequirePermission(model.PermissionSystemAdmin)))
+
+	req := testutil.NewJSONRequest(http.MethodGet, config.APIAdminRoutes, nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRoutePolicy_OwnerOrPermission(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+	user := newTestAccountWithRole(model.RoleUser)
+
+	policy := middleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountRead)
+	router.GET(config.APIAccountIdPath, func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			login(container, c, user)
+			return next(c)
+		}
+	}, middleware.NewRouteTable().Declare(container, http.MethodGet, config.APIAccountIdPath, policy))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, testutil.NewJSONRequest(http.MethodGet, fmt.Sprintf("%s/%d", config.APIAccount, user.ID), nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, testutil.NewJSONRequest(http.MethodGet, fmt.Sprintf("%s/%d", config.APIAccount, user.ID+1), nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestRoutePolicy_AccessTokenWithoutScopeFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+	_, plain := createAccessTokenAccount(container, model.AllScopes, nil)
+
+	router.GET(config.APIRole, func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+		middleware.NewRouteTable().Declare(container, http.MethodGet, config.APIRole, middleware.Authenticated()))
+
+	req := testutil.NewJSONRequest(http.MethodGet, config.APIRole, nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+plain)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestRoutePolicy_MustChangePasswordFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+	admin := newTestAccountWithRole(model.RoleAdmin)
+	admin.MustChangePassword = true
+
+	loginFirst := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			login(container, c, admin)
+			return next(c)
+		}
+	}
+	table := middleware.NewRouteTable()
+	router.GET(config.APIRole, func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+		loginFirst, table.Declare(container, http.MethodGet, config.APIRole, middleware.RequirePermission(model.PermissionRoleManage)))
+	router.POST(config.APIAccountChangePassword, func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+		loginFirst, table.Declare(container, http.MethodPost, config.APIAccountChangePassword,
+			middleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountWrite).AllowPasswordChange()))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, testutil.NewJSONRequest(http.MethodGet, config.APIRole, nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, testutil.NewJSONRequest(http.MethodPost, fmt.Sprintf("%s/%d/change-password", config.APIAccount, admin.ID), nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRouteTableVerify_UndeclaredRouteFailure(t *testing.T) {
+	router, container := testutil.PrepareForControllerTest(false)
+
+	table := middleware.NewRouteTable()
+	router.GET(config.APIHealth, func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+		table.Declare(container, http.MethodGet, config.APIHealth, middleware.Public()))
+	assert.Nil(t, table.Verify(router.Routes()))
+
+	router.GET(config.APIRole, func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	err := table.Verify(router.Routes())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "GET "+config.APIRole)
+}
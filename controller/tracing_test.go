@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracing_Login(t *testing.T) {
+	router, container, recorder := testutil.PrepareForTracingTest()
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	auth := NewAuthController(container)
+	router.POST(config.APIAuthLogin, func(c echo.Context) error { return auth.Login(c) })
+
+	req := testutil.NewJSONRequest("POST", config.APIAuthLogin, createLoginSuccessAccount())
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	root := findSpan(recorder.Ended(), "POST "+config.APIAuthLogin)
+	if assert.NotNil(t, root) {
+		names := []string{}
+		for _, span := range recorder.Ended() {
+			if span.SpanContext().TraceID() == root.SpanContext().TraceID() {
+				names = append(names, span.Name())
+			}
+		}
+		assert.Subset(t, names, []string{"gorm.query", "gorm.update", "bcrypt.compare", "session.get", "session.save"})
+	}
+}
+
+func TestTracing_ContinueTrace(t *testing.T) {
+	router, container, recorder := testutil.PrepareForTracingTest()
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	health := NewHealthController(container)
+	router.GET(config.APIHealth, func(c echo.Context) error { return health.GetHealthCheck(c) })
+
+	req := httptest.NewRequest("GET", config.APIHealth, nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	span := findSpan(recorder.Ended(), "GET "+config.APIHealth)
+	if assert.NotNil(t, span) {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	}
+}
+
+func findSpan(spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
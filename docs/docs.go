@@ -16,6 +16,95 @@ const docTemplate = `{
     "basePath": "{{.BasePath}}",
     "paths": {
         "/account": {
+            "get": {
+                "description": "Get a page of the accounts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Get the accounts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Statuses to filter by, separated by commas",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorities to filter by, separated by commas",
+                        "name": "authority",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 100",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the next page. An empty cursor requests the first page in the cursor mode.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, descending with - prefix",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to fetch data.",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Page-model_Account"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to fetch data.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Failed to the authentication. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "503": {
+                        "description": "The database is unavailable.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "The request timed out.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    }
+                }
+            },
             "post": {
                 "description": "Create a new account",
                 "consumes": [
@@ -44,6 +133,12 @@ const docTemplate = `{
                         "description": "Success to create a new account.",
                         "schema": {
                             "$ref": "#/definitions/model.Account"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the account"
+                            }
                         }
                     },
                     "400": {
@@ -57,6 +152,24 @@ const docTemplate = `{
                         "schema": {
                             "type": "boolean"
                         }
+                    },
+                    "422": {
+                        "description": "The request is not valid. Returns the errors of the fields.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "The database is unavailable.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "The request timed out.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
                     }
                 }
             }
@@ -103,6 +216,88 @@ const docTemplate = `{
                         "schema": {
                             "type": "boolean"
                         }
+                    },
+                    "422": {
+                        "description": "The request is not valid. Returns the errors of the fields.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "The database is unavailable.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "The request timed out.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/account/restore": {
+            "post": {
+                "description": "Restore the account pending deletion",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Restore the account pending deletion",
+                "parameters": [
+                    {
+                        "description": "the restore token sent by email",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RestoreAccountDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to restore the account.",
+                        "schema": {
+                            "$ref": "#/definitions/model.Account"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the account"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to restore the account.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "The request is not valid. Returns the errors of the fields.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "The database is unavailable.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "The request timed out.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
                     }
                 }
             }
@@ -127,6 +322,12 @@ const docTemplate = `{
                         "name": "accountId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the account which the client has cached",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -134,6 +335,21 @@ const docTemplate = `{
                         "description": "Success to fetch data.",
                         "schema": {
                             "$ref": "#/definitions/model.Account"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the account"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "The account is not modified since the ETag.",
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the account"
+                            }
                         }
                     },
                     "400": {
@@ -147,11 +363,29 @@ const docTemplate = `{
                         "schema": {
                             "type": "boolean"
                         }
+                    },
+                    "404": {
+                        "description": "The account is not found.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "The database is unavailable.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "The request timed out.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
                     }
                 }
             },
             "delete": {
-                "description": "Delete the existing account",
+                "description": "Schedule the deletion of the existing account. It can be restored by logging in or by the restore token sent by email until it is purged.",
                 "consumes": [
                     "application/json"
                 ],
@@ -178,6 +412,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dto.DeleteAccountDto"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the account which the client has read",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -198,13 +438,35 @@ const docTemplate = `{
                         "schema": {
                             "type": "boolean"
                         }
+                    },
+                    "412": {
+                        "description": "The account has been modified since the ETag.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "The request is not valid. Returns the errors of the fields.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "The database is unavailable.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "The request timed out.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
                     }
                 }
-            }
-        },
-        "/account/{accountId}/": {
-            "post": {
-                "description": "Change account password",
+            },
+            "patch": {
+                "description": "Update account profile. Only the fields present in the body are changed, and the version must match the current one.\nIf the If-Match header is given, it is checked instead of the version.",
                 "consumes": [
                     "application/json"
                 ],
@@ -214,7 +476,7 @@ const docTemplate = `{
                 "tags": [
                     "Account"
                 ],
-                "summary": "Change account password",
+                "summary": "Update account profile",
                 "parameters": [
                     {
                         "type": "integer",
@@ -224,20 +486,32 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "the account password data for updating",
+                        "description": "the account profile data for updating",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.ChangeAccountPasswordDto"
+                            "$ref": "#/definitions/dto.UpdateAccountProfileDto"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the account which the client has read",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success to change the account password.",
+                        "description": "Success to update the account profile.",
                         "schema": {
                             "$ref": "#/definitions/model.Account"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the updated account"
+                            }
                         }
                     },
                     "400": {
@@ -251,13 +525,43 @@ const docTemplate = `{
                         "schema": {
                             "type": "boolean"
                         }
+                    },
+                    "409": {
+                        "description": "The account has been modified by another request.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "The account has been modified since the ETag.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "The request is not valid. Returns the errors of the fields.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "The database is unavailable.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "The request timed out.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
                     }
                 }
             }
         },
-        "/auth/email-verification/token-generate": {
+        "/account/{accountId}/": {
             "post": {
-                "description": "EmailVerificationTokenSend generate token and send it to email.",
+                "description": "Change account password",
                 "consumes": [
                     "application/json"
                 ],
@@ -265,36 +569,88 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Account"
                 ],
-                "summary": "EmailVerificationTokenSend generate token and send it to email.",
+                "summary": "Change account password",
                 "parameters": [
                     {
-                        "description": "Email for verification.",
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the account password data for updating",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.EmailVerificationTokenSendDto"
+                            "$ref": "#/definitions/dto.ChangeAccountPasswordDto"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the account which the client has read",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "Success to change the account password.",
+                        "schema": {
+                            "$ref": "#/definitions/model.Account"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the updated account"
+                            }
+                        }
                     },
                     "400": {
-                        "description": "Failed to send verification token.",
+                        "description": "Failed to the update.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Failed to the authentication. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "412": {
+                        "description": "The account has been modified since the ETag.",
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "422": {
+                        "description": "The request is not valid. Returns the errors of the fields.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "The database is unavailable.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "The request timed out.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
                     }
                 }
             }
         },
-        "/auth/email-verification/token-verify": {
-            "post": {
-                "description": "EmailVerificationTokenVerify using token.",
+        "/account/{accountId}/avatar": {
+            "get": {
+                "description": "Get the signed URLs of the avatar thumbnails",
                 "consumes": [
                     "application/json"
                 ],
@@ -302,76 +658,158 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Avatar"
                 ],
-                "summary": "EmailVerificationTokenVerify using token.",
+                "summary": "Get the avatar of account",
                 "parameters": [
                     {
-                        "description": "Token for verification.",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.EmailVerificationTokenVerifyDto"
-                        }
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "Success to fetch the avatar.",
+                        "schema": {
+                            "$ref": "#/definitions/dto.AvatarDto"
+                        }
                     },
                     "400": {
-                        "description": "Failed to verify token.",
+                        "description": "Failed to fetch the avatar.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Failed to the authentication. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "404": {
+                        "description": "The account has no avatar.",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            }
-        },
-        "/auth/login": {
+            },
             "post": {
-                "description": "Login using loginId and password.",
+                "description": "Upload a png, jpeg, gif or webp image. It is cropped to a square and resized to the fixed thumbnails.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Avatar"
                 ],
-                "summary": "Login using loginId and password.",
+                "summary": "Upload the avatar of account",
                 "parameters": [
                     {
-                        "description": "User name and Password for logged-in.",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "the avatar image",
+                        "name": "avatar",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to upload the avatar.",
                         "schema": {
-                            "$ref": "#/definitions/dto.LoginDto"
+                            "$ref": "#/definitions/dto.AvatarDto"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to upload the avatar.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Failed to the authentication. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "413": {
+                        "description": "The avatar is too large.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "The avatar is not a supported image.",
+                        "schema": {
+                            "type": "string"
                         }
                     }
+                }
+            },
+            "delete": {
+                "description": "Delete the avatar of account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Avatar"
+                ],
+                "summary": "Delete the avatar of account",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success to the authentication.",
+                        "description": "Success to delete the avatar.",
                         "schema": {
-                            "$ref": "#/definitions/model.Account"
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to delete the avatar.",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "401": {
-                        "description": "Failed to the authentication.",
+                        "description": "Failed to the authentication. Returns false.",
                         "schema": {
                             "type": "boolean"
                         }
+                    },
+                    "404": {
+                        "description": "The account has no avatar.",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/auth/loginAccount": {
+        "/account/{accountId}/export": {
             "get": {
-                "description": "Get the account data of logged-in user.",
+                "description": "Get the personal data exports of the account",
                 "consumes": [
                     "application/json"
                 ],
@@ -379,28 +817,71 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "DataExport"
+                ],
+                "summary": "Get the personal data exports",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Statuses to filter by, separated by commas",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 100",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the next page. An empty cursor requests the first page in the cursor mode.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, descending with - prefix",
+                        "name": "sort",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get the account data of logged-in user.",
                 "responses": {
                     "200": {
-                        "description": "Success to fetch the account data. If the security function is disable, it returns disabled message",
+                        "description": "Success to fetch the exports.",
                         "schema": {
-                            "$ref": "#/definitions/model.Account"
+                            "$ref": "#/definitions/dto.Page-model_DataExport"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to fetch the exports.",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "401": {
-                        "description": "The current user haven't logged-in yet. Returns false.",
+                        "description": "Failed to the authentication. Returns false.",
                         "schema": {
                             "type": "boolean"
                         }
                     }
                 }
-            }
-        },
-        "/auth/loginStatus": {
-            "get": {
-                "description": "Get the login status of current logged-in user.",
+            },
+            "post": {
+                "description": "Request a zip archive of all data held about the account. The download link is sent by email when it is ready.",
                 "consumes": [
                     "application/json"
                 ],
@@ -408,28 +889,97 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "DataExport"
+                ],
+                "summary": "Request a personal data export",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get the login status.",
                 "responses": {
-                    "200": {
-                        "description": "The current user have already logged-in. Returns true.",
+                    "202": {
+                        "description": "Success to request the export.",
+                        "schema": {
+                            "$ref": "#/definitions/model.DataExport"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to request the export.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Failed to the authentication. Returns false.",
                         "schema": {
                             "type": "boolean"
                         }
                     },
+                    "409": {
+                        "description": "An export is already in progress.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/account/{accountId}/export/{exportId}/download": {
+            "get": {
+                "description": "Redirect to the time-limited URL of the archive",
+                "tags": [
+                    "DataExport"
+                ],
+                "summary": "Download a personal data export",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Export ID",
+                        "name": "exportId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the archive."
+                    },
+                    "400": {
+                        "description": "Failed to fetch the export.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "401": {
-                        "description": "The current user haven't logged-in yet. Returns false.",
+                        "description": "Failed to the authentication. Returns false.",
                         "schema": {
                             "type": "boolean"
                         }
+                    },
+                    "404": {
+                        "description": "The export is not ready or has expired.",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/auth/logout": {
-            "post": {
-                "description": "Logout.",
+        "/account/{accountId}/roles": {
+            "get": {
+                "description": "Get the roles of a account",
                 "consumes": [
                     "application/json"
                 ],
@@ -437,19 +987,46 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Role"
+                ],
+                "summary": "Get the roles of a account",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Logout.",
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "Success to fetch data.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Role"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to fetch data.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
                     }
                 }
             }
         },
-        "/health": {
-            "get": {
-                "description": "Get the status of this application",
+        "/account/{accountId}/roles/{role}": {
+            "put": {
+                "description": "Assign a role to a account. It takes effect from the next login of the account.",
                 "consumes": [
                     "application/json"
                 ],
@@ -457,39 +1034,1375 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Health"
+                    "Role"
+                ],
+                "summary": "Assign a role to a account",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Role name",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get the status of this application",
                 "responses": {
                     "200": {
-                        "description": "healthy: This application is started.",
+                        "description": "Success to assign the role.",
                         "schema": {
-                            "type": "string"
+                            "type": "boolean"
                         }
                     },
-                    "404": {
-                        "description": "None: This application is stopped.",
+                    "400": {
+                        "description": "Failed to assign the role.",
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Unassign a role from a account. It takes effect from the next login of the account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role"
+                ],
+                "summary": "Unassign a role from a account",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Role name",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to unassign the role.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to unassign the role.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/account/{accountId}/tokens": {
+            "get": {
+                "description": "Get the access tokens of a account. The token values are not included.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "AccessToken"
+                ],
+                "summary": "Get the access tokens of a account",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 100",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the next page. An empty cursor requests the first page in the cursor mode.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, descending with - prefix",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to fetch data.",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Page-model_AccessToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to fetch data.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new access token. The token value is returned only once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "AccessToken"
+                ],
+                "summary": "Create a new access token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "a new access token data for creating",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateAccessTokenDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to create a new access token.",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreatedAccessTokenDto"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to create a new access token.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization, or the scopes are not granted to the access token of the caller. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "The request is not valid. Returns the errors of the fields.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/account/{accountId}/tokens/{tokenId}": {
+            "delete": {
+                "description": "Revoke the existing access token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "AccessToken"
+                ],
+                "summary": "Revoke the existing access token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Account ID",
+                        "name": "accountId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Access token ID",
+                        "name": "tokenId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to revoke the access token.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to revoke the access token.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "404": {
+                        "description": "The access token is not found.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs": {
+            "get": {
+                "description": "Get the recurring and one-off background jobs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get the background jobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Statuses to filter by, separated by commas",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Handlers to filter by, separated by commas",
+                        "name": "handler",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 100",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the next page. An empty cursor requests the first page in the cursor mode.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, descending with - prefix",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to fetch data.",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Page-model_Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to fetch data.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Failed to the authentication. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs/{jobId}/runs": {
+            "get": {
+                "description": "Get the latest runs of a background job",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get the runs of a job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Statuses to filter by, separated by commas",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, up to 100",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the next page. An empty cursor requests the first page in the cursor mode.",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by, descending with - prefix",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to fetch data.",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Page-model_JobRun"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to fetch data.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Failed to the authentication. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "404": {
+                        "description": "The job is not found.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/jobs/{jobId}/trigger": {
+            "post": {
+                "description": "Make a background job run as soon as possible",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Trigger a job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "jobId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Success to trigger the job.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to trigger the job.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Failed to the authentication. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "404": {
+                        "description": "The job is not found.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/log-levels": {
+            "get": {
+                "description": "Get the current log levels of the components, such as http, gorm, session and email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get the log levels",
+                "responses": {
+                    "200": {
+                        "description": "Success to fetch data.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/logger.ComponentLevel"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Failed to the authentication. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/log-levels/{component}": {
+            "put": {
+                "description": "Change the log level of a component, and restore the previous level after revertAfter if it is given",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Change the log level of a component",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Component name",
+                        "name": "component",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Level and the duration until the revert, such as 15m",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateLogLevelDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to change the level.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/logger.ComponentLevel"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to change the level.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Failed to the authentication. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "404": {
+                        "description": "The component is not found.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "The request is not valid. Returns the errors of the fields.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/routes": {
+            "get": {
+                "description": "Get the routes with their authorization policies and access token scopes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get the route table",
+                "responses": {
+                    "200": {
+                        "description": "Success to fetch data.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/middleware.RoutePolicy"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Failed to the authentication. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/email-verification/token-generate": {
+            "post": {
+                "description": "EmailVerificationTokenSend generate token and send it to email.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "EmailVerificationTokenSend generate token and send it to email.",
+                "parameters": [
+                    {
+                        "description": "Email for verification.",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.EmailVerificationTokenSendDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Failed to send verification token.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "The request is not valid. Returns the errors of the fields.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "The database is unavailable.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "The request timed out.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/email-verification/token-verify": {
+            "post": {
+                "description": "EmailVerificationTokenVerify using token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "EmailVerificationTokenVerify using token.",
+                "parameters": [
+                    {
+                        "description": "Token for verification.",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.EmailVerificationTokenVerifyDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Failed to verify token.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "The request is not valid. Returns the errors of the fields.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Login using loginId and password.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Login using loginId and password.",
+                "parameters": [
+                    {
+                        "description": "User name and Password for logged-in.",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoginDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to the authentication.",
+                        "schema": {
+                            "$ref": "#/definitions/model.Account"
+                        }
+                    },
+                    "401": {
+                        "description": "Failed to the authentication.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "422": {
+                        "description": "The request is not valid. Returns the errors of the fields.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "The database is unavailable.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "The request timed out.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/loginAccount": {
+            "get": {
+                "description": "Get the account data of logged-in user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Get the account data of logged-in user.",
+                "responses": {
+                    "200": {
+                        "description": "Success to fetch the account data. If the security function is disable, it returns disabled message",
+                        "schema": {
+                            "$ref": "#/definitions/model.Account"
+                        }
+                    },
+                    "401": {
+                        "description": "The current user haven't logged-in yet. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/loginStatus": {
+            "get": {
+                "description": "Get the login status of current logged-in user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Get the login status.",
+                "responses": {
+                    "200": {
+                        "description": "The current user have already logged-in. Returns true.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "401": {
+                        "description": "The current user haven't logged-in yet. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "description": "Logout.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Logout.",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/auth/setup": {
+            "post": {
+                "description": "Create the first admin account with the one-time setup token, which is printed to the log at boot while no admin exists.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Create the first admin account.",
+                "parameters": [
+                    {
+                        "description": "Setup token and the admin account to create.",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SetupAdminDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to create the admin account.",
+                        "schema": {
+                            "$ref": "#/definitions/model.Account"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to create the admin account.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "The setup token is invalid or expired.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "An admin account already exists.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "The request is not valid. Returns the errors of the fields.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "The database is unavailable.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    },
+                    "504": {
+                        "description": "The request timed out.",
+                        "schema": {
+                            "$ref": "#/definitions/controller.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{key}": {
+            "get": {
+                "description": "Get a file of the local storage by the signed URL",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "File"
+                ],
+                "summary": "Get a file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Expiration time of the URL",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature of the URL",
+                        "name": "signature",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success to fetch the file.",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "The signature is invalid or expired.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "The file is not found.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Get the status of this application",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Get the status of this application",
+                "responses": {
+                    "200": {
+                        "description": "healthy: This application is started.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "None: This application is stopped.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/role": {
+            "get": {
+                "description": "Get all roles with their permissions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role"
+                ],
+                "summary": "Get all roles",
+                "responses": {
+                    "200": {
+                        "description": "Success to fetch data.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Role"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to fetch data.",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Failed to the authorization. Returns false.",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controller.APIError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FieldError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "requestId": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AvatarDto": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "large": {
+                    "type": "string"
+                },
+                "small": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ChangeAccountPasswordDto": {
+            "type": "object",
+            "properties": {
+                "NewPassword": {
+                    "type": "string"
+                },
+                "oldPassword": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateAccessTokenDto": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.CreateAccountDto": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "loginId": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreatedAccessTokenDto": {
+            "type": "object",
+            "properties": {
+                "accountId": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.DeleteAccountDto": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.EmailVerificationTokenSendDto": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.EmailVerificationTokenVerifyDto": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.FindLoginIdDto": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.LoginDto": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "loginId": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.Page-model_AccessToken": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AccessToken"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.Page-model_Account": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Account"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.Page-model_DataExport": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DataExport"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.Page-model_Job": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Job"
                     }
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.ChangeAccountPasswordDto": {
+        },
+        "dto.Page-model_JobRun": {
             "type": "object",
             "properties": {
-                "NewPassword": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.JobRun"
+                    }
+                },
+                "nextCursor": {
                     "type": "string"
                 },
-                "oldPassword": {
+                "page": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.RestoreAccountDto": {
+            "type": "object",
+            "properties": {
+                "token": {
                     "type": "string"
                 }
             }
         },
-        "dto.CreateAccountDto": {
+        "dto.SetupAdminDto": {
             "type": "object",
             "properties": {
                 "email": {
@@ -500,64 +2413,121 @@ const docTemplate = `{
                 },
                 "password": {
                     "type": "string"
+                },
+                "token": {
+                    "type": "string"
                 }
             }
         },
-        "dto.DeleteAccountDto": {
+        "dto.UpdateAccountProfileDto": {
             "type": "object",
             "properties": {
-                "password": {
+                "bio": {
+                    "type": "string"
+                },
+                "displayName": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "timeZone": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.EmailVerificationTokenSendDto": {
+        "dto.UpdateLogLevelDto": {
             "type": "object",
             "properties": {
-                "email": {
+                "level": {
+                    "type": "string"
+                },
+                "revertAfter": {
                     "type": "string"
                 }
             }
         },
-        "dto.EmailVerificationTokenVerifyDto": {
+        "gorm.DeletedAt": {
             "type": "object",
             "properties": {
-                "token": {
+                "time": {
                     "type": "string"
+                },
+                "valid": {
+                    "description": "Valid is true if Time is not NULL",
+                    "type": "boolean"
                 }
             }
         },
-        "dto.FindLoginIdDto": {
+        "logger.ComponentLevel": {
             "type": "object",
             "properties": {
-                "email": {
+                "component": {
+                    "type": "string"
+                },
+                "level": {
+                    "type": "string"
+                },
+                "revertAt": {
                     "type": "string"
                 }
             }
         },
-        "dto.LoginDto": {
+        "middleware.RoutePolicy": {
             "type": "object",
             "properties": {
-                "email": {
+                "method": {
                     "type": "string"
                 },
-                "loginId": {
+                "path": {
                     "type": "string"
                 },
-                "password": {
+                "policy": {
+                    "type": "string"
+                },
+                "scope": {
                     "type": "string"
                 }
             }
         },
-        "gorm.DeletedAt": {
+        "model.AccessToken": {
             "type": "object",
             "properties": {
-                "time": {
+                "accountId": {
+                    "type": "integer"
+                },
+                "createdAt": {
                     "type": "string"
                 },
-                "valid": {
-                    "description": "Valid is true if Time is not NULL",
-                    "type": "boolean"
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
                 }
             }
         },
@@ -567,8 +2537,8 @@ const docTemplate = `{
                 "authority": {
                     "$ref": "#/definitions/model.Authority"
                 },
-                "badAttempt": {
-                    "type": "integer"
+                "bio": {
+                    "type": "string"
                 },
                 "createdAt": {
                     "type": "string"
@@ -576,20 +2546,48 @@ const docTemplate = `{
                 "deletedAt": {
                     "$ref": "#/definitions/gorm.DeletedAt"
                 },
+                "deletionScheduledAt": {
+                    "description": "DeletionScheduledAt is the time when the account pending deletion is purged.",
+                    "type": "string"
+                },
+                "displayName": {
+                    "description": "Profile",
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
+                "locale": {
+                    "type": "string"
+                },
                 "loginId": {
                     "type": "string"
                 },
+                "mustChangePassword": {
+                    "description": "MustChangePassword is set on the bootstrapped admin, whose password was in the configuration.\nThe account can do nothing but change the password until it is cleared.",
+                    "type": "boolean"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Role"
+                    }
+                },
                 "status": {
                     "$ref": "#/definitions/model.Status"
                 },
+                "timeZone": {
+                    "type": "string"
+                },
                 "updatedAt": {
                     "type": "string"
+                },
+                "version": {
+                    "description": "Version is incremented on every update for optimistic concurrency control.",
+                    "type": "integer"
                 }
             }
         },
@@ -604,15 +2602,234 @@ const docTemplate = `{
                 "AuthorityUser"
             ]
         },
+        "model.DataExport": {
+            "type": "object",
+            "properties": {
+                "accountId": {
+                    "type": "integer"
+                },
+                "completedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "description": "ExpiresAt is the time when the archive is deleted.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.ExportStatus"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ExportStatus": {
+            "type": "integer",
+            "enum": [
+                1,
+                2,
+                3,
+                4
+            ],
+            "x-enum-varnames": [
+                "ExportStatusPending",
+                "ExportStatusReady",
+                "ExportStatusFailed",
+                "ExportStatusExpired"
+            ]
+        },
+        "model.Job": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "handler": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "lockedBy": {
+                    "description": "LockedBy is the instance which is running the job. The lock expires at LockedUntil,\nso that the job is run again when the instance dies while running it.",
+                    "type": "string"
+                },
+                "lockedUntil": {
+                    "type": "string"
+                },
+                "maxAttempts": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nextRunAt": {
+                    "description": "NextRunAt is the time when the job runs next.",
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.JobStatus"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.JobRun": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "jobId": {
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.JobRunStatus"
+                }
+            }
+        },
+        "model.JobRunStatus": {
+            "type": "integer",
+            "enum": [
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "JobRunStatusRunning",
+                "JobRunStatusSucceeded",
+                "JobRunStatusFailed"
+            ]
+        },
+        "model.JobStatus": {
+            "type": "integer",
+            "enum": [
+                1,
+                2,
+                3
+            ],
+            "x-enum-varnames": [
+                "JobStatusScheduled",
+                "JobStatusCompleted",
+                "JobStatusFailed"
+            ]
+        },
+        "model.Permission": {
+            "type": "string",
+            "enum": [
+                "account:read",
+                "account:write",
+                "account:delete",
+                "account:export",
+                "token:read",
+                "token:write",
+                "role:manage",
+                "system:admin"
+            ],
+            "x-enum-varnames": [
+                "PermissionAccountRead",
+                "PermissionAccountWrite",
+                "PermissionAccountDelete",
+                "PermissionAccountExport",
+                "PermissionTokenRead",
+                "PermissionTokenWrite",
+                "PermissionRoleManage",
+                "PermissionSystemAdmin"
+            ]
+        },
+        "model.Role": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.RolePermission"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.RolePermission": {
+            "type": "object",
+            "properties": {
+                "permission": {
+                    "$ref": "#/definitions/model.Permission"
+                }
+            }
+        },
         "model.Status": {
             "type": "integer",
             "enum": [
                 1,
-                2
+                2,
+                3,
+                4
             ],
             "x-enum-varnames": [
                 "StatusActive",
-                "StatusInactive"
+                "StatusInactive",
+                "StatusPendingDeletion",
+                "StatusPurged"
             ]
         }
     }
@@ -2,21 +2,30 @@ package infrastructure
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
 	"os"
 
+	"github.com/labstack/echo/v4"
 	"github.com/onetooler/bistory-backend/config"
 	"github.com/onetooler/bistory-backend/logger"
 	"github.com/onetooler/bistory-backend/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/gomail.v2"
 )
 
+// EmailSender sends the emails by the templates.
+// The request id of a given context is set to the X-Request-ID header of the email.
 type EmailSender interface {
-	SendEmail(to, subject, template string, body any) error
+	SendEmail(ctx context.Context, to, subject, template string, body any) error
 }
 
 type emailSender struct {
+	logger    logger.Logger
 	account   string
 	dialer    *gomail.Dialer
 	templates map[string]*template.Template
@@ -28,6 +37,7 @@ func NewEmailSender(logger logger.Logger, conf *config.Config, templates map[str
 	if !conf.Email.Enabled {
 		return &disabledEmailsender{}
 	}
+	logger = logger.Component(config.LogComponentEmail)
 
 	logger.GetZapLogger().Infof("Try email smtp connection")
 	d := gomail.NewDialer(conf.Email.Host, conf.Email.Port, conf.Email.Username, conf.Email.Password)
@@ -40,13 +50,18 @@ func NewEmailSender(logger logger.Logger, conf *config.Config, templates map[str
 	logger.GetZapLogger().Infof("Success email smtp connection, %s:%s", conf.Email.Host, conf.Email.Port)
 
 	return &emailSender{
+		logger:    logger,
 		account:   conf.Email.Account,
 		dialer:    d,
 		templates: templates,
 	}
 }
 
-func (e emailSender) SendEmail(to, subject, template string, body any) error {
+func (e emailSender) SendEmail(ctx context.Context, to, subject, template string, body any) error {
+	_, span := otel.Tracer(config.TracerName).Start(ctx, "smtp.send",
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attribute.String("email.template", template)))
+	defer span.End()
+
 	t, ok := e.templates[template]
 	if !ok {
 		return fmt.Errorf("template not found: %s", template)
@@ -61,11 +76,20 @@ func (e emailSender) SendEmail(to, subject, template string, body any) error {
 	msg.SetHeader("From", e.account)
 	msg.SetHeader("To", to)
 	msg.SetHeader("Subject", subject)
+	if requestId := logger.RequestIdFromContext(ctx); requestId != "" {
+		msg.SetHeader(echo.HeaderXRequestID, requestId)
+	}
 	msg.SetBody("text/html", buf.String())
 
-	return e.dialer.DialAndSend(msg)
+	if err := e.dialer.DialAndSend(msg); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	e.logger.GetZapLoggerFromContext(ctx).Debugf("Sent the email %s to %s", template, e.logger.GetRedactor().Text(to))
+	return nil
 }
 
-func (e disabledEmailsender) SendEmail(to, subject, template string, body any) error {
+func (e disabledEmailsender) SendEmail(ctx context.Context, to, subject, template string, body any) error {
 	return fmt.Errorf("email sender disabled by config")
 }
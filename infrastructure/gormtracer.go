@@ -0,0 +1,85 @@
+package infrastructure
+
+import (
+	"errors"
+
+	"github.com/onetooler/bistory-backend/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// GORM plugin to record a span of each statement, in the trace of the context given by gorm.DB.WithContext.
+// The SQL is recorded without the values, so that the personal data is not exported.
+
+const (
+	tracerPluginName = "tracing"
+	spanInstanceKey  = "tracing:span"
+)
+
+type gormTracer struct {
+	tracer trace.Tracer
+}
+
+// newGormTracer is constructor for the GORM plugin of tracing. It is used alongside the gorm adapter of the logger.
+func newGormTracer() gorm.Plugin {
+	return &gormTracer{tracer: otel.Tracer(config.TracerName)}
+}
+
+// Name returns the name of the plugin.
+func (t *gormTracer) Name() string {
+	return tracerPluginName
+}
+
+// Initialize registers the callbacks around the statements.
+func (t *gormTracer) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", t.before("gorm.create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", t.after),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", t.before("gorm.query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", t.after),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", t.before("gorm.update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", t.after),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", t.before("gorm.delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", t.after),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", t.before("gorm.row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", t.after),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", t.before("gorm.raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", t.after),
+	)
+}
+
+func (t *gormTracer) before(name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		ctx, span := t.tracer.Start(db.Statement.Context, name, trace.WithSpanKind(trace.SpanKindClient))
+		db.Statement.Context = ctx
+		db.InstanceSet(spanInstanceKey, span)
+	}
+}
+
+func (t *gormTracer) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanInstanceKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBSystemKey.String(db.Dialector.Name()),
+		semconv.DBStatement(db.Statement.SQL.String()),
+		semconv.DBSQLTable(db.Statement.Table),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
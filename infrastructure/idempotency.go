@@ -0,0 +1,200 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/logger"
+	"github.com/onetooler/bistory-backend/model"
+)
+
+// IdempotencyRecord is the request stored by its idempotency key, with its response once it is completed.
+type IdempotencyRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// IdempotencyStore stores the requests and their responses by the idempotency keys.
+// The key being processed is locked for config.IdempotencyLockTimeout, so an abandoned request can be retried after it.
+type IdempotencyStore interface {
+	// Reserve locks a new key for the request of a given fingerprint and returns nil.
+	// If the key exists, it returns the stored record instead.
+	Reserve(ctx context.Context, key string, fingerprint string) (*IdempotencyRecord, error)
+	// Complete stores the response of a reserved key, which is kept for ttl.
+	Complete(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error
+	// Release deletes a reserved key, so that the request can be retried.
+	Release(ctx context.Context, key string) error
+	// DeleteExpired deletes the keys expired at a given time, and returns the number of them.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// NewIdempotencyStore is constructor. The keys are stored in Redis if it is enabled, otherwise in the database.
+func NewIdempotencyStore(logger logger.Logger, conf *config.Config, rep Repository) IdempotencyStore {
+	if !conf.Redis.Enabled {
+		logger.GetZapLogger().Infof("use database for idempotency keys")
+		return &databaseIdempotencyStore{rep: rep}
+	}
+
+	logger.GetZapLogger().Infof("use redis for idempotency keys")
+	address := fmt.Sprintf("%s:%s", conf.Redis.Host, conf.Redis.Port)
+	return &redisIdempotencyStore{pool: &redis.Pool{
+		MaxIdle:     conf.Redis.ConnectionPoolSize,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", address)
+		},
+	}}
+}
+
+// databaseIdempotencyStore stores the keys in the database. The expired keys are deleted by a job.
+type databaseIdempotencyStore struct {
+	rep Repository
+}
+
+func (s *databaseIdempotencyStore) Reserve(ctx context.Context, key string, fingerprint string) (*IdempotencyRecord, error) {
+	keys := NewRepo[model.IdempotencyKey](s.rep.WithContext(ctx))
+	entity := &model.IdempotencyKey{KeyHash: key, Fingerprint: fingerprint, ExpiresAt: time.Now().Add(config.IdempotencyLockTimeout)}
+	for retried := false; ; retried = true {
+		err := keys.Create(entity)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, ErrAlreadyExists) {
+			return nil, err
+		}
+
+		existing, err := keys.FindOne(Where("key_hash = ?", key))
+		if errors.Is(err, ErrNotFound) && !retried {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if existing.ExpiresAt.After(time.Now()) || retried {
+			record := &IdempotencyRecord{
+				Fingerprint: existing.Fingerprint,
+				Completed:   existing.Completed,
+				Status:      existing.Status,
+				Body:        existing.Body,
+			}
+			if len(existing.Header) > 0 {
+				if err := json.Unmarshal(existing.Header, &record.Header); err != nil {
+					return nil, err
+				}
+			}
+			return record, nil
+		}
+		// the key is expired but not deleted yet by the job
+		tx := s.rep.WithContext(ctx).Where("key_hash = ? AND expires_at <= ?", key, time.Now()).Delete(&model.IdempotencyKey{})
+		if tx.Error != nil {
+			return nil, tx.Error
+		}
+	}
+}
+
+func (s *databaseIdempotencyStore) Complete(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+	return s.rep.WithContext(ctx).Model(&model.IdempotencyKey{}).Where("key_hash = ?", key).Updates(map[string]interface{}{
+		"completed":  true,
+		"status":     record.Status,
+		"header":     header,
+		"body":       record.Body,
+		"expires_at": time.Now().Add(ttl),
+	}).Error
+}
+
+func (s *databaseIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.rep.WithContext(ctx).Where("key_hash = ? AND completed = ?", key, false).Delete(&model.IdempotencyKey{}).Error
+}
+
+func (s *databaseIdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	tx := s.rep.WithContext(ctx).Where("expires_at <= ?", now).Delete(&model.IdempotencyKey{})
+	return tx.RowsAffected, tx.Error
+}
+
+// redisIdempotencyStore stores the keys in Redis, which expires them by itself.
+type redisIdempotencyStore struct {
+	pool *redis.Pool
+}
+
+func (s *redisIdempotencyStore) Reserve(ctx context.Context, key string, fingerprint string) (*IdempotencyRecord, error) {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	value, err := json.Marshal(&IdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+	for retried := false; ; retried = true {
+		_, err := redis.String(conn.Do("SET", redisIdempotencyKey(key), value, "NX", "PX", config.IdempotencyLockTimeout.Milliseconds()))
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, redis.ErrNil) {
+			return nil, err
+		}
+
+		stored, err := redis.Bytes(conn.Do("GET", redisIdempotencyKey(key)))
+		// the key has expired between the commands
+		if errors.Is(err, redis.ErrNil) && !retried {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		record := &IdempotencyRecord{}
+		if err := json.Unmarshal(stored, record); err != nil {
+			return nil, err
+		}
+		return record, nil
+	}
+}
+
+func (s *redisIdempotencyStore) Complete(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	record.Completed = true
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Do("SET", redisIdempotencyKey(key), value, "PX", ttl.Milliseconds())
+	return err
+}
+
+func (s *redisIdempotencyStore) Release(ctx context.Context, key string) error {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Do("DEL", redisIdempotencyKey(key))
+	return err
+}
+
+// DeleteExpired does nothing, since Redis expires the keys.
+func (s *redisIdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func redisIdempotencyKey(key string) string {
+	return config.IdempotencyRedisKeyPrefix + key
+}
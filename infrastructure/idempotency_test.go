@@ -0,0 +1,112 @@
+package infrastructure
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/logger"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newDatabaseIdempotencyStore(t *testing.T) (IdempotencyStore, Repository) {
+	conf := &config.Config{}
+	conf.Database.Dialect = SQLITE
+	conf.Database.Host = filepath.Join(t.TempDir(), "test.db")
+	log := logger.NewLogger(zap.NewNop().Sugar(), conf)
+	rep := NewRepository(log, conf)
+	t.Cleanup(func() { _ = rep.Close() })
+	assert.Nil(t, rep.AutoMigrate(&model.IdempotencyKey{}))
+	return NewIdempotencyStore(log, conf, rep), rep
+}
+
+func newRedisIdempotencyStore(t *testing.T) (IdempotencyStore, *miniredis.Miniredis) {
+	m := miniredis.RunT(t)
+	conf := &config.Config{}
+	conf.Redis.Enabled = true
+	conf.Redis.Host = m.Host()
+	conf.Redis.Port = m.Port()
+	return NewIdempotencyStore(logger.NewLogger(zap.NewNop().Sugar(), conf), conf, nil), m
+}
+
+func assertIdempotencyStore(t *testing.T, store IdempotencyStore) {
+	ctx := context.Background()
+
+	record, err := store.Reserve(ctx, "key", "fingerprint")
+	assert.Nil(t, err)
+	assert.Nil(t, record)
+
+	record, err = store.Reserve(ctx, "key", "fingerprint")
+	assert.Nil(t, err)
+	if assert.NotNil(t, record) {
+		assert.Equal(t, "fingerprint", record.Fingerprint)
+		assert.False(t, record.Completed)
+	}
+
+	assert.Nil(t, store.Complete(ctx, "key", &IdempotencyRecord{Fingerprint: "fingerprint", Status: 201, Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte("{}")}, time.Hour))
+	record, err = store.Reserve(ctx, "key", "other")
+	assert.Nil(t, err)
+	if assert.NotNil(t, record) {
+		assert.Equal(t, "fingerprint", record.Fingerprint)
+		assert.True(t, record.Completed)
+		assert.Equal(t, 201, record.Status)
+		assert.Equal(t, "application/json", record.Header.Get("Content-Type"))
+		assert.Equal(t, []byte("{}"), record.Body)
+	}
+
+	record, err = store.Reserve(ctx, "released", "fingerprint")
+	assert.Nil(t, err)
+	assert.Nil(t, record)
+	assert.Nil(t, store.Release(ctx, "released"))
+	record, err = store.Reserve(ctx, "released", "fingerprint")
+	assert.Nil(t, err)
+	assert.Nil(t, record)
+}
+
+func TestDatabaseIdempotencyStore(t *testing.T) {
+	store, _ := newDatabaseIdempotencyStore(t)
+	assertIdempotencyStore(t, store)
+}
+
+func TestDatabaseIdempotencyStore_Expired(t *testing.T) {
+	store, rep := newDatabaseIdempotencyStore(t)
+	ctx := context.Background()
+
+	_, _ = store.Reserve(ctx, "key", "fingerprint")
+	assert.Nil(t, store.Complete(ctx, "key", &IdempotencyRecord{Fingerprint: "fingerprint", Status: 200}, -time.Second))
+
+	record, err := store.Reserve(ctx, "key", "other")
+	assert.Nil(t, err)
+	assert.Nil(t, record)
+
+	deleted, err := store.DeleteExpired(ctx, time.Now().Add(config.IdempotencyLockTimeout+time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), deleted)
+	var count int64
+	assert.Nil(t, rep.Model(&model.IdempotencyKey{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestRedisIdempotencyStore(t *testing.T) {
+	store, _ := newRedisIdempotencyStore(t)
+	assertIdempotencyStore(t, store)
+}
+
+func TestRedisIdempotencyStore_Expired(t *testing.T) {
+	store, m := newRedisIdempotencyStore(t)
+	ctx := context.Background()
+
+	_, _ = store.Reserve(ctx, "key", "fingerprint")
+	assert.Nil(t, store.Complete(ctx, "key", &IdempotencyRecord{Fingerprint: "fingerprint", Status: 200}, time.Hour))
+	m.FastForward(time.Hour)
+
+	record, err := store.Reserve(ctx, "key", "other")
+	assert.Nil(t, err)
+	assert.Nil(t, record)
+}
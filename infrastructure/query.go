@@ -0,0 +1,333 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidQuery is returned when the query string of a list endpoint can not be applied.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// QuerySpec whitelists the fields which a list can be filtered and sorted by.
+// The keys are the names in the query string and the values are the columns.
+// The columns to sort by must not be null, because the cursor compares them.
+type QuerySpec struct {
+	Filters     map[string]string
+	Sorts       map[string]string
+	DefaultSort string
+}
+
+// Query is the pagination, filtering and sorting requested by the query string of a list endpoint.
+// It is either in the page mode by page and size, or in the cursor mode by cursor and size.
+type Query struct {
+	Page    int
+	Size    int
+	Sort    string
+	column  string
+	desc    bool
+	filters map[string][]string
+	cursor  *pageCursor
+}
+
+// pageCursor is the position after the last item of a page, by the value of the sorted column and the primary key.
+type pageCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    json.RawMessage `json:"i"`
+}
+
+// ParseQuery parses the query string by a given spec.
+// The cursor mode is used when the cursor parameter is given, even if it is empty, which requests the first page.
+func ParseQuery(params url.Values, spec QuerySpec) (*Query, error) {
+	query := &Query{Size: config.PageDefaultSize, filters: make(map[string][]string)}
+
+	if size := params.Get(config.QueryParamSize); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 || n > config.PageMaxSize {
+			return nil, fmt.Errorf("%w: size must be between 1 and %d", ErrInvalidQuery, config.PageMaxSize)
+		}
+		query.Size = n
+	}
+
+	query.Sort = params.Get(config.QueryParamSort)
+	if query.Sort == "" {
+		query.Sort = spec.DefaultSort
+	}
+	column, ok := spec.Sorts[strings.TrimPrefix(query.Sort, "-")]
+	if !ok {
+		return nil, fmt.Errorf("%w: sort by %s is not allowed", ErrInvalidQuery, query.Sort)
+	}
+	query.column, query.desc = column, strings.HasPrefix(query.Sort, "-")
+
+	for name, column := range spec.Filters {
+		if value := params.Get(name); value != "" {
+			query.filters[column] = strings.Split(value, ",")
+		}
+	}
+
+	_, hasCursor := params[config.QueryParamCursor]
+	page := params.Get(config.QueryParamPage)
+	switch {
+	case hasCursor && page != "":
+		return nil, fmt.Errorf("%w: page and cursor can not be used together", ErrInvalidQuery)
+	case hasCursor:
+		cursor, err := decodeCursor(params.Get(config.QueryParamCursor), query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		query.cursor = cursor
+	case page != "":
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("%w: page must be a positive number", ErrInvalidQuery)
+		}
+		query.Page = n
+	default:
+		query.Page = 1
+	}
+	return query, nil
+}
+
+// IsCursor returns true if the query is in the cursor mode.
+func (q *Query) IsCursor() bool {
+	return q.cursor != nil
+}
+
+var querySchemas = &sync.Map{}
+
+// FindPage finds a page of the records matching the query and given specs.
+// The sort is tie-broken by the primary key, so the order is stable even if the sorted values are duplicated,
+// and the cursor mode seeks from the last item instead of an offset, so it is not slowed by the preceding rows.
+func FindPage[T any](rep Repository, query *Query, specs ...Spec) (*dto.Page[T], error) {
+	s, err := schema.Parse(new(T), querySchemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+	filter, err := query.filterScope(s)
+	if err != nil {
+		return nil, err
+	}
+	paginate, err := query.paginateScope(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	counted := append(scopes(specs), filter)
+	if err := rep.Scopes(counted...).Model(new(T)).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	items := []T{}
+	if err := rep.Scopes(append(counted, paginate)...).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	page := &dto.Page[T]{Total: total, Size: query.Size, Page: query.Page}
+	if query.IsCursor() && len(items) > query.Size {
+		items = items[:query.Size]
+		next, err := query.encodeCursor(s, &items[query.Size-1])
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = next
+	}
+	page.Items = items
+	return page, nil
+}
+
+// filterScope returns the scope filtering by the values converted to the types of the fields.
+func (q *Query) filterScope(s *schema.Schema) (func(*gorm.DB) *gorm.DB, error) {
+	columns := make([]string, 0, len(q.filters))
+	for column := range q.filters {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	exprs := make([]clause.Expression, 0, len(columns))
+	for _, column := range columns {
+		field, err := lookUpField(s, column)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, 0, len(q.filters[column]))
+		for _, raw := range q.filters[column] {
+			value, err := convertValue(field, raw)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		exprs = append(exprs, clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Values: values})
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		for _, expr := range exprs {
+			db = db.Where(expr)
+		}
+		return db
+	}, nil
+}
+
+// paginateScope returns the scope ordering and limiting the records.
+// The cursor mode fetches one more item to find whether the next page exists.
+func (q *Query) paginateScope(s *schema.Schema) (func(*gorm.DB) *gorm.DB, error) {
+	primary := s.PrioritizedPrimaryField
+	if primary == nil {
+		return nil, fmt.Errorf("%s has no primary key", s.Name)
+	}
+	field, err := lookUpField(s, q.column)
+	if err != nil {
+		return nil, err
+	}
+
+	var seek clause.Expression
+	if q.cursor != nil && len(q.cursor.ID) > 0 {
+		id, err := decodeValue(primary, q.cursor.ID)
+		if err != nil {
+			return nil, err
+		}
+		seek = q.after(clause.Column{Table: clause.CurrentTable, Name: primary.DBName}, id)
+		if field != primary {
+			value, err := decodeValue(field, q.cursor.Value)
+			if err != nil {
+				return nil, err
+			}
+			column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+			seek = clause.Or(q.after(column, value), clause.And(clause.Eq{Column: column, Value: value}, seek))
+		}
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Desc: q.desc})
+		if field != primary {
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: primary.DBName}, Desc: q.desc})
+		}
+		if q.cursor == nil {
+			return db.Offset((q.Page - 1) * q.Size).Limit(q.Size)
+		}
+		if seek != nil {
+			db = db.Where(seek)
+		}
+		return db.Limit(q.Size + 1)
+	}, nil
+}
+
+// after returns the condition of the values after a given value in the order of the query.
+func (q *Query) after(column clause.Column, value interface{}) clause.Expression {
+	if q.desc {
+		return clause.Lt{Column: column, Value: value}
+	}
+	return clause.Gt{Column: column, Value: value}
+}
+
+func (q *Query) encodeCursor(s *schema.Schema, item interface{}) (string, error) {
+	field, err := lookUpField(s, q.column)
+	if err != nil {
+		return "", err
+	}
+	cursor := pageCursor{Sort: q.Sort}
+	if cursor.Value, err = fieldJSON(field, item); err != nil {
+		return "", err
+	}
+	if cursor.ID, err = fieldJSON(s.PrioritizedPrimaryField, item); err != nil {
+		return "", err
+	}
+	bytes, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// decodeCursor decodes the cursor of the next page. The cursor must be issued with the same sort.
+func decodeCursor(raw string, sort string) (*pageCursor, error) {
+	cursor := &pageCursor{Sort: sort}
+	if raw == "" {
+		return cursor, nil
+	}
+	bytes, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || json.Unmarshal(bytes, cursor) != nil || len(cursor.ID) == 0 {
+		return nil, fmt.Errorf("%w: cursor is malformed", ErrInvalidQuery)
+	}
+	if cursor.Sort != sort {
+		return nil, fmt.Errorf("%w: cursor was issued for sort by %s", ErrInvalidQuery, cursor.Sort)
+	}
+	return cursor, nil
+}
+
+func lookUpField(s *schema.Schema, column string) (*schema.Field, error) {
+	field := s.LookUpField(column)
+	if field == nil {
+		return nil, fmt.Errorf("%s has no column %s", s.Name, column)
+	}
+	return field, nil
+}
+
+func fieldJSON(field *schema.Field, item interface{}) (json.RawMessage, error) {
+	value, _ := field.ValueOf(context.Background(), reflect.ValueOf(item).Elem())
+	return json.Marshal(value)
+}
+
+func decodeValue(field *schema.Field, raw json.RawMessage) (interface{}, error) {
+	value := reflect.New(field.FieldType)
+	if err := json.Unmarshal(raw, value.Interface()); err != nil {
+		return nil, fmt.Errorf("%w: cursor is malformed", ErrInvalidQuery)
+	}
+	return value.Elem().Interface(), nil
+}
+
+// convertValue converts a value of the query string to the type of a given field.
+func convertValue(field *schema.Field, raw string) (interface{}, error) {
+	t := field.FieldType
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	value := reflect.New(t).Elem()
+	var err error
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		var parsed time.Time
+		if parsed, err = time.Parse(time.RFC3339, raw); err == nil {
+			value.Set(reflect.ValueOf(parsed))
+		}
+	case t.Kind() == reflect.String:
+		value.SetString(raw)
+	case t.Kind() == reflect.Bool:
+		var parsed bool
+		if parsed, err = strconv.ParseBool(raw); err == nil {
+			value.SetBool(parsed)
+		}
+	case value.CanInt():
+		var parsed int64
+		if parsed, err = strconv.ParseInt(raw, 10, 64); err == nil {
+			value.SetInt(parsed)
+		}
+	case value.CanUint():
+		var parsed uint64
+		if parsed, err = strconv.ParseUint(raw, 10, 64); err == nil {
+			value.SetUint(parsed)
+		}
+	default:
+		return nil, fmt.Errorf("%s can not be filtered", field.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s is not a valid value of %s", ErrInvalidQuery, raw, field.Name)
+	}
+	return value.Interface(), nil
+}
@@ -0,0 +1,118 @@
+package infrastructure
+
+import (
+	"errors"
+
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when no record matches the condition.
+var ErrNotFound = errors.New("record not found")
+
+// ErrAlreadyExists is returned when a record violates a unique constraint.
+var ErrAlreadyExists = errors.New("already exists")
+
+// Spec is a condition to find the records, and it is applied as a GORM scope.
+type Spec func(*gorm.DB) *gorm.DB
+
+// Where returns the spec of a given condition.
+func Where(query interface{}, args ...interface{}) Spec {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(query, args...)
+	}
+}
+
+// Repo is a typed repository of a domain model on top of Repository.
+type Repo[T model.DomainObject] struct {
+	rep Repository
+}
+
+// NewRepo is constructor.
+func NewRepo[T model.DomainObject](rep Repository) *Repo[T] {
+	return &Repo[T]{rep: rep}
+}
+
+// FindByID returns the record of a given primary key, or ErrNotFound.
+func (r *Repo[T]) FindByID(id uint) (*T, error) {
+	entity := new(T)
+	if err := r.rep.First(entity, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return entity, nil
+}
+
+// FindOne returns a record matching the specs, or ErrNotFound.
+func (r *Repo[T]) FindOne(specs ...Spec) (*T, error) {
+	entity := new(T)
+	if err := r.rep.Scopes(scopes(specs)...).Take(entity).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return entity, nil
+}
+
+// Exists returns true if any record matches the specs.
+func (r *Repo[T]) Exists(specs ...Spec) (bool, error) {
+	exists := false
+	tx := r.rep.Raw("SELECT EXISTS (?)", r.rep.Scopes(scopes(specs)...).Model(new(T)).Select("1")).Scan(&exists)
+	return exists, tx.Error
+}
+
+// Create inserts a given record, or returns ErrAlreadyExists if it violates a unique constraint.
+func (r *Repo[T]) Create(entity *T) error {
+	return alreadyExists(r.rep.Create(entity).Error)
+}
+
+// Update updates the columns of a given record by its primary key, or returns ErrNotFound if it does not match the specs.
+// The values can be expressions, e.g. gorm.Expr("version + 1").
+func (r *Repo[T]) Update(entity *T, values map[string]interface{}, specs ...Spec) error {
+	tx := r.rep.Model(entity).Scopes(scopes(specs)...).Updates(values)
+	if tx.Error != nil {
+		return alreadyExists(tx.Error)
+	}
+	if tx.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SoftDelete deletes the record of a given primary key, or returns ErrNotFound.
+// The record is only marked as deleted if the model has gorm.DeletedAt.
+func (r *Repo[T]) SoftDelete(id uint, specs ...Spec) error {
+	tx := r.rep.Scopes(scopes(specs)...).Delete(new(T), id)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns a page of the records matching the query and specs.
+func (r *Repo[T]) List(query *Query, specs ...Spec) (*dto.Page[T], error) {
+	return FindPage[T](r.rep, query, specs...)
+}
+
+func scopes(specs []Spec) []func(*gorm.DB) *gorm.DB {
+	funcs := make([]func(*gorm.DB) *gorm.DB, len(specs))
+	for i, spec := range specs {
+		funcs[i] = spec
+	}
+	return funcs
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func alreadyExists(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrAlreadyExists
+	}
+	return err
+}
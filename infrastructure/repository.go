@@ -1,9 +1,15 @@
 package infrastructure
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/glebarez/sqlite"
 	"github.com/onetooler/bistory-backend/config"
@@ -11,6 +17,7 @@ import (
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
 // Repository defines a interface for access the database.
@@ -30,6 +37,8 @@ type Repository interface {
 	Scopes(funcs ...func(*gorm.DB) *gorm.DB) *gorm.DB
 	ScanRows(rows *sql.Rows, result interface{}) error
 	Transaction(fc func(tx Repository) error) (err error)
+	WithContext(ctx context.Context) Repository
+	Primary() Repository
 	Close() error
 	DropTableIfExists(value interface{}) error
 	AutoMigrate(value interface{}) error
@@ -42,12 +51,24 @@ type repository struct {
 
 func NewRepository(logger logger.Logger, conf *config.Config) Repository {
 	logger.GetZapLogger().Infof("Try database connection")
-	db, err := connectDatabase(logger, conf)
+	db, err := connectDatabase(logger.Component(config.LogComponentGorm), conf)
 	if err != nil {
 		logger.GetZapLogger().Errorf("Failure database connection")
 		os.Exit(config.ErrExitStatus)
 	}
 	logger.GetZapLogger().Infof("Success database connection, %s:%s", conf.Database.Host, conf.Database.Port)
+	if len(conf.Database.Replicas) > 0 {
+		if err := db.Use(newResolver(conf)); err != nil {
+			logger.GetZapLogger().Errorf("Failure database replica connection: %s", err.Error())
+			os.Exit(config.ErrExitStatus)
+		}
+		logger.GetZapLogger().Infof("Success database replica connection, %d replicas", len(conf.Database.Replicas))
+	}
+	if conf.Tracing.Enabled {
+		if err := db.Use(newGormTracer()); err != nil {
+			logger.GetZapLogger().Errorf("Failed to trace the database: %s", err.Error())
+		}
+	}
 	return &repository{db: db}
 }
 
@@ -61,17 +82,142 @@ const (
 )
 
 func connectDatabase(logger logger.Logger, config *config.Config) (*gorm.DB, error) {
-	var dsn string
-	gormConfig := &gorm.Config{Logger: logger}
+	gormConfig := &gorm.Config{Logger: logger, TranslateError: true}
+	db, err := gorm.Open(openDialector(config, config.Database.Host, config.Database.Port, config.Database.Dbname,
+		config.Database.LoginId, config.Database.Password), gormConfig)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	configurePool(sqlDB, config)
+	return db, nil
+}
+
+// newResolver returns the GORM plugin which routes the reads to the replicas, and the writes and the transactions to the primary.
+func newResolver(conf *config.Config) gorm.Plugin {
+	replicas := make([]gorm.Dialector, 0, len(conf.Database.Replicas))
+	for _, replica := range conf.Database.Replicas {
+		dbname, loginId, password := replica.Dbname, replica.LoginId, replica.Password
+		if dbname == "" {
+			dbname = conf.Database.Dbname
+		}
+		if loginId == "" {
+			loginId, password = conf.Database.LoginId, conf.Database.Password
+		}
+		replicas = append(replicas, openDialector(conf, replica.Host, replica.Port, dbname, loginId, password))
+	}
+	pool := conf.Database.Pool
+	resolver := dbresolver.Register(dbresolver.Config{Replicas: replicas, Policy: dbresolver.RandomPolicy{}})
+	if pool.MaxOpenConns > 0 {
+		resolver.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		resolver.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		resolver.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+	if pool.ConnMaxIdleTime > 0 {
+		resolver.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
+	return resolver
+}
+
+// configurePool applies the configured limits to the connection pool. The defaults of database/sql are kept for zero values.
+func configurePool(sqlDB *sql.DB, conf *config.Config) {
+	pool := conf.Database.Pool
+	if pool.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+	if pool.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
+}
+
+func openDialector(conf *config.Config, host, port, dbname, loginId, password string) gorm.Dialector {
+	dsn := dataSourceName(conf, host, port, dbname, loginId, password)
+	switch conf.Database.Dialect {
+	case POSTGRES:
+		return postgres.Open(dsn)
+	case MYSQL:
+		return mysql.Open(dsn)
+	}
+	return sqlite.Open(dsn)
+}
+
+// dataSourceName returns the DSN of the configured dialect for a given server.
+// The SSL mode and the options of the configuration are added to it, and the options take precedence.
+func dataSourceName(conf *config.Config, host, port, dbname, loginId, password string) string {
+	sslMode := conf.Database.SSLMode
+	if sslMode == "" {
+		sslMode = config.DatabaseSSLModeDisable
+	}
 
-	if config.Database.Dialect == POSTGRES {
-		dsn = fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=disable", config.Database.Host, config.Database.Port, config.Database.LoginId, config.Database.Dbname, config.Database.Password)
-		return gorm.Open(postgres.Open(dsn), gormConfig)
-	} else if config.Database.Dialect == MYSQL {
-		dsn = fmt.Sprintf("%s:%s@(%s)/%s?charset=utf8&parseTime=True&loc=Local", config.Database.LoginId, config.Database.Password, config.Database.Host, config.Database.Dbname)
-		return gorm.Open(mysql.Open(dsn), gormConfig)
+	switch conf.Database.Dialect {
+	case POSTGRES:
+		params := map[string]string{"sslmode": sslMode}
+		for key, value := range conf.Database.Options {
+			params[key] = value
+		}
+		dsn := fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s",
+			quoteDSNValue(host), quoteDSNValue(port), quoteDSNValue(loginId), quoteDSNValue(dbname), quoteDSNValue(password))
+		for _, key := range sortedKeys(params) {
+			dsn += fmt.Sprintf(" %s=%s", key, quoteDSNValue(params[key]))
+		}
+		return dsn
+	case MYSQL:
+		params := url.Values{"charset": {"utf8"}, "parseTime": {"True"}, "loc": {"Local"}}
+		if sslMode != config.DatabaseSSLModeDisable {
+			params.Set("tls", sslMode)
+		}
+		for key, value := range conf.Database.Options {
+			params.Set(key, value)
+		}
+		address := host
+		if port != "" {
+			address = net.JoinHostPort(host, port)
+		}
+		return fmt.Sprintf("%s:%s@tcp(%s)/%s?%s", loginId, password, address, dbname, params.Encode())
+	}
+
+	if len(conf.Database.Options) == 0 {
+		return host
+	}
+	params := url.Values{}
+	for key, value := range conf.Database.Options {
+		params.Set(key, value)
+	}
+	separator := "?"
+	if strings.Contains(host, "?") {
+		separator = "&"
+	}
+	return host + separator + params.Encode()
+}
+
+// quoteDSNValue quotes a value of the key/value DSN of PostgreSQL if it is empty or has spaces or quotes.
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
 	}
-	return gorm.Open(sqlite.Open(config.Database.Host), gormConfig)
+	sort.Strings(keys)
+	return keys
 }
 
 // Model specify the model you would like to run db operations
@@ -160,27 +306,54 @@ func (rep *repository) AutoMigrate(value interface{}) error {
 	return rep.db.AutoMigrate(value)
 }
 
-// Transaction start a transaction as a block.
-// If it is failed, will rollback and return error.
-// If it is sccuessed, will commit.
-// ref: https://github.com/jinzhu/gorm/blob/master/main.go#L533
-func (rep *repository) Transaction(fc func(tx Repository) error) (err error) {
-	panicked := true
-	tx := rep.db.Begin()
-	defer func() {
-		if panicked || err != nil {
-			tx.Rollback()
-		}
-	}()
+// WithContext returns the repository whose operations are canceled with a given context.
+// The context is also passed to the logger of GORM.
+// If the context carries a unit of work started by InTransaction, the repository joins its transaction.
+// If the context carries the timeout of WithQueryTimeout, the operations are also canceled at its deadline.
+func (rep *repository) WithContext(ctx context.Context) Repository {
+	ctx = withQueryDeadline(ctx)
+	if uow := unitOfWorkFromContext(ctx); uow != nil {
+		return &repository{db: uow.db.WithContext(ctx)}
+	}
+	return &repository{db: rep.db.WithContext(ctx)}
+}
+
+// queryTimeoutKey is the key of the context which holds the deadline of the database work.
+type queryTimeoutKey struct{}
 
-	txrep := &repository{}
-	txrep.db = tx
-	err = fc(txrep)
+// WithQueryTimeout returns the context which limits the database work by a given timeout.
+// The deadline applies only to the repositories given the context by WithContext, and not to the other work with the context.
+// The cancel function must be called when the work is done.
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	limit, cancel := context.WithTimeout(context.Background(), timeout)
+	return context.WithValue(ctx, queryTimeoutKey{}, limit), cancel
+}
 
-	if err == nil {
-		err = tx.Commit().Error
+// withQueryDeadline returns the context which is canceled at the deadline of WithQueryTimeout, if it is given.
+func withQueryDeadline(ctx context.Context) context.Context {
+	limit, ok := ctx.Value(queryTimeoutKey{}).(context.Context)
+	if !ok {
+		return ctx
 	}
+	deadline, _ := limit.Deadline()
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	// the context is released when the cancel function of WithQueryTimeout is called at the latest
+	context.AfterFunc(limit, cancel)
+	return ctx
+}
+
+// Primary returns the repository whose reads go to the primary database instead of the replicas,
+// which is used to read the records just written.
+func (rep *repository) Primary() Repository {
+	return &repository{db: rep.db.Clauses(dbresolver.Write).Session(&gorm.Session{})}
+}
 
-	panicked = false
-	return
+// Transaction start a transaction as a block.
+// If it is failed, will rollback and return error.
+// If it is sccuessed, will commit.
+// If the repository is already in a transaction, the block runs in a savepoint which is rolled back alone.
+func (rep *repository) Transaction(fc func(tx Repository) error) error {
+	return rep.db.Transaction(func(tx *gorm.DB) error {
+		return fc(&repository{db: tx})
+	})
 }
@@ -0,0 +1,87 @@
+package infrastructure
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestDataSourceName_Postgres(t *testing.T) {
+	conf := &config.Config{}
+	conf.Database.Dialect = POSTGRES
+	conf.Database.SSLMode = "verify-full"
+	conf.Database.Options = map[string]string{"connect_timeout": "5", "sslrootcert": "/etc/ssl/root ca.pem"}
+
+	dsn := dataSourceName(conf, "db", "5432", "app", "user", "pa'ss")
+
+	assert.Equal(t, `host=db port=5432 user=user dbname=app password='pa\'ss' connect_timeout=5 sslmode=verify-full sslrootcert='/etc/ssl/root ca.pem'`, dsn)
+}
+
+func TestDataSourceName_MySQL(t *testing.T) {
+	conf := &config.Config{}
+	conf.Database.Dialect = MYSQL
+	conf.Database.SSLMode = "true"
+	conf.Database.Options = map[string]string{"charset": "utf8mb4"}
+
+	dsn := dataSourceName(conf, "db", "3306", "app", "user", "pass")
+
+	assert.Equal(t, "user:pass@tcp(db:3306)/app?charset=utf8mb4&loc=Local&parseTime=True&tls=true", dsn)
+}
+
+func TestRepository_Replicas(t *testing.T) {
+	dir := t.TempDir()
+	conf := &config.Config{}
+	conf.Database.Dialect = SQLITE
+	conf.Database.Host = filepath.Join(dir, "primary.db")
+	conf.Database.Replicas = []config.DatabaseReplica{{Host: filepath.Join(dir, "replica.db")}}
+	conf.Database.Pool.MaxOpenConns = 2
+	conf.Database.Pool.ConnMaxLifetime = time.Minute
+	rep := NewRepository(logger.NewLogger(zap.NewNop().Sugar(), conf), conf)
+	defer rep.Close()
+
+	replica, err := gorm.Open(sqlite.Open(filepath.Join(dir, "replica.db")), &gorm.Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, replica.Exec("CREATE TABLE items (name text)").Error)
+	assert.Nil(t, replica.Exec("INSERT INTO items VALUES ('replica')").Error)
+	assert.Nil(t, rep.Exec("CREATE TABLE items (name text)").Error)
+	assert.Nil(t, rep.Exec("INSERT INTO items VALUES ('primary')").Error)
+
+	names := func(rep Repository) []string {
+		var result []string
+		assert.Nil(t, rep.Raw("SELECT name FROM items").Scan(&result).Error)
+		return result
+	}
+	assert.Equal(t, []string{"replica"}, names(rep))
+	assert.Equal(t, []string{"primary"}, names(rep.Primary()))
+	assert.Nil(t, rep.Transaction(func(tx Repository) error {
+		assert.Equal(t, []string{"primary"}, names(tx))
+		return nil
+	}))
+	assert.Equal(t, []string{"replica"}, names(rep))
+}
+
+func TestRepository_QueryTimeout(t *testing.T) {
+	conf := &config.Config{}
+	conf.Database.Dialect = SQLITE
+	conf.Database.Host = filepath.Join(t.TempDir(), "timeout.db")
+	rep := NewRepository(logger.NewLogger(zap.NewNop().Sugar(), conf), conf)
+	defer rep.Close()
+
+	ctx, cancel := WithQueryTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+
+	var result int
+	assert.ErrorIs(t, rep.WithContext(ctx).Raw("SELECT 1").Scan(&result).Error, context.DeadlineExceeded)
+	// the other work with the context is not limited
+	assert.Nil(t, ctx.Err())
+	assert.Nil(t, rep.WithContext(context.Background()).Raw("SELECT 1").Scan(&result).Error)
+}
@@ -0,0 +1,319 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/logger"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/util"
+	"github.com/robfig/cron/v3"
+)
+
+// ErrJobNotFound is returned when no job has a given id.
+var ErrJobNotFound = errors.New("job not found")
+
+// JobHandler runs a job with its payload. The context is canceled when the scheduler stops.
+type JobHandler func(ctx context.Context, payload string) error
+
+// Scheduler runs the background jobs persisted in the database.
+// A job is claimed by a conditional update before running, so that only one of the instances runs it.
+// The lock is extended while the job is running, and only the instance holding it reschedules the job.
+type Scheduler interface {
+	// Register registers the handler of the jobs by name.
+	Register(handler string, fn JobHandler)
+	// Schedule creates or updates the recurring job of a given name by a cron expression.
+	Schedule(name, spec, handler string) error
+	// Enqueue creates a job which runs once at a given time.
+	Enqueue(handler, payload string, runAt time.Time) (*model.Job, error)
+	// Trigger makes a job run at the next poll.
+	Trigger(jobId uint) error
+	// RunDue runs the jobs which are due at a given time and returns the number of them.
+	RunDue(ctx context.Context, now time.Time) int
+	Start()
+	Stop()
+}
+
+type scheduler struct {
+	rep      Repository
+	logger   logger.Logger
+	instance string
+	// heartbeat is the interval to extend the lock of a running job.
+	heartbeat time.Duration
+
+	mu       sync.RWMutex
+	handlers map[string]JobHandler
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler is constructor.
+func NewScheduler(logger logger.Logger, rep Repository) Scheduler {
+	hostname, _ := os.Hostname()
+	return &scheduler{
+		rep:       rep,
+		logger:    logger,
+		instance:  fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), util.RandomBase16String(4)),
+		heartbeat: config.JobLockHeartbeat,
+		handlers:  make(map[string]JobHandler),
+	}
+}
+
+func (s *scheduler) Register(handler string, fn JobHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[handler] = fn
+}
+
+func (s *scheduler) Schedule(name, spec, handler string) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %s: %s", spec, err.Error())
+	}
+
+	job := model.Job{}
+	tx := s.rep.Where(&model.Job{Name: name}).Limit(1).Find(&job)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if job.ID == 0 {
+		job = model.Job{
+			Name:      name,
+			Handler:   handler,
+			Schedule:  spec,
+			Status:    model.JobStatusScheduled,
+			NextRunAt: schedule.Next(time.Now()),
+		}
+		return s.rep.Create(&job).Error
+	}
+	if job.Schedule == spec && job.Handler == handler {
+		return nil
+	}
+	return s.rep.Model(&job).Updates(map[string]interface{}{
+		"handler":     handler,
+		"schedule":    spec,
+		"status":      model.JobStatusScheduled,
+		"next_run_at": schedule.Next(time.Now()),
+	}).Error
+}
+
+func (s *scheduler) Enqueue(handler, payload string, runAt time.Time) (*model.Job, error) {
+	job := &model.Job{
+		Name:        handler + ":" + util.RandomBase16String(16),
+		Handler:     handler,
+		Payload:     payload,
+		Status:      model.JobStatusScheduled,
+		NextRunAt:   runAt,
+		MaxAttempts: config.JobMaxAttempts,
+	}
+	if err := s.rep.Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Trigger makes a job run at the next poll. If the job is running, it runs again after the current run.
+func (s *scheduler) Trigger(jobId uint) error {
+	tx := s.rep.Model(&model.Job{}).Where("id = ?", jobId).Updates(map[string]interface{}{
+		"status":      model.JobStatusScheduled,
+		"next_run_at": time.Now(),
+		"attempts":    0,
+		"triggered":   true,
+	})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+func (s *scheduler) RunDue(ctx context.Context, now time.Time) int {
+	jobs := []model.Job{}
+	tx := s.rep.Where("status = ? AND next_run_at <= ? AND (locked_until IS NULL OR locked_until < ?)", model.JobStatusScheduled, now, now).
+		Order("next_run_at").Limit(config.SchedulerBatchSize).Find(&jobs)
+	if tx.Error != nil {
+		s.logger.GetZapLogger().Errorf("Failed to find the due jobs: %s", tx.Error.Error())
+		return 0
+	}
+
+	run := 0
+	for i := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		if !s.claim(&jobs[i], now) {
+			continue
+		}
+		s.run(ctx, &jobs[i])
+		run++
+	}
+	return run
+}
+
+func (s *scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(config.SchedulerPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.RunDue(ctx, now)
+			}
+		}
+	}()
+	s.logger.GetZapLogger().Infof("Started the job scheduler, %s", s.instance)
+}
+
+// Stop stops polling and waits for the running jobs.
+func (s *scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	s.logger.GetZapLogger().Infof("Stopped the job scheduler, %s", s.instance)
+}
+
+// claim locks a given job for this instance. It returns false if another instance has claimed it.
+func (s *scheduler) claim(job *model.Job, now time.Time) bool {
+	lockedUntil := now.Add(config.JobLockTimeout)
+	tx := s.rep.Model(&model.Job{}).
+		Where("id = ? AND status = ? AND next_run_at <= ? AND (locked_until IS NULL OR locked_until < ?)", job.ID, model.JobStatusScheduled, now, now).
+		Updates(map[string]interface{}{"locked_by": s.instance, "locked_until": lockedUntil, "triggered": false})
+	if tx.Error != nil {
+		s.logger.GetZapLogger().Errorf("Failed to claim the job %s: %s", job.Name, tx.Error.Error())
+		return false
+	}
+	return tx.RowsAffected == 1
+}
+
+// run runs a claimed job, records the run and schedules the next one.
+// The job is rescheduled only if this instance still holds the lock, and it keeps the next run set by a trigger during the run.
+func (s *scheduler) run(ctx context.Context, job *model.Job) {
+	run := model.JobRun{JobID: job.ID, Instance: s.instance, Status: model.JobRunStatusRunning, StartedAt: time.Now()}
+	if err := s.rep.Create(&run).Error; err != nil {
+		s.logger.GetZapLogger().Errorf("Failed to record the run of the job %s: %s", job.Name, err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.keepLock(ctx, cancel, job)
+	}()
+	err := s.invoke(ctx, job)
+	cancel()
+	<-stopped
+
+	finishedAt := time.Now()
+	runUpdates := map[string]interface{}{"status": model.JobRunStatusSucceeded, "finished_at": finishedAt}
+	if err != nil {
+		runUpdates["status"] = model.JobRunStatusFailed
+		runUpdates["error"] = err.Error()
+		s.logger.GetZapLogger().Errorf("Failed to run the job %s: %s", job.Name, err.Error())
+	}
+	if run.ID != 0 {
+		if err := s.rep.Model(&run).Updates(runUpdates).Error; err != nil {
+			s.logger.GetZapLogger().Errorf("Failed to record the run of the job %s: %s", job.Name, err.Error())
+		}
+	}
+
+	updates := s.next(job, finishedAt, err)
+	tx := s.rep.Model(&model.Job{}).Where("id = ? AND locked_by = ? AND triggered = ?", job.ID, s.instance, false).Updates(updates)
+	if tx.Error == nil && tx.RowsAffected == 0 {
+		// the job has been triggered during the run, so only the lock is released
+		tx = s.rep.Model(&model.Job{}).Where("id = ? AND locked_by = ?", job.ID, s.instance).
+			Updates(map[string]interface{}{"locked_by": "", "locked_until": nil, "last_error": updates["last_error"], "triggered": false})
+		if tx.Error == nil && tx.RowsAffected == 0 {
+			s.logger.GetZapLogger().Warnf("Lost the lock of the job %s, so it is not rescheduled", job.Name)
+		}
+	}
+	if tx.Error != nil {
+		s.logger.GetZapLogger().Errorf("Failed to reschedule the job %s: %s", job.Name, tx.Error.Error())
+	}
+}
+
+// keepLock extends the lock of a running job until the context is done.
+// If another instance has taken the lock, it cancels the job.
+func (s *scheduler) keepLock(ctx context.Context, cancel context.CancelFunc, job *model.Job) {
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			tx := s.rep.Model(&model.Job{}).Where("id = ? AND locked_by = ?", job.ID, s.instance).
+				Update("locked_until", now.Add(config.JobLockTimeout))
+			if tx.Error != nil {
+				s.logger.GetZapLogger().Errorf("Failed to extend the lock of the job %s: %s", job.Name, tx.Error.Error())
+			} else if tx.RowsAffected == 0 {
+				s.logger.GetZapLogger().Warnf("Lost the lock of the job %s, so it is canceled", job.Name)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (s *scheduler) invoke(ctx context.Context, job *model.Job) (err error) {
+	s.mu.RLock()
+	fn, ok := s.handlers[job.Handler]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("handler %s is not registered", job.Handler)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, job.Payload)
+}
+
+// next returns the updates of a job after it has run.
+func (s *scheduler) next(job *model.Job, now time.Time, runErr error) map[string]interface{} {
+	updates := map[string]interface{}{"locked_by": "", "locked_until": nil, "last_error": ""}
+	if runErr != nil {
+		updates["last_error"] = runErr.Error()
+	}
+
+	if job.IsRecurring() {
+		schedule, err := cron.ParseStandard(job.Schedule)
+		if err != nil {
+			updates["status"] = model.JobStatusFailed
+			updates["last_error"] = err.Error()
+			return updates
+		}
+		updates["next_run_at"] = schedule.Next(now)
+		return updates
+	}
+
+	if runErr == nil {
+		updates["status"] = model.JobStatusCompleted
+		return updates
+	}
+	attempts := job.Attempts + 1
+	updates["attempts"] = attempts
+	if attempts >= job.MaxAttempts {
+		updates["status"] = model.JobStatusFailed
+		return updates
+	}
+	updates["next_run_at"] = now.Add(config.JobRetryDelay * time.Duration(attempts))
+	return updates
+}
@@ -0,0 +1,60 @@
+package infrastructure
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/logger"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newTestScheduler(t *testing.T) (*scheduler, Repository) {
+	conf := &config.Config{}
+	conf.Database.Dialect = SQLITE
+	conf.Database.Host = filepath.Join(t.TempDir(), "test.db")
+	log := logger.NewLogger(zap.NewNop().Sugar(), conf)
+	rep := NewRepository(log, conf)
+	t.Cleanup(func() { _ = rep.Close() })
+	assert.Nil(t, rep.AutoMigrate(&model.Job{}))
+	assert.Nil(t, rep.AutoMigrate(&model.JobRun{}))
+	return NewScheduler(log, rep).(*scheduler), rep
+}
+
+func TestScheduler_ExtendLockWhileRunning(t *testing.T) {
+	s, rep := newTestScheduler(t)
+	s.heartbeat = 10 * time.Millisecond
+	other := NewScheduler(s.logger, rep)
+
+	// the job is claimed as if it started a lock timeout ago, so the lock expires now without the heartbeat.
+	startedAt := time.Now().Add(-config.JobLockTimeout)
+	job, err := s.Enqueue("test", "", startedAt)
+	assert.Nil(t, err)
+
+	running := make(chan struct{})
+	done := make(chan struct{})
+	handler := func(ctx context.Context, payload string) error {
+		close(running)
+		<-done
+		return nil
+	}
+	s.Register("test", handler)
+	other.Register("test", handler)
+
+	finished := make(chan int)
+	go func() { finished <- s.RunDue(context.Background(), startedAt) }()
+	<-running
+	assert.Eventually(t, func() bool {
+		locked := model.Job{}
+		rep.First(&locked, job.ID)
+		return locked.LockedUntil != nil && locked.LockedUntil.After(time.Now().Add(config.JobLockTimeout/2))
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, other.RunDue(context.Background(), time.Now().Add(time.Second)))
+
+	close(done)
+	assert.Equal(t, 1, <-finished)
+}
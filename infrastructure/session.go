@@ -1,14 +1,20 @@
 package infrastructure
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/garyburd/redigo/redis"
 	"github.com/gorilla/sessions"
 	"github.com/labstack/echo/v4"
 	"github.com/onetooler/bistory-backend/config"
 	"github.com/onetooler/bistory-backend/logger"
+	"github.com/onetooler/bistory-backend/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 	"gopkg.in/boj/redistore.v1"
 )
 
@@ -21,10 +27,15 @@ const (
 	accountStr = "Account"
 	// emailVerification is the key of email verification token in the session.
 	emailVerificationStr = "EmailVerification"
+	// redisSessionKeyPrefix is the prefix of the keys of the sessions saved by redistore.
+	redisSessionKeyPrefix = "session_"
 )
 
 type session struct {
-	store sessions.Store
+	store  sessions.Store
+	logger logger.Logger
+	// pool is the connection pool of redis to index the sessions by account. It is nil for CookieStore.
+	pool *redis.Pool
 }
 
 // Session represents a interface for accessing the session on the application.
@@ -38,19 +49,58 @@ type Session interface {
 	GetValue(c echo.Context, key string) string
 	SetAccount(c echo.Context, account *Account) error
 	GetAccount(c echo.Context) *Account
+	SetRequestAccount(c echo.Context, account *Account)
 	SetEmailVerification(c echo.Context, emailVerification *EmailVerification) error
 	VerifyEmailToken(c echo.Context, token string) error
 	IsVerifiedEmail(c echo.Context, email string) (bool, error)
 	Login(c echo.Context, account *Account) error
 	Logout(c echo.Context) error
-	HasAuthorizationTo(c echo.Context, accountId uint, authority uint) bool
+	GetSessions(ctx context.Context, accountId uint) ([]SessionInfo, error)
 }
 
 type Account struct {
 	Id        uint      `json:"id"`
 	LoginId   string    `json:"loginId"`
 	LoginTime time.Time `json:"loginTime"`
-	Authority uint      `json:"authority"`
+	// Roles and Permissions are reloaded from the roles assigned to the account at every request,
+	// so the account in the session is only used to identify it.
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+	// MustChangePassword restricts the account to change its password, which was set by the configuration.
+	MustChangePassword bool `json:"mustChangePassword,omitempty"`
+	// AccessTokenId and Scopes are set only when the account is authenticated by an access token.
+	AccessTokenId uint     `json:"accessTokenId,omitempty"`
+	Scopes        []string `json:"scopes,omitempty"`
+}
+
+// HasPermission judges whether the account has been granted a given permission.
+func (a *Account) HasPermission(permission string) bool {
+	for _, p := range a.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope judges whether the account is allowed to use a given scope.
+// An account authenticated by the session is allowed to use all scopes.
+func (a *Account) HasScope(scope string) bool {
+	if a.AccessTokenId == 0 {
+		return true
+	}
+	for _, s := range a.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionInfo describes a session of an account which is logged in.
+type SessionInfo struct {
+	LoginTime time.Time `json:"loginTime"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
 
 type EmailVerification struct {
@@ -62,9 +112,10 @@ type EmailVerification struct {
 
 // NewSession is constructor.
 func NewSession(logger logger.Logger, conf *config.Config) Session {
+	logger = logger.Component(config.LogComponentSession)
 	if !conf.Redis.Enabled {
 		logger.GetZapLogger().Infof("use CookieStore for session")
-		return &session{sessions.NewCookieStore([]byte("secret"))}
+		return &session{store: sessions.NewCookieStore([]byte("secret")), logger: logger}
 	}
 
 	logger.GetZapLogger().Infof("use redis for session")
@@ -75,7 +126,7 @@ func NewSession(logger logger.Logger, conf *config.Config) Session {
 		logger.GetZapLogger().Panicf("Failure redis connection, %s", err.Error())
 	}
 	logger.GetZapLogger().Infof(fmt.Sprintf("Success redis connection, %s", address))
-	return &session{store: store}
+	return &session{store: store, logger: logger, pool: store.Pool}
 }
 
 func (s *session) GetStore() sessions.Store {
@@ -83,8 +134,15 @@ func (s *session) GetStore() sessions.Store {
 }
 
 // Get returns a session for the current request.
+// The session is loaded from the store at the first call in the request, which is traced as a span.
 func (s *session) Get(c echo.Context) *sessions.Session {
-	sess, _ := s.store.Get(c.Request(), sessionStr)
+	_, span := otel.Tracer(config.TracerName).Start(c.Request().Context(), "session.get")
+	defer span.End()
+	sess, err := s.store.Get(c.Request(), sessionStr)
+	if err != nil {
+		span.RecordError(err)
+		s.logger.GetZapLoggerFromContext(c.Request().Context()).Debugf("Failed to load the session: %s", err.Error())
+	}
 	return sess
 }
 
@@ -110,7 +168,12 @@ func (s *session) Delete(c echo.Context) error {
 }
 
 func (s *session) saveSession(c echo.Context, sess *sessions.Session) error {
+	_, span := otel.Tracer(config.TracerName).Start(c.Request().Context(), "session.save")
+	defer span.End()
 	if err := sess.Save(c.Request(), c.Response()); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		s.logger.GetZapLoggerFromContext(c.Request().Context()).Errorf("Failed to save the session: %s", err.Error())
 		return fmt.Errorf("error occurred while save session")
 	}
 	return nil
@@ -145,10 +208,14 @@ func (s *session) Login(c echo.Context, account *Account) error {
 	if err := s.Save(c); err != nil {
 		return err
 	}
+	s.index(c, account)
 	return nil
 }
 
 func (s *session) Logout(c echo.Context) error {
+	if account := s.GetAccount(c); account != nil && account.AccessTokenId == 0 {
+		s.unindex(c.Request().Context(), account.Id, s.Get(c).ID)
+	}
 	if err := s.SetAccount(c, nil); err != nil {
 		return err
 	}
@@ -158,6 +225,70 @@ func (s *session) Logout(c echo.Context) error {
 	return nil
 }
 
+// GetSessions returns the sessions of the account which are logged in.
+// The sessions are not held by this application if CookieStore is used, so none is returned.
+func (s *session) GetSessions(ctx context.Context, accountId uint) ([]SessionInfo, error) {
+	sessions := []SessionInfo{}
+	if s.pool == nil {
+		return sessions, nil
+	}
+	conn := s.pool.Get()
+	defer util.Check(conn.Close)
+
+	index, err := redis.StringMap(conn.Do("HGETALL", sessionIndexKey(accountId)))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for id, loginTime := range index {
+		ttl, err := redis.Int64(conn.Do("PTTL", redisSessionKeyPrefix+id))
+		if err != nil {
+			return nil, err
+		}
+		// the session has expired or has been deleted without logging out
+		if ttl < 0 {
+			s.unindex(ctx, accountId, id)
+			continue
+		}
+		info := SessionInfo{ExpiresAt: now.Add(time.Duration(ttl) * time.Millisecond)}
+		_ = info.LoginTime.UnmarshalText([]byte(loginTime))
+		sessions = append(sessions, info)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LoginTime.Before(sessions[j].LoginTime) })
+	return sessions, nil
+}
+
+// index records the session of the account which has logged in, so that its sessions can be listed.
+// The failure is only logged, because the session itself has been saved.
+func (s *session) index(c echo.Context, account *Account) {
+	if s.pool == nil {
+		return
+	}
+	conn := s.pool.Get()
+	defer util.Check(conn.Close)
+
+	loginTime, _ := account.LoginTime.MarshalText()
+	if _, err := conn.Do("HSET", sessionIndexKey(account.Id), s.Get(c).ID, string(loginTime)); err != nil {
+		s.logger.GetZapLoggerFromContext(c.Request().Context()).Errorf("Failed to index the session of account %d: %s", account.Id, err.Error())
+	}
+}
+
+func (s *session) unindex(ctx context.Context, accountId uint, id string) {
+	if s.pool == nil || id == "" {
+		return
+	}
+	conn := s.pool.Get()
+	defer util.Check(conn.Close)
+
+	if _, err := conn.Do("HDEL", sessionIndexKey(accountId), id); err != nil {
+		s.logger.GetZapLoggerFromContext(ctx).Errorf("Failed to unindex the session of account %d: %s", accountId, err.Error())
+	}
+}
+
+func sessionIndexKey(accountId uint) string {
+	return fmt.Sprintf("%s%d", config.SessionIndexRedisKeyPrefix, accountId)
+}
+
 func (s *session) SetAccount(c echo.Context, account *Account) error {
 	bytes, err := json.Marshal(account)
 	if err != nil {
@@ -167,6 +298,9 @@ func (s *session) SetAccount(c echo.Context, account *Account) error {
 }
 
 func (s *session) GetAccount(c echo.Context) *Account {
+	if a, ok := c.Get(accountStr).(*Account); ok && a != nil {
+		return a
+	}
 	if v := s.GetValue(c, accountStr); v != "" {
 		a := &Account{}
 		_ = json.Unmarshal([]byte(v), a)
@@ -175,6 +309,11 @@ func (s *session) GetAccount(c echo.Context) *Account {
 	return nil
 }
 
+// SetRequestAccount binds the account to the current request only. It is not saved in the session.
+func (s *session) SetRequestAccount(c echo.Context, account *Account) {
+	c.Set(accountStr, account)
+}
+
 func (s *session) SetEmailVerification(c echo.Context, emailVerification *EmailVerification) error {
 	bytes, err := json.Marshal(emailVerification)
 	if err != nil {
@@ -226,17 +365,3 @@ func (s *session) IsVerifiedEmail(c echo.Context, email string) (bool, error) {
 	}
 	return true, nil
 }
-
-func (s *session) HasAuthorizationTo(c echo.Context, accountId uint, authority uint) bool {
-	currentAccount := s.GetAccount(c)
-	if currentAccount == nil {
-		return false
-	}
-	if currentAccount.Id == accountId {
-		return true
-	}
-	if currentAccount.Authority < authority {
-		return true
-	}
-	return false
-}
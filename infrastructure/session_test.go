@@ -0,0 +1,76 @@
+package infrastructure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	echosession "github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newRedisSession(t *testing.T) (*echo.Echo, Session, *miniredis.Miniredis) {
+	m := miniredis.RunT(t)
+	conf := &config.Config{}
+	conf.Redis.Enabled = true
+	conf.Redis.Host = m.Host()
+	conf.Redis.Port = m.Port()
+	conf.Redis.ConnectionPoolSize = 1
+	sess := NewSession(logger.NewLogger(zap.NewNop().Sugar(), conf), conf)
+
+	e := echo.New()
+	e.Use(echosession.Middleware(sess.GetStore()))
+	e.POST("/login", func(c echo.Context) error { return sess.Login(c, &Account{Id: 1, LoginId: "test"}) })
+	e.POST("/logout", func(c echo.Context) error { return sess.Logout(c) })
+	return e, sess, m
+}
+
+func serveSession(e *echo.Echo, path string, cookies []*http.Cookie) []*http.Cookie {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Result().Cookies()
+}
+
+func TestSession_GetSessions(t *testing.T) {
+	e, sess, _ := newRedisSession(t)
+
+	first := serveSession(e, "/login", nil)
+	serveSession(e, "/login", nil)
+	sessions, err := sess.GetSessions(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Len(t, sessions, 2)
+	assert.WithinDuration(t, time.Now(), sessions[0].LoginTime, time.Minute)
+	assert.True(t, sessions[0].ExpiresAt.After(time.Now()))
+
+	serveSession(e, "/logout", first)
+	sessions, err = sess.GetSessions(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Len(t, sessions, 1)
+
+	sessions, err = sess.GetSessions(context.Background(), 2)
+	assert.Nil(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestSession_GetSessionsExpired(t *testing.T) {
+	e, sess, m := newRedisSession(t)
+
+	serveSession(e, "/login", nil)
+	m.FastForward(31 * 24 * time.Hour)
+
+	sessions, err := sess.GetSessions(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Empty(t, sessions)
+	assert.False(t, m.Exists(sessionIndexKey(1)))
+}
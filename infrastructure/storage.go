@@ -0,0 +1,273 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/logger"
+)
+
+// ErrFileNotFound is returned when no file is stored by a given key.
+var ErrFileNotFound = errors.New("file not found")
+
+// ErrInvalidSignature is returned when a signed URL is tampered or expired.
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+const (
+	signedURLExpiresParam   = "expires"
+	signedURLSignatureParam = "signature"
+)
+
+// Storage stores the files such as avatars by key. A key is a slash separated relative path.
+type Storage interface {
+	Put(key string, body io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	// SignedURL returns the URL to download the file which expires after a given duration.
+	SignedURL(key string, expiry time.Duration) (string, error)
+}
+
+// SignedURLVerifier is implemented by the storages which files are downloaded through this application.
+type SignedURLVerifier interface {
+	VerifySignedURL(key string, query url.Values) error
+}
+
+// NewStorage is constructor. It returns the storage of the backend selected by the configuration.
+func NewStorage(logger logger.Logger, conf *config.Config) Storage {
+	switch conf.Storage.Type {
+	case "", config.StorageTypeLocal:
+		root := conf.Storage.Local.Root
+		if root == "" {
+			root = "storage"
+		}
+		storage, err := NewLocalStorage(root, conf.Storage.SigningKey)
+		if err != nil {
+			logger.GetZapLogger().Errorf("Failure local storage initialization. error: %s", err.Error())
+			os.Exit(config.ErrExitStatus)
+		}
+		logger.GetZapLogger().Infof("Success local storage initialization, %s", root)
+		return storage
+	case config.StorageTypeS3:
+		s3 := conf.Storage.S3
+		storage, err := NewS3Storage(s3.Endpoint, s3.Region, s3.Bucket, s3.AccessKey, s3.SecretKey, s3.UseSSL)
+		if err != nil {
+			logger.GetZapLogger().Errorf("Failure s3 storage connection. error: %s", err.Error())
+			os.Exit(config.ErrExitStatus)
+		}
+		logger.GetZapLogger().Infof("Success s3 storage connection, %s/%s", s3.Endpoint, s3.Bucket)
+		return storage
+	default:
+		logger.GetZapLogger().Errorf("Not supported storage type: %s", conf.Storage.Type)
+		os.Exit(config.ErrExitStatus)
+	}
+	return nil
+}
+
+// cleanKey validates a given key and returns it in the canonical form.
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != key {
+		return "", fmt.Errorf("invalid storage key: %s", key)
+	}
+	return cleaned, nil
+}
+
+type localStorage struct {
+	root       string
+	signingKey []byte
+}
+
+// NewLocalStorage is constructor. The files are stored under a given root directory.
+// If the signing key is empty, a random key is used, so the signed URLs are invalidated on restart.
+func NewLocalStorage(root, signingKey string) (Storage, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+	key := []byte(signingKey)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	return &localStorage{root: root, signingKey: key}, nil
+}
+
+func (s *localStorage) Put(key string, body io.Reader, size int64, contentType string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so the readers never see a partial file.
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *localStorage) Get(key string) (io.ReadCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrFileNotFound
+	}
+	return file, err
+}
+
+func (s *localStorage) Delete(key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *localStorage) SignedURL(key string, expiry time.Duration) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{}
+	query.Set(signedURLExpiresParam, expires)
+	query.Set(signedURLSignatureParam, s.sign(key, expires))
+	return config.APIFiles + "/" + key + "?" + query.Encode(), nil
+}
+
+func (s *localStorage) VerifySignedURL(key string, query url.Values) error {
+	expires := query.Get(signedURLExpiresParam)
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(s.sign(key, expires)), []byte(query.Get(signedURLSignatureParam))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (s *localStorage) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *localStorage) path(key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+type s3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Storage is constructor. It connects to a S3 compatible server such as MinIO and creates the bucket if it does not exist.
+func NewS3Storage(endpoint, region, bucket, accessKey, secretKey string, useSSL bool) (Storage, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+		Region: region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: region}); err != nil {
+			return nil, err
+		}
+	}
+	return &s3Storage{client: client, bucket: bucket}, nil
+}
+
+func (s *s3Storage) Put(key string, body io.Reader, size int64, contentType string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(context.Background(), s.bucket, key, body, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *s3Storage) Get(key string) (io.ReadCloser, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	object, err := s.client.GetObject(context.Background(), s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject does not request until the first read, so the existence is checked here.
+	if _, err := object.Stat(); err != nil {
+		_ = object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrFileNotFound
+		}
+		return nil, err
+	}
+	return object, nil
+}
+
+func (s *s3Storage) Delete(key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	return s.client.RemoveObject(context.Background(), s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *s3Storage) SignedURL(key string, expiry time.Duration) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	u, err := s.client.PresignedGetObject(context.Background(), s.bucket, key, expiry, url.Values{})
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,59 @@
+package infrastructure
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorage_Success(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir(), "test")
+	assert.Nil(t, err)
+	testStorage(t, storage)
+}
+
+func TestLocalStorage_InvalidKeyFailure(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir(), "test")
+	assert.Nil(t, err)
+
+	for _, key := range []string{"", "../escape.txt", "a/../../escape.txt", "/absolute.txt", "a//b.txt"} {
+		assert.NotNil(t, storage.Put(key, strings.NewReader("x"), 1, "text/plain"), key)
+	}
+}
+
+// TestS3Storage_Success runs against a S3 compatible server such as MinIO, e.g.
+// docker run -p 9000:9000 minio/minio server /data
+// STORAGE_TEST_S3_ENDPOINT=localhost:9000 go test ./infrastructure/
+func TestS3Storage_Success(t *testing.T) {
+	endpoint := os.Getenv("STORAGE_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("STORAGE_TEST_S3_ENDPOINT is not set")
+	}
+	storage, err := NewS3Storage(endpoint, "us-east-1", "bistory-test", "minioadmin", "minioadmin", false)
+	assert.Nil(t, err)
+	testStorage(t, storage)
+}
+
+func testStorage(t *testing.T, storage Storage) {
+	key := "test/hello.txt"
+	assert.Nil(t, storage.Put(key, strings.NewReader("hello"), 5, "text/plain"))
+
+	file, err := storage.Get(key)
+	assert.Nil(t, err)
+	body, err := io.ReadAll(file)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+	assert.Equal(t, "hello", string(body))
+
+	signedURL, err := storage.SignedURL(key, time.Minute)
+	assert.Nil(t, err)
+	assert.Contains(t, signedURL, key)
+
+	assert.Nil(t, storage.Delete(key))
+	_, err = storage.Get(key)
+	assert.ErrorIs(t, err, ErrFileNotFound)
+}
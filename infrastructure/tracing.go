@@ -0,0 +1,72 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// InitTracing sets up the global tracer provider to export the spans as configured,
+// and returns the function to flush the remaining spans and stop it.
+// The spans are not recorded if the tracing is disabled, because the default global tracer provider is no-op.
+func InitTracing(logger logger.Logger, conf *config.Config) func() error {
+	if !conf.Tracing.Enabled {
+		return func() error { return nil }
+	}
+
+	exporter, err := newSpanExporter(conf)
+	if err != nil {
+		logger.GetZapLogger().Errorf("Failed to create the span exporter, the tracing is disabled: %s", err.Error())
+		return func() error { return nil }
+	}
+
+	ratio := conf.Tracing.SampleRatio
+	if ratio == 0 {
+		ratio = config.TracingSampleRatio
+	}
+	serviceName := conf.Tracing.ServiceName
+	if serviceName == "" {
+		serviceName = config.TracingServiceName
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	logger.GetZapLogger().Infof("Started the tracing, exported to %s", conf.Tracing.Exporter)
+
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), config.TracingShutdownDeadline)
+		defer cancel()
+		return provider.Shutdown(ctx)
+	}
+}
+
+func newSpanExporter(conf *config.Config) (sdktrace.SpanExporter, error) {
+	switch conf.Tracing.Exporter {
+	case config.TracingExporterOTLP:
+		opts := []otlptracehttp.Option{}
+		if conf.Tracing.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(conf.Tracing.Endpoint))
+		}
+		if conf.Tracing.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+	case config.TracingExporterStdout, "":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	}
+	return nil, fmt.Errorf("unknown exporter: %s", conf.Tracing.Exporter)
+}
@@ -0,0 +1,58 @@
+package infrastructure
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type unitOfWorkKey struct{}
+
+// unitOfWork is the transaction carried by a context, with the hooks to run after it is committed.
+type unitOfWork struct {
+	db    *gorm.DB
+	hooks []func()
+}
+
+// InTransaction runs fn as a unit of work in a transaction, which is carried by the context given to fn.
+// The repositories got by WithContext of the context join the transaction, so the services called in fn share it.
+// If the context already carries a unit of work, fn runs in a nested savepoint which is rolled back alone on error.
+func InTransaction(ctx context.Context, rep Repository, fn func(ctx context.Context) error) error {
+	parent := unitOfWorkFromContext(ctx)
+	var uow *unitOfWork
+	err := rep.WithContext(ctx).Transaction(func(tx Repository) error {
+		uow = &unitOfWork{db: tx.(*repository).db}
+		return fn(context.WithValue(ctx, unitOfWorkKey{}, uow))
+	})
+	if err != nil {
+		return err
+	}
+
+	if parent != nil {
+		parent.hooks = append(parent.hooks, uow.hooks...)
+		return nil
+	}
+	for _, hook := range uow.hooks {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit registers a hook which runs after the outermost transaction of the context is committed,
+// for the side effects such as sending emails. It is discarded if the transaction or the savepoint is rolled back.
+// The hook runs at once if the context carries no unit of work.
+func AfterCommit(ctx context.Context, hook func()) {
+	if uow := unitOfWorkFromContext(ctx); uow != nil {
+		uow.hooks = append(uow.hooks, hook)
+		return
+	}
+	hook()
+}
+
+func unitOfWorkFromContext(ctx context.Context) *unitOfWork {
+	if ctx == nil {
+		return nil
+	}
+	uow, _ := ctx.Value(unitOfWorkKey{}).(*unitOfWork)
+	return uow
+}
@@ -0,0 +1,103 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newUnitOfWorkTestRepository(t *testing.T) Repository {
+	conf := &config.Config{}
+	conf.Database.Dialect = SQLITE
+	conf.Database.Host = filepath.Join(t.TempDir(), "test.db")
+	rep := NewRepository(logger.NewLogger(zap.NewNop().Sugar(), conf), conf)
+	t.Cleanup(func() { _ = rep.Close() })
+	assert.Nil(t, rep.Exec("CREATE TABLE items (name text UNIQUE)").Error)
+	return rep
+}
+
+func itemNames(t *testing.T, rep Repository) []string {
+	var names []string
+	assert.Nil(t, rep.Raw("SELECT name FROM items ORDER BY name").Scan(&names).Error)
+	return names
+}
+
+func TestInTransaction_Commit(t *testing.T) {
+	rep := newUnitOfWorkTestRepository(t)
+
+	hooks := []string{}
+	err := InTransaction(context.Background(), rep, func(ctx context.Context) error {
+		assert.Nil(t, rep.WithContext(ctx).Exec("INSERT INTO items VALUES ('a')").Error)
+		AfterCommit(ctx, func() { hooks = append(hooks, "a") })
+		assert.Empty(t, hooks)
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a"}, itemNames(t, rep))
+	assert.Equal(t, []string{"a"}, hooks)
+}
+
+func TestInTransaction_Rollback(t *testing.T) {
+	rep := newUnitOfWorkTestRepository(t)
+
+	hooks := []string{}
+	err := InTransaction(context.Background(), rep, func(ctx context.Context) error {
+		assert.Nil(t, rep.WithContext(ctx).Exec("INSERT INTO items VALUES ('a')").Error)
+		AfterCommit(ctx, func() { hooks = append(hooks, "a") })
+		return errors.New("failed")
+	})
+
+	assert.EqualError(t, err, "failed")
+	assert.Empty(t, itemNames(t, rep))
+	assert.Empty(t, hooks)
+}
+
+func TestInTransaction_NestedSavepoint(t *testing.T) {
+	rep := newUnitOfWorkTestRepository(t)
+
+	hooks := []string{}
+	err := InTransaction(context.Background(), rep, func(ctx context.Context) error {
+		assert.Nil(t, rep.WithContext(ctx).Exec("INSERT INTO items VALUES ('a')").Error)
+		AfterCommit(ctx, func() { hooks = append(hooks, "a") })
+
+		err := InTransaction(ctx, rep, func(ctx context.Context) error {
+			assert.Nil(t, rep.WithContext(ctx).Exec("INSERT INTO items VALUES ('b')").Error)
+			AfterCommit(ctx, func() { hooks = append(hooks, "b") })
+			return errors.New("failed")
+		})
+		assert.EqualError(t, err, "failed")
+
+		return InTransaction(ctx, rep, func(ctx context.Context) error {
+			assert.Nil(t, rep.WithContext(ctx).Exec("INSERT INTO items VALUES ('c')").Error)
+			AfterCommit(ctx, func() { hooks = append(hooks, "c") })
+			return nil
+		})
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "c"}, itemNames(t, rep))
+	assert.Equal(t, []string{"a", "c"}, hooks)
+}
+
+func TestAfterCommit_WithoutTransaction(t *testing.T) {
+	called := false
+
+	AfterCommit(context.Background(), func() { called = true })
+
+	assert.True(t, called)
+}
+
+func TestAlreadyExists_UniqueViolation(t *testing.T) {
+	rep := newUnitOfWorkTestRepository(t)
+	assert.Nil(t, rep.Exec("INSERT INTO items VALUES ('a')").Error)
+	err := alreadyExists(rep.Exec("INSERT INTO items VALUES ('a')").Error)
+
+	assert.True(t, errors.Is(err, ErrAlreadyExists))
+}
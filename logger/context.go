@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// RequestIdField is the name of the structured field of the request id in the log lines.
+	RequestIdField = "request_id"
+	// TraceIdField is the name of the structured field of the trace id in the log lines, set if the request is traced.
+	TraceIdField = "trace_id"
+)
+
+type requestIdKey struct{}
+
+// WithRequestId returns a copy of a given context which carries the request id.
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestIdFromContext returns the request id carried by a given context, or an empty string.
+func RequestIdFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+// GetZapLoggerFromContext returns zapSugaredLogger with the request id and the trace id of a given context as fields.
+func (log *logger) GetZapLoggerFromContext(ctx context.Context) *zap.SugaredLogger {
+	if ctx == nil {
+		return log.Zap
+	}
+	fields := []interface{}{}
+	if requestId := RequestIdFromContext(ctx); requestId != "" {
+		fields = append(fields, RequestIdField, requestId)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		fields = append(fields, TraceIdField, spanContext.TraceID().String())
+	}
+	if len(fields) == 0 {
+		return log.Zap
+	}
+	return log.Zap.With(fields...)
+}
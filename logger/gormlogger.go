@@ -18,43 +18,68 @@ const (
 	sqlFormat     = logTitle + "%s"
 	messageFormat = logTitle + "%s, %s"
 	errorFormat   = logTitle + "%s, %s, %s"
-	slowThreshold = 200
 )
 
-// LogMode The log level of gorm logger is overwritten by the log level of Zap logger.
-func (log *logger) LogMode(_ gormLogger.LogLevel) gormLogger.Interface {
-	return log
+// LogMode returns a copy of the logger limited to a given level of GORM, such as by db.Debug().
+// The level of the gorm component of Zap logger is applied as well.
+func (log *logger) LogMode(level gormLogger.LogLevel) gormLogger.Interface {
+	copied := *log
+	copied.gormLevel = level
+	return &copied
+}
+
+// gormEnabled judges whether the logs of a given level of GORM are printed. All levels are printed unless LogMode is called.
+func (log *logger) gormEnabled(level gormLogger.LogLevel) bool {
+	return log.gormLevel == 0 || log.gormLevel >= level
 }
 
 // Info prints a information log.
-func (log *logger) Info(_ context.Context, msg string, data ...interface{}) {
-	log.Zap.Infof(messageFormat, append([]interface{}{msg, gormUtils.FileWithLineNum()}, data...)...)
+func (log *logger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if !log.gormEnabled(gormLogger.Info) {
+		return
+	}
+	log.GetZapLoggerFromContext(ctx).Infof(messageFormat, append([]interface{}{msg, gormUtils.FileWithLineNum()}, data...)...)
 }
 
 // Warn prints a warning log.
-func (log *logger) Warn(_ context.Context, msg string, data ...interface{}) {
-	log.Zap.Warnf(messageFormat, append([]interface{}{msg, gormUtils.FileWithLineNum()}, data...)...)
+func (log *logger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if !log.gormEnabled(gormLogger.Warn) {
+		return
+	}
+	log.GetZapLoggerFromContext(ctx).Warnf(messageFormat, append([]interface{}{msg, gormUtils.FileWithLineNum()}, data...)...)
 }
 
 // Error prints a error log.
-func (log *logger) Error(_ context.Context, msg string, data ...interface{}) {
-	log.Zap.Errorf(messageFormat, append([]interface{}{msg, gormUtils.FileWithLineNum()}, data...)...)
+func (log *logger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if !log.gormEnabled(gormLogger.Error) {
+		return
+	}
+	log.GetZapLoggerFromContext(ctx).Errorf(messageFormat, append([]interface{}{msg, gormUtils.FileWithLineNum()}, data...)...)
+}
+
+// ParamsFilter masks the parameters of the sql bound to the sensitive columns, before the sql is traced.
+func (log *logger) ParamsFilter(_ context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, log.redactor.SQLParams(sql, params)
 }
 
 // Trace prints a trace log such as sql, source file and error.
-func (log *logger) Trace(_ context.Context, begin time.Time, fc func() (string, int64), err error) {
+func (log *logger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if !log.gormEnabled(gormLogger.Error) {
+		return
+	}
 	elapsed := time.Since(begin)
+	zap := log.GetZapLoggerFromContext(ctx)
 
 	switch {
 	case err != nil:
 		sql, _ := fc()
-		log.GetZapLogger().Errorf(errorFormat, gormUtils.FileWithLineNum(), err, sql)
-	case elapsed > slowThreshold*time.Millisecond && slowThreshold*time.Millisecond != 0:
+		zap.Errorf(errorFormat, gormUtils.FileWithLineNum(), log.redactor.Text(err.Error()), sql)
+	case log.slowThreshold > 0 && elapsed > log.slowThreshold && log.gormEnabled(gormLogger.Warn):
 		sql, _ := fc()
-		slowLog := fmt.Sprintf("SLOW SQL >= %v", slowThreshold)
-		log.GetZapLogger().Warnf(errorFormat, gormUtils.FileWithLineNum(), slowLog, sql)
-	default:
+		slowLog := fmt.Sprintf("SLOW SQL >= %v", log.slowThreshold)
+		zap.Warnf(errorFormat, gormUtils.FileWithLineNum(), slowLog, sql)
+	case log.gormEnabled(gormLogger.Info):
 		sql, _ := fc()
-		log.GetZapLogger().Debugf(sqlFormat, sql)
+		zap.Debugf(sqlFormat, sql)
 	}
 }
@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrUnknownComponent is returned when the level of a component which has no logger is changed.
+var ErrUnknownComponent = errors.New("unknown log component")
+
+// components are the named loggers registered by default.
+var components = []string{
+	config.LogComponentApp, config.LogComponentHTTP, config.LogComponentGorm,
+	config.LogComponentSession, config.LogComponentEmail,
+}
+
+// ComponentLevel represents the current log level of a component.
+// RevertAt is set while the level is changed temporarily.
+type ComponentLevel struct {
+	Component string     `json:"component"`
+	Level     string     `json:"level"`
+	RevertAt  *time.Time `json:"revertAt,omitempty"`
+}
+
+// Levels holds the atomic log levels of the components, which are changed at runtime.
+type Levels struct {
+	mu      sync.Mutex
+	levels  map[string]zap.AtomicLevel
+	reverts map[string]*revert
+}
+
+// revert restores the level of a component which is changed temporarily.
+type revert struct {
+	timer *time.Timer
+	level zapcore.Level
+	at    time.Time
+}
+
+// NewLevels is constructor. All default components start at a given level.
+func NewLevels(level zapcore.Level) *Levels {
+	l := &Levels{levels: make(map[string]zap.AtomicLevel), reverts: make(map[string]*revert)}
+	for _, component := range components {
+		l.levels[component] = zap.NewAtomicLevelAt(level)
+	}
+	return l
+}
+
+// Get returns the current levels of the components ordered by the name.
+func (l *Levels) Get() []ComponentLevel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]ComponentLevel, 0, len(l.levels))
+	for component, level := range l.levels {
+		entry := ComponentLevel{Component: component, Level: level.String()}
+		if r, ok := l.reverts[component]; ok {
+			at := r.at
+			entry.RevertAt = &at
+		}
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Component < result[j].Component })
+	return result
+}
+
+// Set changes the level of a component. If revertAfter is positive, the level is restored after it.
+// Changing the level again before the revert keeps the level to be restored.
+func (l *Levels) Set(component string, level zapcore.Level, revertAfter time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	atomic, ok := l.levels[component]
+	if !ok {
+		return ErrUnknownComponent
+	}
+
+	original := atomic.Level()
+	if r, ok := l.reverts[component]; ok {
+		r.timer.Stop()
+		original = r.level
+		delete(l.reverts, component)
+	}
+	atomic.SetLevel(level)
+
+	if revertAfter > 0 {
+		r := &revert{level: original, at: time.Now().Add(revertAfter)}
+		r.timer = time.AfterFunc(revertAfter, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.reverts[component] == r {
+				atomic.SetLevel(r.level)
+				delete(l.reverts, component)
+			}
+		})
+		l.reverts[component] = r
+	}
+	return nil
+}
+
+// level returns the atomic level of a component. A component which is not registered yet is added at the level of the app.
+func (l *Levels) level(component string) zap.AtomicLevel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if atomic, ok := l.levels[component]; ok {
+		return atomic
+	}
+	atomic := zap.NewAtomicLevelAt(l.levels[config.LogComponentApp].Level())
+	l.levels[component] = atomic
+	return atomic
+}
+
+// levelCore filters the entries of a core by the atomic level of a component.
+// The wrapped core is built at the lowest level, so the level of a component can be lowered below the configured one.
+type levelCore struct {
+	zapcore.Core
+	level zap.AtomicLevel
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level) && c.Core.Enabled(level)
+}
+
+func (c *levelCore) Level() zapcore.Level {
+	return c.level.Level()
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
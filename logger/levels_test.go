@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+func newObservedLogger(conf *config.Config) (Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return NewLogger(zap.New(core).Sugar(), conf), logs
+}
+
+func TestComponent_Level(t *testing.T) {
+	log, logs := newObservedLogger(&config.Config{})
+	http := log.Component(config.LogComponentHTTP)
+
+	assert.Nil(t, log.GetLevels().Set(config.LogComponentHTTP, zapcore.WarnLevel, 0))
+	http.GetZapLogger().Infof("http info")
+	http.GetZapLogger().Warnf("http warn")
+	log.GetZapLogger().Infof("app info")
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "http warn", entries[0].Message)
+	assert.Equal(t, config.LogComponentHTTP, entries[0].LoggerName)
+	assert.Equal(t, "app info", entries[1].Message)
+	assert.Equal(t, "", entries[1].LoggerName)
+}
+
+func TestLevelsSet_Revert(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+
+	assert.Nil(t, levels.Set(config.LogComponentGorm, zapcore.DebugLevel, 50*time.Millisecond))
+	for _, level := range levels.Get() {
+		if level.Component == config.LogComponentGorm {
+			assert.Equal(t, "debug", level.Level)
+			assert.NotNil(t, level.RevertAt)
+		} else {
+			assert.Equal(t, "info", level.Level)
+			assert.Nil(t, level.RevertAt)
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		return !levels.level(config.LogComponentGorm).Enabled(zapcore.DebugLevel)
+	}, time.Second, 10*time.Millisecond)
+	for _, level := range levels.Get() {
+		assert.Nil(t, level.RevertAt)
+	}
+}
+
+func TestLevelsSet_RevertKeepsOriginal(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+
+	assert.Nil(t, levels.Set(config.LogComponentEmail, zapcore.DebugLevel, time.Hour))
+	assert.Nil(t, levels.Set(config.LogComponentEmail, zapcore.ErrorLevel, 20*time.Millisecond))
+
+	assert.Eventually(t, func() bool {
+		return levels.level(config.LogComponentEmail).Level() == zapcore.InfoLevel
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLevelsSet_UnknownComponent(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+
+	err := levels.Set("unknown", zapcore.DebugLevel, 0)
+
+	assert.True(t, errors.Is(err, ErrUnknownComponent))
+}
+
+func TestTrace_SlowThreshold(t *testing.T) {
+	conf := &config.Config{}
+	conf.Database.SlowThreshold = time.Millisecond
+	log, logs := newObservedLogger(conf)
+
+	log.Trace(context.Background(), time.Now().Add(-time.Second), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	log.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 2", 1 }, nil)
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	assert.Contains(t, entries[0].Message, "SLOW SQL >= 1ms")
+	assert.Equal(t, zapcore.DebugLevel, entries[1].Level)
+}
+
+func TestLogMode_Silent(t *testing.T) {
+	log, logs := newObservedLogger(&config.Config{})
+
+	silent := log.LogMode(gormLogger.Silent)
+	silent.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 0 }, errors.New("failed"))
+	silent.Error(context.Background(), "failed")
+	log.LogMode(gormLogger.Error).Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	assert.Equal(t, 0, logs.Len())
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/onetooler/bistory-backend/config"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
 	"gopkg.in/yaml.v3"
 	gormLogger "gorm.io/gorm/logger"
@@ -18,11 +19,17 @@ import (
 type Config struct {
 	ZapConfig zap.Config        `json:"zap_config" yaml:"zap_config"`
 	LogRotate lumberjack.Logger `json:"log_rotate" yaml:"log_rotate"`
+	// Levels overrides the level of zap_config by the components, such as http and gorm.
+	Levels map[string]string `json:"levels" yaml:"levels"`
 }
 
 // Logger is an alternative implementation of *gorm.Logger
 type Logger interface {
 	GetZapLogger() *zap.SugaredLogger
+	GetZapLoggerFromContext(ctx context.Context) *zap.SugaredLogger
+	GetRedactor() *Redactor
+	GetLevels() *Levels
+	Component(name string) Logger
 	LogMode(level gormLogger.LogLevel) gormLogger.Interface
 	Info(ctx context.Context, msg string, data ...interface{})
 	Warn(ctx context.Context, msg string, data ...interface{})
@@ -31,16 +38,30 @@ type Logger interface {
 }
 
 type logger struct {
-	Zap *zap.SugaredLogger
+	Zap           *zap.SugaredLogger
+	base          *zap.SugaredLogger
+	redactor      *Redactor
+	levels        *Levels
+	slowThreshold time.Duration
+	gormLevel     gormLogger.LogLevel
 }
 
-// NewLogger is constructor for logger
-func NewLogger(sugar *zap.SugaredLogger) Logger {
-	return &logger{Zap: sugar}
+// NewLogger is constructor for logger. The components start at the level of a given zap logger.
+func NewLogger(sugar *zap.SugaredLogger, conf *config.Config) Logger {
+	return newLogger(sugar, conf, NewLevels(zapcore.LevelOf(sugar.Desugar().Core())))
+}
+
+func newLogger(sugar *zap.SugaredLogger, conf *config.Config, levels *Levels) Logger {
+	slowThreshold := conf.Database.SlowThreshold
+	if slowThreshold == 0 {
+		slowThreshold = config.DefaultSlowThreshold
+	}
+	log := &logger{base: sugar, redactor: NewRedactor(conf), levels: levels, slowThreshold: slowThreshold}
+	return log.Component(config.LogComponentApp)
 }
 
 // InitLogger create logger object for *gorm.DB from *echo.Logger
-func InitLogger(env string, yamlFile embed.FS) Logger {
+func InitLogger(env string, yamlFile embed.FS, conf *config.Config) Logger {
 	configYaml, err := yamlFile.ReadFile(fmt.Sprintf(config.LoggerConfigPath, env))
 	if err != nil {
 		fmt.Printf("Failed to read logger configuration: %s", err)
@@ -57,13 +78,56 @@ func InitLogger(env string, yamlFile embed.FS) Logger {
 		fmt.Printf("Failed to compose zap logger : %s", err)
 		os.Exit(config.ErrExitStatus)
 	}
+	levels, err := initLevels(myConfig)
+	if err != nil {
+		fmt.Printf("Failed to read the log levels of the components: %s", err)
+		os.Exit(config.ErrExitStatus)
+	}
 	sugar := zap.Sugar()
-	log := NewLogger(sugar)
+	log := newLogger(sugar, conf, levels)
 	log.GetZapLogger().Infof("Success to read zap logger configuration: zaplogger." + env + ".yml")
 	_ = zap.Sync()
 	return log
 }
 
+// initLevels returns the levels of the components, which are the level of zap_config unless overridden by levels.
+func initLevels(cfg *Config) (*Levels, error) {
+	levels := NewLevels(cfg.ZapConfig.Level.Level())
+	for component, text := range cfg.Levels {
+		level, err := zapcore.ParseLevel(text)
+		if err != nil {
+			return nil, err
+		}
+		levels.level(component).SetLevel(level)
+	}
+	return levels, nil
+}
+
+// Component returns the logger of a given component, whose level is changed individually.
+// The name of the component is added to the log lines except for the app.
+func (log *logger) Component(name string) Logger {
+	level := log.levels.level(name)
+	sugar := log.base.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelCore{Core: core, level: level}
+	}))
+	if name != config.LogComponentApp {
+		sugar = sugar.Named(name)
+	}
+	component := *log
+	component.Zap = sugar
+	return &component
+}
+
+// GetLevels returns the levels of the components.
+func (log *logger) GetLevels() *Levels {
+	return log.levels
+}
+
+// GetRedactor returns the redactor of the sensitive data in the logs.
+func (log *logger) GetRedactor() *Redactor {
+	return log.redactor
+}
+
 // GetZapLogger returns zapSugaredLogger
 func (log *logger) GetZapLogger() *zap.SugaredLogger {
 	return log.Zap
@@ -0,0 +1,210 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/onetooler/bistory-backend/config"
+)
+
+const (
+	// redacted replaces the values of the sensitive fields in the logs.
+	redacted = "[REDACTED]"
+	// columnLookBehind is the bytes before a placeholder of the SQL to find its column.
+	columnLookBehind = 256
+)
+
+// defaultRedactFields are always masked in addition to the configured fields.
+var defaultRedactFields = []string{"password", "token", "secret", "signature"}
+
+// defaultRedactHeaders are always masked in addition to the configured headers.
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+var (
+	emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+	// pairPattern matches "key": "value", "key": value and key=value in the texts.
+	pairPattern = regexp.MustCompile(`("?)([A-Za-z_][A-Za-z0-9_\-]*)("?\s*[:=]\s*)("(?:[^"\\]|\\.)*"|[^\s,&}\]]+)`)
+	// placeholderPattern matches the placeholders of the SQL, ? or $1 of PostgreSQL.
+	placeholderPattern = regexp.MustCompile(`\?|\$(\d+)`)
+	// columnPattern matches the column compared with, assigned to or listed in IN with the following placeholder.
+	columnPattern = regexp.MustCompile("[`\"]?(\\w+)[`\"]?\\s*(?:=|<>|!=|<=|>=|<|>|(?i:like)|(?i:in)\\s*\\((?:\\s*(?:\\?|\\$\\d+)\\s*,)*)\\s*$")
+	// insertPattern matches the columns and the values of the INSERT statement.
+	insertPattern = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+\S+\s*\(([^)]*)\)\s*VALUES\s*`)
+)
+
+// Redactor masks the sensitive data in the logs, such as passwords, tokens and email addresses.
+// A field is masked if its name contains any of the deny-list, ignoring the case and underscores.
+type Redactor struct {
+	fields      []string
+	headers     map[string]bool
+	showEmails  bool
+	bodyMaxSize int
+}
+
+// NewRedactor is constructor. The configured deny-lists are added to the default ones.
+func NewRedactor(conf *config.Config) *Redactor {
+	r := &Redactor{headers: make(map[string]bool), showEmails: conf.Log.ShowEmails, bodyMaxSize: conf.Log.BodyMaxSize}
+	for _, field := range append(defaultRedactFields, conf.Log.RedactFields...) {
+		r.fields = append(r.fields, normalizeField(field))
+	}
+	for _, header := range append(defaultRedactHeaders, conf.Log.RedactHeaders...) {
+		r.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	if r.bodyMaxSize == 0 {
+		r.bodyMaxSize = config.LogBodyMaxSize
+	}
+	return r
+}
+
+// Body returns the request or response body to log. The JSON fields in the deny-list are masked,
+// and the body is truncated to the configured size.
+func (r *Redactor) Body(body []byte) string {
+	var value interface{}
+	var result string
+	if err := json.Unmarshal(body, &value); err == nil {
+		bytes, _ := json.Marshal(r.redactValue("", value))
+		result = string(bytes)
+	} else {
+		result = r.Text(string(body))
+	}
+	if r.bodyMaxSize > 0 && len(result) > r.bodyMaxSize {
+		return fmt.Sprintf("%s...(truncated %d bytes)", result[:r.bodyMaxSize], len(result)-r.bodyMaxSize)
+	}
+	return result
+}
+
+// Value returns a decoded JSON or YAML value, whose fields in the deny-list are masked.
+func (r *Redactor) Value(value interface{}) interface{} {
+	return r.redactValue("", value)
+}
+
+// Header returns a copy of the headers whose values in the deny-list are masked.
+func (r *Redactor) Header(header http.Header) http.Header {
+	result := make(http.Header, len(header))
+	for key, values := range header {
+		if r.headers[http.CanonicalHeaderKey(key)] {
+			result[key] = []string{redacted}
+			continue
+		}
+		result[key] = values
+	}
+	return result
+}
+
+// URI returns the request URI whose query parameters in the deny-list are masked.
+func (r *Redactor) URI(uri string) string {
+	path, rawQuery, found := strings.Cut(uri, "?")
+	if !found {
+		return uri
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && r.isDenied(name) {
+			params[i] = key + "=" + redacted
+		}
+	}
+	return path + "?" + strings.Join(params, "&")
+}
+
+// Text returns the text such as an error message, whose values of the fields in the deny-list and email addresses are masked.
+func (r *Redactor) Text(text string) string {
+	text = pairPattern.ReplaceAllStringFunc(text, func(pair string) string {
+		m := pairPattern.FindStringSubmatch(pair)
+		if !r.isDenied(m[2]) {
+			return pair
+		}
+		return m[1] + m[2] + m[3] + redacted
+	})
+	return r.maskEmails(text)
+}
+
+// SQLParams returns the parameters of the SQL to log, whose values bound to the columns in the deny-list are masked.
+// The column of a parameter is found by the comparison or assignment before it, or by the column list of the INSERT.
+func (r *Redactor) SQLParams(sql string, params []interface{}) []interface{} {
+	result := make([]interface{}, len(params))
+	copy(result, params)
+
+	var insertColumns []string
+	valuesStart := -1
+	if m := insertPattern.FindStringSubmatchIndex(sql); m != nil {
+		for _, column := range strings.Split(sql[m[2]:m[3]], ",") {
+			insertColumns = append(insertColumns, strings.Trim(strings.TrimSpace(column), "`\""))
+		}
+		valuesStart = m[1]
+	}
+
+	for i, m := range placeholderPattern.FindAllStringSubmatchIndex(sql, -1) {
+		index := i
+		if m[2] >= 0 {
+			n, _ := strconv.Atoi(sql[m[2]:m[3]])
+			index = n - 1
+		}
+		if index < 0 || index >= len(result) {
+			continue
+		}
+
+		column := ""
+		if valuesStart >= 0 && m[0] >= valuesStart && len(insertColumns) > 0 {
+			column = insertColumns[index%len(insertColumns)]
+		} else if c := columnPattern.FindStringSubmatch(sql[max(0, m[0]-columnLookBehind):m[0]]); c != nil {
+			column = c[1]
+		}
+
+		if r.isDenied(column) {
+			result[index] = redacted
+		} else if s, ok := result[index].(string); ok {
+			result[index] = r.maskEmails(s)
+		}
+	}
+	return result
+}
+
+func (r *Redactor) redactValue(key string, value interface{}) interface{} {
+	if key != "" && r.isDenied(key) {
+		return redacted
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = r.redactValue(k, child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = r.redactValue("", child)
+		}
+	case string:
+		return r.maskEmails(v)
+	}
+	return value
+}
+
+func (r *Redactor) isDenied(name string) bool {
+	if name == "" {
+		return false
+	}
+	name = normalizeField(name)
+	for _, field := range r.fields {
+		if strings.Contains(name, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskEmails masks the local part of the email addresses except for the first letter, e.g. t***@example.com.
+func (r *Redactor) maskEmails(text string) string {
+	if r.showEmails {
+		return text
+	}
+	return emailPattern.ReplaceAllString(text, "$1***@$2")
+}
+
+func normalizeField(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
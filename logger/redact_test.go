@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactorBody_JSON(t *testing.T) {
+	r := NewRedactor(&config.Config{})
+
+	body := r.Body([]byte(`{"loginId":"test","password":"secret!","nested":{"NewPassword":"new"},"email":"test@example.com"}`))
+
+	assert.JSONEq(t, `{"loginId":"test","password":"[REDACTED]","nested":{"NewPassword":"[REDACTED]"},"email":"t***@example.com"}`, body)
+}
+
+func TestRedactorBody_ConfiguredFields(t *testing.T) {
+	conf := &config.Config{}
+	conf.Log.RedactFields = []string{"bio"}
+	conf.Log.ShowEmails = true
+	r := NewRedactor(conf)
+
+	body := r.Body([]byte(`[{"bio":"hello","email":"test@example.com","oldPassword":"old"}]`))
+
+	assert.JSONEq(t, `[{"bio":"[REDACTED]","email":"test@example.com","oldPassword":"[REDACTED]"}]`, body)
+}
+
+func TestRedactorBody_Truncated(t *testing.T) {
+	conf := &config.Config{}
+	conf.Log.BodyMaxSize = 10
+	r := NewRedactor(conf)
+
+	assert.Equal(t, "0123456789...(truncated 5 bytes)", r.Body([]byte("012345678901234")))
+}
+
+func TestRedactorBody_Form(t *testing.T) {
+	r := NewRedactor(&config.Config{})
+
+	assert.Equal(t, "loginId=test&password=[REDACTED]", r.Body([]byte("loginId=test&password=secret")))
+}
+
+func TestRedactorValue(t *testing.T) {
+	conf := &config.Config{}
+	conf.Log.RedactFields = []string{"key"}
+	r := NewRedactor(conf)
+
+	value := map[string]interface{}{
+		"database": map[string]interface{}{"host": "localhost", "password": "secret"},
+		"storage":  map[string]interface{}{"signing_key": "signing", "bucket": "files"},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"database": map[string]interface{}{"host": "localhost", "password": "[REDACTED]"},
+		"storage":  map[string]interface{}{"signing_key": "[REDACTED]", "bucket": "files"},
+	}, r.Value(value))
+}
+
+func TestRedactorHeader(t *testing.T) {
+	r := NewRedactor(&config.Config{})
+	header := http.Header{}
+	header.Set("Authorization", "Bearer bst_secret")
+	header.Set("Content-Type", "application/json")
+
+	redacted := r.Header(header)
+
+	assert.Equal(t, "[REDACTED]", redacted.Get("Authorization"))
+	assert.Equal(t, "application/json", redacted.Get("Content-Type"))
+	assert.Equal(t, "Bearer bst_secret", header.Get("Authorization"))
+}
+
+func TestRedactorURI(t *testing.T) {
+	r := NewRedactor(&config.Config{})
+
+	assert.Equal(t, "/api/files/a.zip?expires=1&signature=[REDACTED]", r.URI("/api/files/a.zip?expires=1&signature=abc"))
+	assert.Equal(t, "/api/health", r.URI("/api/health"))
+}
+
+func TestRedactorText(t *testing.T) {
+	r := NewRedactor(&config.Config{})
+
+	text := r.Text(`code=400, message=invalid {"password": "secret", "token":"abc"} for test@example.com`)
+
+	assert.Equal(t, `code=400, message=invalid {"password": [REDACTED], "token":[REDACTED]} for t***@example.com`, text)
+}
+
+func TestRedactorSQLParams(t *testing.T) {
+	r := NewRedactor(&config.Config{})
+
+	params := r.SQLParams("UPDATE `account` SET `password`=?,`version`=version + 1 WHERE id = ? AND `email` = ?",
+		[]interface{}{"$2a$10$hash", 1, "test@example.com"})
+	assert.Equal(t, []interface{}{"[REDACTED]", 1, "t***@example.com"}, params)
+
+	params = r.SQLParams(`INSERT INTO "account" ("login_id","password") VALUES ($1,$2),($3,$4)`,
+		[]interface{}{"a", "hash-a", "b", "hash-b"})
+	assert.Equal(t, []interface{}{"a", "[REDACTED]", "b", "[REDACTED]"}, params)
+
+	params = r.SQLParams("SELECT * FROM `access_token` WHERE token_hash IN (?,?)", []interface{}{"x", "y"})
+	assert.Equal(t, []interface{}{"[REDACTED]", "[REDACTED]"}, params)
+}
@@ -18,7 +18,8 @@ func build(cfg *Config) (*zap.Logger, error) {
 		return nil, errors.New("missing Level")
 	}
 
-	log := zap.New(zapcore.NewCore(enc, writer, zapCfg.Level), buildOptions(zapCfg, errWriter)...)
+	// The core accepts all levels, and the loggers of the components filter the entries by their own levels.
+	log := zap.New(zapcore.NewCore(enc, writer, zapcore.DebugLevel), buildOptions(zapCfg, errWriter)...)
 	return log, nil
 }
 
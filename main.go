@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"os"
 )
 
 //go:embed resources/config/application.*.yml
@@ -19,17 +20,21 @@ var emailFile embed.FS
 //go:embed resources/config/messages.properties
 var propsFile embed.FS
 
+//go:embed resources/seed
+var seedFile embed.FS
+
 // @title bistory-backend API
 // @version 0.0.1
 // @description This is API specification for bistory-backend project.
 // @host localhost:8080
 // @BasePath /api
 func main() {
-	Server{
+	os.Exit(Server{
 		YamlFile:    yamlFile,
 		ZapYamlFile: zapYamlFile,
 		StaticFile:  staticFile,
 		EmailFile:   emailFile,
 		PropsFile:   propsFile,
-	}.Run()
+		SeedFile:    seedFile,
+	}.Main())
 }
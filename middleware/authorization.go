@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/util"
+)
+
+type policyKind string
+
+const (
+	policyPublic            policyKind = "public"
+	policyAuthenticated     policyKind = "authenticated"
+	policyOwnerOrPermission policyKind = "owner-or-permission"
+	policyPermission        policyKind = "permission"
+)
+
+// Policy is the authorization requirement which is declared at the registration of a route.
+type Policy struct {
+	kind       policyKind
+	param      string
+	permission model.Permission
+	scope      string
+	// passwordChange allows the account which must change its password.
+	passwordChange bool
+}
+
+// Public allows everyone to access the route.
+func Public() Policy {
+	return Policy{kind: policyPublic}
+}
+
+// Authenticated allows the logged-in accounts to access the route.
+func Authenticated() Policy {
+	return Policy{kind: policyAuthenticated}
+}
+
+// OwnerOrPermission allows the account whose id is the path parameter, or the accounts which have a given permission.
+func OwnerOrPermission(param string, permission model.Permission) Policy {
+	return Policy{kind: policyOwnerOrPermission, param: param, permission: permission}
+}
+
+// RequirePermission allows the accounts which have a given permission.
+func RequirePermission(permission model.Permission) Policy {
+	return Policy{kind: policyPermission, permission: permission}
+}
+
+// WithScope allows the access tokens holding a given scope to access the route.
+// The access tokens are rejected on the routes which declare no scope.
+func (p Policy) WithScope(scope string) Policy {
+	p.scope = scope
+	return p
+}
+
+// AllowPasswordChange allows the account which must change its password to access the route.
+// Such an account is rejected on the other routes until it changes the password.
+func (p Policy) AllowPasswordChange() Policy {
+	p.passwordChange = true
+	return p
+}
+
+// String returns the description of the policy.
+func (p Policy) String() string {
+	switch p.kind {
+	case policyOwnerOrPermission:
+		return fmt.Sprintf("%s(:%s, %s)", p.kind, p.param, p.permission)
+	case policyPermission:
+		return fmt.Sprintf("%s(%s)", p.kind, p.permission)
+	default:
+		return string(p.kind)
+	}
+}
+
+// authorize returns the http status code to respond if the account is not allowed, otherwise 0.
+func (p Policy) authorize(c echo.Context, account *infrastructure.Account) int {
+	if p.kind == policyPublic {
+		return 0
+	}
+	if account == nil {
+		return http.StatusUnauthorized
+	}
+	if account.AccessTokenId != 0 && (p.scope == "" || !account.HasScope(p.scope)) {
+		return http.StatusForbidden
+	}
+	if account.MustChangePassword && !p.passwordChange {
+		return http.StatusForbidden
+	}
+
+	switch p.kind {
+	case policyAuthenticated:
+		return 0
+	case policyOwnerOrPermission:
+		if util.ConvertToUint(c.Param(p.param)) == account.Id || account.HasPermission(string(p.permission)) {
+			return 0
+		}
+	case policyPermission:
+		if account.HasPermission(string(p.permission)) {
+			return 0
+		}
+	}
+	return http.StatusForbidden
+}
+
+// RoutePolicy is an entry of the route table.
+type RoutePolicy struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Policy string `json:"policy"`
+	Scope  string `json:"scope,omitempty"`
+}
+
+// RouteTable holds the authorization policies declared for the routes.
+type RouteTable interface {
+	Declare(container container.Container, method, path string, policy Policy) echo.MiddlewareFunc
+	Verify(routes []*echo.Route) error
+	Entries() []RoutePolicy
+}
+
+type routeTable struct {
+	mu      sync.RWMutex
+	entries map[string]RoutePolicy
+}
+
+// NewRouteTable is constructor.
+func NewRouteTable() RouteTable {
+	return &routeTable{entries: make(map[string]RoutePolicy)}
+}
+
+// Declare records the policy of a route and returns the middleware which enforces it.
+func (t *routeTable) Declare(container container.Container, method, path string, policy Policy) echo.MiddlewareFunc {
+	t.mu.Lock()
+	t.entries[routeKey(method, path)] = RoutePolicy{Method: method, Path: path, Policy: policy.String(), Scope: policy.scope}
+	t.mu.Unlock()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			account := container.GetSession().GetAccount(c)
+			if status := policy.authorize(c, account); status != 0 {
+				return c.JSON(status, false)
+			}
+			if account != nil {
+				refreshSession(c, container, account)
+			}
+			return next(c)
+		}
+	}
+}
+
+// Verify returns an error if there are routes which have no declared policy.
+func (t *routeTable) Verify(routes []*echo.Route) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	missing := []string{}
+	for _, r := range routes {
+		if _, ok := t.entries[routeKey(r.Method, r.Path)]; !ok {
+			missing = append(missing, routeKey(r.Method, r.Path))
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("routes without authorization policy: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Entries returns the route table sorted by path and method.
+func (t *routeTable) Entries() []RoutePolicy {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	entries := make([]RoutePolicy, 0, len(t.entries))
+	for _, e := range t.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].Method < entries[j].Method
+	})
+	return entries
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// refreshSession saves the session to extend it. The account authenticated by an access token has no session.
+func refreshSession(c echo.Context, container container.Container, account *infrastructure.Account) {
+	if account.AccessTokenId == 0 {
+		_ = container.GetSession().Save(c)
+	}
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+)
+
+// idempotentReplayedHeaders are the response headers stored and replayed with the body.
+var idempotentReplayedHeaders = []string{
+	echo.HeaderContentType,
+	echo.HeaderLocation,
+	config.HeaderETag,
+}
+
+// IdempotencyMiddleware makes the POST requests with the Idempotency-Key header safe to retry.
+// It is applied to the routes registered by the router of Idempotent, rather than to all routes.
+// The response of the first request is stored by the key and replayed to the retries with the Idempotent-Replayed header.
+// The key is scoped by the client and the path, and it is rejected if it is reused for a different request
+// or while the first request is being processed. The client is the account, or the address of an anonymous client.
+// The responses of the server errors are not stored, so they can be retried.
+// The responses with Cache-Control: no-store or Set-Cookie are not stored either, because they contain secrets
+// such as the session, which must not be held by the store or handed to another client reusing the key.
+func IdempotencyMiddleware(container container.Container) echo.MiddlewareFunc {
+	ttl := container.GetConfig().Idempotency.TTL
+	if ttl <= 0 {
+		ttl = config.IdempotencyTTL
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			idempotencyKey := req.Header.Get(config.HeaderIdempotencyKey)
+			if req.Method != http.MethodPost || idempotencyKey == "" {
+				return next(c)
+			}
+			if len(idempotencyKey) > config.IdempotencyKeyMaxLength {
+				return c.JSON(http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", config.HeaderIdempotencyKey, config.IdempotencyKeyMaxLength))
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			client := "anonymous:" + c.RealIP()
+			if account := container.GetSession().GetAccount(c); account != nil {
+				client = fmt.Sprintf("account:%d", account.Id)
+			}
+			key := hashIdempotencyKey(client + ":" + req.URL.Path + ":" + idempotencyKey)
+			fingerprint := hashIdempotencyKey(req.Method + " " + req.URL.Path + "\n" + string(body))
+
+			store := container.GetIdempotencyStore()
+			log := container.GetLogger().GetZapLoggerFromContext(req.Context())
+			record, err := store.Reserve(req.Context(), key, fingerprint)
+			if err != nil {
+				log.Errorf("failed to reserve the idempotency key: %s", err.Error())
+				return c.JSON(http.StatusServiceUnavailable, false)
+			}
+			if record != nil {
+				switch {
+				case record.Fingerprint != fingerprint:
+					return c.JSON(http.StatusUnprocessableEntity, fmt.Sprintf("%s is already used for a different request", config.HeaderIdempotencyKey))
+				case !record.Completed:
+					return c.JSON(http.StatusConflict, fmt.Sprintf("the request of %s is being processed", config.HeaderIdempotencyKey))
+				}
+				header := c.Response().Header()
+				for name, values := range record.Header {
+					for _, value := range values {
+						header.Add(name, value)
+					}
+				}
+				header.Set(config.HeaderIdempotentReplayed, "true")
+				return c.Blob(record.Status, record.Header.Get(echo.HeaderContentType), record.Body)
+			}
+
+			res := c.Response()
+			recorder := &responseRecorder{ResponseWriter: res.Writer}
+			res.Writer = recorder
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+			res.Writer = recorder.ResponseWriter
+
+			// the response is stored even if the request is canceled after it is written
+			ctx := context.WithoutCancel(req.Context())
+			if res.Status >= http.StatusInternalServerError || hasSecret(res.Header()) {
+				err = store.Release(ctx, key)
+			} else {
+				err = store.Complete(ctx, key, &infrastructure.IdempotencyRecord{
+					Fingerprint: fingerprint,
+					Status:      res.Status,
+					Header:      replayedHeader(res.Header()),
+					Body:        recorder.body.Bytes(),
+				}, ttl)
+			}
+			if err != nil {
+				log.Errorf("failed to store the response of the idempotency key: %s", err.Error())
+			}
+			return nil
+		}
+	}
+}
+
+// responseRecorder copies the body of the response written through it.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// replayedHeader returns the headers of a response which are replayed to the retries.
+func replayedHeader(header http.Header) http.Header {
+	replayed := http.Header{}
+	for _, name := range idempotentReplayedHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			replayed[name] = values
+		}
+	}
+	return replayed
+}
+
+// hasSecret judges whether a response must not be stored, by its Cache-Control and Set-Cookie headers.
+func hasSecret(header http.Header) bool {
+	if len(header.Values(echo.HeaderSetCookie)) > 0 {
+		return true
+	}
+	for _, directive := range strings.Split(header.Get(echo.HeaderCacheControl), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return true
+		}
+	}
+	return false
+}
+
+func hashIdempotencyKey(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
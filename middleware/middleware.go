@@ -1,26 +1,37 @@
 package middleware
 
 import (
+	"context"
 	"embed"
+	"errors"
 	"io"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
 	echomd "github.com/labstack/echo/v4/middleware"
+	"github.com/onetooler/bistory-backend/config"
 	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/logger"
 	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/util"
 	"github.com/valyala/fasttemplate"
+	"gorm.io/gorm"
 )
 
-var authorizationPathRegexps map[string]*regexp.Regexp
+const bearerPrefix = "Bearer "
 
 func Init(e *echo.Echo, container container.Container, staticFile embed.FS) {
+	InitValidationBinder(e)
 	InitCORSMiddleware(e, container)
+	InitTracingMiddleware(e, container)
 	InitLoggerMiddleware(e, container)
+	InitTimeoutMiddleware(e, container)
 	InitSessionMiddleware(e, container)
 	StaticContentsMiddleware(e, container, staticFile)
 }
@@ -33,14 +44,30 @@ func InitCORSMiddleware(e *echo.Echo, container container.Container) {
 			AllowOrigins:                             []string{"*"},
 			AllowHeaders: []string{
 				echo.HeaderAccessControlAllowHeaders,
+				echo.HeaderAuthorization,
 				echo.HeaderContentType,
 				echo.HeaderContentLength,
 				echo.HeaderAcceptEncoding,
+				echo.HeaderXRequestID,
+				config.HeaderIdempotencyKey,
+				config.HeaderIfMatch,
+				config.HeaderIfNoneMatch,
+				"traceparent",
+				"tracestate",
+			},
+			ExposeHeaders: []string{
+				echo.HeaderXRequestID,
+				config.HeaderIdempotentReplayed,
+				config.HeaderETag,
+				config.HeaderDeprecation,
+				config.HeaderSunset,
+				config.HeaderLink,
 			},
 			AllowMethods: []string{
 				http.MethodGet,
 				http.MethodPost,
 				http.MethodPut,
+				http.MethodPatch,
 				http.MethodDelete,
 			},
 			MaxAge: 86400,
@@ -50,20 +77,58 @@ func InitCORSMiddleware(e *echo.Echo, container container.Container) {
 
 // InitLoggerMiddleware initialize a middleware for logger.
 func InitLoggerMiddleware(e *echo.Echo, container container.Container) {
+	e.Use(RequestIdMiddleware())
 	e.Use(RequestLoggerMiddleware(container))
 	e.Use(ActionLoggerMiddleware(container))
 	e.Use(BodyLoggerMiddleware(container))
 }
 
+// InitTimeoutMiddleware initialize a middleware for the timeouts of the database work.
+func InitTimeoutMiddleware(e *echo.Echo, container container.Container) {
+	e.Use(QueryTimeoutMiddleware(container))
+}
+
 // InitSessionMiddleware initialize a middleware for session management.
 func InitSessionMiddleware(e *echo.Echo, container container.Container) {
 	e.Use(session.Middleware(container.GetSession().GetStore()))
 	e.Use(AuthenticationMiddleware(container))
 }
 
+// RequestIdMiddleware binds the id of the request to its context, so that the logs of the request can be correlated.
+// The id in the X-Request-ID header is accepted if it is valid, otherwise a new id is generated.
+// The id is returned in the X-Request-ID header of the response.
+func RequestIdMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			requestId := c.Request().Header.Get(echo.HeaderXRequestID)
+			if !isValidRequestId(requestId) {
+				requestId = util.RandomBase16String(config.RequestIdLength)
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, requestId)
+			c.SetRequest(c.Request().WithContext(logger.WithRequestId(c.Request().Context(), requestId)))
+			return next(c)
+		}
+	}
+}
+
+// isValidRequestId returns true if a given id is short and consists of the characters safe for the logs and headers.
+func isValidRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > config.RequestIdMaxLength {
+		return false
+	}
+	for _, r := range requestId {
+		isAlnum := r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+		if !isAlnum && !strings.ContainsRune("-_.:", r) {
+			return false
+		}
+	}
+	return true
+}
+
 // RequestLoggerMiddleware is middleware for logging the contents of requests.
 func RequestLoggerMiddleware(container container.Container) echo.MiddlewareFunc {
 	template := fasttemplate.New(container.GetConfig().Log.RequestLogFormat, "${", "}")
+	log := container.GetLogger().Component(config.LogComponentHTTP)
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -83,16 +148,18 @@ func RequestLoggerMiddleware(container container.Container) echo.MiddlewareFunc
 					}
 					return w.Write([]byte("None"))
 				case "uri":
-					return w.Write([]byte(req.RequestURI))
+					return w.Write([]byte(log.GetRedactor().URI(req.RequestURI)))
 				case "method":
 					return w.Write([]byte(req.Method))
 				case "status":
 					return w.Write([]byte(strconv.Itoa(res.Status)))
+				case "request_id":
+					return w.Write([]byte(logger.RequestIdFromContext(req.Context())))
 				default:
 					return w.Write([]byte(""))
 				}
 			})
-			container.GetLogger().GetZapLogger().Infof(logstr)
+			log.GetZapLoggerFromContext(req.Context()).Infof(logstr)
 			return nil
 		}
 	}
@@ -101,29 +168,33 @@ func RequestLoggerMiddleware(container container.Container) echo.MiddlewareFunc
 // ActionLoggerMiddleware is middleware for logging the start and end of controller processes.
 // ref: https://echo.labstack.com/cookbook/middleware
 func ActionLoggerMiddleware(container container.Container) echo.MiddlewareFunc {
+	log := container.GetLogger().Component(config.LogComponentHTTP)
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			logger := container.GetLogger()
-			logger.GetZapLogger().Debugf("%s Action Start", c.Path())
+			logger := log.GetZapLoggerFromContext(c.Request().Context())
+			logger.Debugf("%s Action Start", c.Path())
 			if err := next(c); err != nil {
 				c.Error(err)
 			}
-			logger.GetZapLogger().Debugf("%s Action End", c.Path())
+			logger.Debugf("%s Action End", c.Path())
 			return nil
 		}
 	}
 }
 
 func BodyLoggerMiddleware(container container.Container) echo.MiddlewareFunc {
+	log := container.GetLogger().Component(config.LogComponentHTTP)
 	return echomd.BodyDumpWithConfig(
 		echomd.BodyDumpConfig{
 			Skipper: func(c echo.Context) bool {
 				return strings.Contains(c.Request().URL.Path, "swagger")
 			},
 			Handler: func(c echo.Context, reqBody []byte, resBody []byte) {
-				logger := container.GetLogger()
-				logger.GetZapLogger().Debugf("%s request body: %s", c.Path(), reqBody)
-				logger.GetZapLogger().Debugf("%s response body: %s", c.Path(), resBody)
+				redactor := log.GetRedactor()
+				logger := log.GetZapLoggerFromContext(c.Request().Context())
+				logger.Debugf("%s request headers: %v", c.Path(), redactor.Header(c.Request().Header))
+				logger.Debugf("%s request body: %s", c.Path(), redactor.Body(reqBody))
+				logger.Debugf("%s response body: %s", c.Path(), redactor.Body(resBody))
 			},
 		},
 	)
@@ -141,8 +212,9 @@ func StaticContentsMiddleware(e *echo.Echo, container container.Container, stati
 			Filesystem: http.FS(staticFile),
 		}
 		if conf.Swagger.Enabled {
+			swaggerPath := regexp.MustCompile(conf.Swagger.Path)
 			staticConfig.Skipper = func(c echo.Context) bool {
-				return equalPath(c.Path(), []string{conf.Swagger.Path})
+				return swaggerPath.MatchString(c.Path())
 			}
 		}
 		e.Use(echomd.StaticWithConfig(staticConfig))
@@ -150,27 +222,65 @@ func StaticContentsMiddleware(e *echo.Echo, container container.Container, stati
 	}
 }
 
-// AuthenticationMiddleware is the middleware of session authentication for echo.
-func AuthenticationMiddleware(container container.Container) echo.MiddlewareFunc {
-	// pre-compile all paths
-	authorizationPathRegexps = make(map[string]*regexp.Regexp)
-	for _, path := range container.GetConfig().Security.AuthPath {
-		authorizationPathRegexps[path] = regexp.MustCompile(path)
-	}
-	for _, path := range container.GetConfig().Security.ExcludePath {
-		authorizationPathRegexps[path] = regexp.MustCompile(path)
-	}
-	for _, path := range container.GetConfig().Security.AdminPath {
-		authorizationPathRegexps[path] = regexp.MustCompile(path)
-	}
-	for _, path := range container.GetConfig().Security.UserPath {
-		authorizationPathRegexps[path] = regexp.MustCompile(path)
+// QueryTimeoutMiddleware sets the deadline of the database work to the context of the request.
+// It only cancels the repositories given the context, and the other work such as reading the body or the storage is not limited.
+// The timeout of the route is looked up by "METHOD /path" in Database.RouteTimeouts, otherwise Database.QueryTimeout is used.
+// The path may omit the API version, such as /api/account for all versions of /api/v1/account.
+// A negative timeout disables the deadline.
+func QueryTimeoutMiddleware(container container.Container) echo.MiddlewareFunc {
+	conf := container.GetConfig().Database
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			timeout, ok := conf.RouteTimeouts[c.Request().Method+" "+c.Path()]
+			if !ok {
+				timeout, ok = conf.RouteTimeouts[c.Request().Method+" "+unversionedPath(c.Path())]
+			}
+			if !ok {
+				timeout = conf.QueryTimeout
+			}
+			if timeout == 0 {
+				timeout = config.DefaultQueryTimeout
+			}
+			if timeout < 0 {
+				return next(c)
+			}
+
+			ctx, cancel := infrastructure.WithQueryTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
 	}
+}
 
+// AuthenticationMiddleware is the middleware of authentication for echo.
+// It binds the account of the access token in the Authorization header to the request.
+// Otherwise, it binds the account of the session reloaded from the database, and logs out the session
+// whose account has been deleted or locked.
+// The authorization is done by the policies declared at the registration of routes.
+func AuthenticationMiddleware(container container.Container) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			if !hasAuthorization(c, container) {
-				return c.JSON(http.StatusForbidden, false)
+			if plain := bearerToken(c); plain != "" {
+				account := authenticateAccessToken(c.Request().Context(), container, plain)
+				if account == nil {
+					return c.JSON(http.StatusUnauthorized, false)
+				}
+				container.GetSession().SetRequestAccount(c, account)
+			} else if account := container.GetSession().GetAccount(c); account != nil {
+				current, err := authenticateSession(c.Request().Context(), container, account)
+				if err != nil {
+					c.Error(echo.NewHTTPError(http.StatusServiceUnavailable, "the database is unavailable"))
+					return nil
+				}
+				if current == nil {
+					if err := container.GetSession().Logout(c); err != nil {
+						c.Error(err)
+						return nil
+					}
+				} else {
+					container.GetSession().SetRequestAccount(c, current)
+				}
 			}
 			if err := next(c); err != nil {
 				c.Error(err)
@@ -180,38 +290,68 @@ func AuthenticationMiddleware(container container.Container) echo.MiddlewareFunc
 	}
 }
 
-// hasAuthorization judges whether the user has the right to access the path.
-func hasAuthorization(c echo.Context, container container.Container) bool {
-	currentPath := c.Path()
-	if !equalPath(currentPath, container.GetConfig().Security.AuthPath) {
-		return true
+// authenticateSession reloads the account of the session, so the changes of its roles and status take effect
+// on the sessions already logged in. It returns nil if the account has been deleted or is not active.
+func authenticateSession(ctx context.Context, container container.Container, account *infrastructure.Account) (*infrastructure.Account, error) {
+	current := model.Account{}
+	err := container.GetRepository().WithContext(ctx).Preload("Roles.Permissions").First(&current, account.Id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
-	if equalPath(currentPath, container.GetConfig().Security.ExcludePath) {
-		return true
+	if !current.IsActive() {
+		return nil, nil
 	}
 
-	account := container.GetSession().GetAccount(c)
-	if account == nil {
-		return false
+	return &infrastructure.Account{
+		Id:                 current.ID,
+		LoginId:            current.LoginId,
+		LoginTime:          account.LoginTime,
+		Roles:              current.RoleNames(),
+		Permissions:        current.Permissions(),
+		MustChangePassword: current.MustChangePassword,
+	}, nil
+}
+
+// bearerToken returns the access token in the Authorization header.
+func bearerToken(c echo.Context) string {
+	auth := c.Request().Header.Get(echo.HeaderAuthorization)
+	if len(auth) > len(bearerPrefix) && strings.EqualFold(auth[:len(bearerPrefix)], bearerPrefix) {
+		return strings.TrimSpace(auth[len(bearerPrefix):])
 	}
-	if account.Authority == uint(model.AuthorityAdmin) && equalPath(currentPath, container.GetConfig().Security.AdminPath) {
-		_ = container.GetSession().Save(c)
-		return true
+	return ""
+}
+
+// authenticateAccessToken finds the account of a given access token and records its usage.
+// It returns nil if the token is unknown, expired or its account is not active.
+func authenticateAccessToken(ctx context.Context, container container.Container, plain string) *infrastructure.Account {
+	repo := container.GetRepository().WithContext(ctx)
+	now := time.Now()
+
+	token := model.AccessToken{}
+	if err := repo.Where(&model.AccessToken{TokenHash: model.HashAccessToken(plain)}).First(&token).Error; err != nil {
+		return nil
 	}
-	if account.Authority <= uint(model.AuthorityUser) && equalPath(currentPath, container.GetConfig().Security.UserPath) {
-		_ = container.GetSession().Save(c)
-		return true
+	if token.IsExpired(now) {
+		return nil
+	}
+	account := model.Account{}
+	if err := repo.Preload("Roles.Permissions").First(&account, token.AccountID).Error; err != nil || !account.IsActive() {
+		return nil
+	}
+	if err := repo.Model(&token).UpdateColumn("last_used_at", now).Error; err != nil {
+		container.GetLogger().GetZapLogger().Warnf("failed to record the usage of access token %d: %s", token.ID, err.Error())
 	}
 
-	return false
-}
-
-// equalPath judges whether a given path contains in the path list.
-func equalPath(cpath string, paths []string) bool {
-	for _, path := range paths {
-		if authorizationPathRegexps[path].Match([]byte(cpath)) {
-			return true
-		}
+	return &infrastructure.Account{
+		Id:            account.ID,
+		LoginId:       account.LoginId,
+		LoginTime:     now,
+		Roles:         account.RoleNames(),
+		Permissions:   account.Permissions(),
+		AccessTokenId: token.ID,
+		Scopes:        token.Scopes,
 	}
-	return false
 }
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/model/dto"
+)
+
+// InitValidationBinder replaces the binder of echo by ValidationBinder.
+func InitValidationBinder(e *echo.Echo) {
+	e.Binder = &ValidationBinder{}
+}
+
+// ValidationBinder binds the request as echo.DefaultBinder, and validates the DTO which implements dto.Validatable.
+// It returns dto.ValidationErrors with all the field errors, so the controllers respond them at once.
+type ValidationBinder struct {
+	echo.DefaultBinder
+}
+
+func (b *ValidationBinder) Bind(i interface{}, c echo.Context) error {
+	if err := b.DefaultBinder.Bind(i, c); err != nil {
+		return err
+	}
+	if v, ok := i.(dto.Validatable); ok {
+		if errs := v.Validate(); errs != nil {
+			return errs
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+)
+
+// DeprecationMiddleware announces the deprecation of an API version to the clients,
+// by the Deprecation header of RFC 9745 and the Sunset header of RFC 8594.
+// The headers of the zero times are omitted.
+func DeprecationMiddleware(version config.APIVersion) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Response().Header()
+			if !version.Deprecation.IsZero() {
+				header.Set(config.HeaderDeprecation, fmt.Sprintf("@%d", version.Deprecation.Unix()))
+			}
+			if !version.Sunset.IsZero() {
+				header.Set(config.HeaderSunset, version.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if version.Link != "" {
+				header.Add(config.HeaderLink, fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, version.Link))
+			}
+			return next(c)
+		}
+	}
+}
+
+// unversionedPath returns the path of a route without the API version, such as /api/account for /api/v1/account.
+func unversionedPath(path string) string {
+	for _, version := range config.APIVersions {
+		if prefix := config.API + "/" + version; path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return config.API + strings.TrimPrefix(path, prefix)
+		}
+	}
+	return path
+}
@@ -1,8 +1,11 @@
 package migration
 
 import (
+	"fmt"
+
 	"github.com/onetooler/bistory-backend/container"
 	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/logger"
 	"github.com/onetooler/bistory-backend/model"
 )
 
@@ -10,17 +13,162 @@ func Init(container container.Container) {
 	if container.GetConfig().Database.Migration {
 		createDatabase(container.GetRepository())
 	}
-	if container.GetConfig().Extension.MasterGenerator {
-		createMasterData(container.GetRepository())
+	if err := Up(container); err != nil {
+		container.GetLogger().GetZapLogger().Errorf("Failed to migrate the schema: %s", err.Error())
+	}
+}
+
+// models are the domain models stored in the database, in the order of their creation.
+var models = []interface{}{&model.Account{}, &model.AccessToken{}, &model.Role{}, &model.RolePermission{}, &model.AccountRole{}, &model.DataExport{}, &model.Job{}, &model.JobRun{}, &model.SetupToken{}, &model.IdempotencyKey{}}
+
+// Up adds the missing tables and columns, seeds the default roles and migrates the legacy data.
+// It is idempotent, so it runs on every boot.
+func Up(container container.Container) error {
+	if err := migrateSchema(container.GetRepository()); err != nil {
+		return err
+	}
+	initRoles(container.GetRepository(), container.GetLogger())
+	anonymiseDeletedAccounts(container.GetRepository(), container.GetLogger())
+	return nil
+}
+
+// Down drops all tables of the domain models with their data.
+func Down(container container.Container) error {
+	for i := len(models) - 1; i >= 0; i-- {
+		if err := container.GetRepository().DropTableIfExists(models[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TableStatus represents whether the table of a domain model is up to date.
+type TableStatus struct {
+	Table          string
+	Exists         bool
+	MissingColumns []string
+}
+
+// Status returns the tables of the domain models and their columns which Up will add.
+func Status(container container.Container) ([]TableStatus, error) {
+	result := make([]TableStatus, 0, len(models))
+	for _, value := range models {
+		db := container.GetRepository().Model(value)
+		if err := db.Statement.Parse(value); err != nil {
+			return nil, err
+		}
+		status := TableStatus{Table: db.Statement.Schema.Table, Exists: db.Migrator().HasTable(value)}
+		for _, field := range db.Statement.Schema.Fields {
+			if field.DBName == "" || !status.Exists {
+				continue
+			}
+			if !db.Migrator().HasColumn(value, field.DBName) {
+				status.MissingColumns = append(status.MissingColumns, field.DBName)
+			}
+		}
+		result = append(result, status)
+	}
+	return result, nil
+}
+
+// migrateSchema adds the missing tables and columns. It never drops anything.
+func migrateSchema(db infrastructure.Repository) error {
+	for _, value := range models {
+		if err := db.AutoMigrate(value); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 func createDatabase(db infrastructure.Repository) {
-	_ = db.DropTableIfExists(&model.Account{})
-	_ = db.AutoMigrate(&model.Account{})
+	for _, value := range models {
+		_ = db.DropTableIfExists(value)
+	}
+	for _, value := range models {
+		_ = db.AutoMigrate(value)
+	}
+}
+
+// initRoles seeds the default roles and assigns a role to the accounts which have none by their legacy authority.
+// It is idempotent, so it runs on every boot.
+func initRoles(db infrastructure.Repository, logger logger.Logger) {
+	roles := seedRoles(db, logger)
+	migrateAuthorities(db, logger, roles)
 }
 
-func createMasterData(db infrastructure.Repository) {
-	adminAccount, _ := model.NewAccountWithPasswordEncrypt("test", "test@example.com", "test", model.AuthorityAdmin)
-	db.Create(adminAccount)
+// seedRoles creates the default roles and their missing permissions. The permissions added by hand are kept.
+func seedRoles(db infrastructure.Repository, logger logger.Logger) map[string]uint {
+	roles := make(map[string]uint)
+	for _, defaultRole := range model.DefaultRoles() {
+		role := model.Role{}
+		if err := db.Where(&model.Role{Name: defaultRole.Name}).Preload("Permissions").Find(&role).Error; err != nil {
+			logger.GetZapLogger().Errorf("Failed to find the role %s: %s", defaultRole.Name, err.Error())
+			continue
+		}
+		if role.ID == 0 {
+			role = model.Role{Name: defaultRole.Name, Description: defaultRole.Description}
+			if err := db.Create(&role).Error; err != nil {
+				logger.GetZapLogger().Errorf("Failed to create the role %s: %s", defaultRole.Name, err.Error())
+				continue
+			}
+		}
+		for _, p := range defaultRole.Permissions {
+			if role.HasPermission(p.Permission) {
+				continue
+			}
+			if err := db.Create(&model.RolePermission{RoleID: role.ID, Permission: p.Permission}).Error; err != nil {
+				logger.GetZapLogger().Errorf("Failed to grant %s to the role %s: %s", p.Permission, role.Name, err.Error())
+			}
+		}
+		roles[role.Name] = role.ID
+	}
+	return roles
+}
+
+// migrateAuthorities assigns the role which replaces the legacy authority to the accounts without any role.
+func migrateAuthorities(db infrastructure.Repository, logger logger.Logger, roles map[string]uint) {
+	accounts := []model.Account{}
+	tx := db.Where("NOT EXISTS (SELECT 1 FROM account_role WHERE account_role.account_id = account.id)").Find(&accounts)
+	if tx.Error != nil {
+		logger.GetZapLogger().Errorf("Failed to find the accounts without role: %s", tx.Error.Error())
+		return
+	}
+	for _, account := range accounts {
+		roleId, ok := roles[account.Authority.RoleName()]
+		if !ok {
+			continue
+		}
+		if err := db.Create(&model.AccountRole{AccountID: account.ID, RoleID: roleId}).Error; err != nil {
+			logger.GetZapLogger().Errorf("Failed to assign the role to the account %d: %s", account.ID, err.Error())
+		}
+	}
+	if len(accounts) > 0 {
+		logger.GetZapLogger().Infof("Assigned the roles to %d accounts by their authority", len(accounts))
+	}
+}
+
+// anonymiseDeletedAccounts anonymises the accounts soft-deleted before the deletion lifecycle,
+// so that their loginId and email can be registered again.
+func anonymiseDeletedAccounts(db infrastructure.Repository, logger logger.Logger) {
+	accounts := []model.Account{}
+	tx := db.Where("deleted_at IS NOT NULL AND status <> ?", model.StatusPurged).Unscoped().Find(&accounts)
+	if tx.Error != nil {
+		logger.GetZapLogger().Errorf("Failed to find the deleted accounts: %s", tx.Error.Error())
+		return
+	}
+	for _, account := range accounts {
+		tx := db.Model(&model.Account{}).Where("id = ?", account.ID).Unscoped().Updates(map[string]interface{}{
+			"login_id": model.PurgedLoginId(account.ID),
+			"email":    fmt.Sprintf("deleted-%d@deleted.invalid", account.ID),
+			"password": "",
+			"status":   model.StatusPurged,
+		})
+		if tx.Error != nil {
+			logger.GetZapLogger().Errorf("Failed to anonymise the deleted account %d: %s", account.ID, tx.Error.Error())
+		}
+	}
+	if len(accounts) > 0 {
+		logger.GetZapLogger().Infof("Anonymised %d deleted accounts", len(accounts))
+	}
 }
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/util"
+	"gorm.io/gorm"
+)
+
+// AccessToken defines struct of personal access token data.
+// The plain token is never stored, only its SHA-256 hash.
+type AccessToken struct {
+	gorm.Model
+	AccountID  uint       `gorm:"index;not null" json:"accountId"`
+	Name       string     `gorm:"not null" json:"name"`
+	TokenHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	Prefix     string     `json:"prefix"`
+	Scopes     Scopes     `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+// Constant about scopes which an access token can hold.
+const (
+	ScopeAccountRead  = "account:read"
+	ScopeAccountWrite = "account:write"
+	ScopeTokenRead    = "token:read"
+	ScopeTokenWrite   = "token:write"
+)
+
+// AllScopes is the list of the scopes which can be granted to an access token.
+var AllScopes = []string{ScopeAccountRead, ScopeAccountWrite, ScopeTokenRead, ScopeTokenWrite}
+
+// Scopes is a list of scopes. It is stored as a space separated string.
+type Scopes []string
+
+// NewAccessToken is constructor. It returns the token and its plain value which is shown only once.
+func NewAccessToken(accountId uint, name string, scopes []string, expiresAt *time.Time) (*AccessToken, string) {
+	plain := config.AccessTokenPrefix + util.RandomBase16String(config.AccessTokenLength)
+	return &AccessToken{
+		AccountID: accountId,
+		Name:      name,
+		TokenHash: HashAccessToken(plain),
+		Prefix:    plain[:len(config.AccessTokenPrefix)+4],
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}, plain
+}
+
+// HashAccessToken returns the hash of a plain access token for storing and lookup.
+func HashAccessToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// TableName returns the table name of access token struct and it is used by gorm.
+func (AccessToken) TableName() string {
+	return "access_token"
+}
+
+// ToString is return string of object
+func (t *AccessToken) ToString() string {
+	return toString(t)
+}
+
+// IsExpired judges whether the token has been expired at the given time.
+func (t *AccessToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// IsValidScope judges whether a given scope is defined.
+func IsValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains judges whether the scopes contain a given scope.
+func (s Scopes) Contains(scope string) bool {
+	for _, v := range s {
+		if v == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements driver.Valuer.
+func (s Scopes) Value() (driver.Value, error) {
+	return strings.Join(s, " "), nil
+}
+
+// Scan implements sql.Scanner.
+func (s *Scopes) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*s = strings.Fields(v)
+	case []byte:
+		*s = strings.Fields(string(v))
+	case nil:
+		*s = nil
+	default:
+		return fmt.Errorf("unsupported type for scopes: %T", value)
+	}
+	return nil
+}
+
+// GormDataType returns the data type of scopes for gorm.
+func (Scopes) GormDataType() string {
+	return "string"
+}
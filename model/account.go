@@ -1,7 +1,14 @@
 package model
 
 import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
 	"github.com/onetooler/bistory-backend/config"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -9,14 +16,36 @@ import (
 // Account defines struct of account data.
 type Account struct {
 	gorm.Model
-	LoginId    string    `gorm:"unique;not null" json:"loginId"`
-	Email      string    `gorm:"unique;not null" json:"email"`
-	Password   string    `json:"-"`
-	Authority  Authority `json:"authority"`
-	Status     Status    `json:"status"`
-	BadAttempt uint      `json:"badAttempt"`
+	LoginId   string    `gorm:"unique;not null" json:"loginId"`
+	Email     string    `gorm:"unique;not null" json:"email"`
+	Password  string    `json:"-"`
+	Authority Authority `json:"authority"`
+	Status    Status    `json:"status"`
+	// BadAttempt is changed by every login without incrementing Version, so it is not a part of the representation.
+	BadAttempt uint   `json:"-"`
+	Roles      []Role `gorm:"many2many:account_role" json:"roles,omitempty"`
+	// MustChangePassword is set on the bootstrapped admin, whose password was in the configuration.
+	// The account can do nothing but change the password until it is cleared.
+	MustChangePassword bool `json:"mustChangePassword"`
+
+	// Profile
+	DisplayName string `json:"displayName"`
+	Locale      string `json:"locale"`
+	TimeZone    string `json:"timeZone"`
+	Bio         string `json:"bio"`
+	// Avatar is the storage key prefix of the avatar thumbnails. It is empty if no avatar is uploaded.
+	Avatar string `json:"-"`
+
+	// DeletionScheduledAt is the time when the account pending deletion is purged.
+	DeletionScheduledAt *time.Time `json:"deletionScheduledAt,omitempty"`
+	RestoreTokenHash    string     `json:"-"`
+
+	// Version is incremented on every update for optimistic concurrency control.
+	Version uint `gorm:"not null;default:1" json:"version"`
 }
 
+// Authority is the legacy authorization level of account.
+// Deprecated: authorization is decided by the roles of account. It is kept to assign the initial role.
 type Authority uint
 
 const (
@@ -40,6 +69,10 @@ type Status uint
 const (
 	StatusActive Status = iota + 1
 	StatusInactive
+	// StatusPendingDeletion is the account deleted by its owner. It can be restored until it is purged.
+	StatusPendingDeletion
+	// StatusPurged is the account anonymised after the deletion grace period.
+	StatusPurged
 )
 
 func (s Status) String() string {
@@ -48,18 +81,36 @@ func (s Status) String() string {
 		return "Active"
 	case StatusInactive:
 		return "Inactive"
+	case StatusPendingDeletion:
+		return "PendingDeletion"
+	case StatusPurged:
+		return "Purged"
 	default:
 		return "Invalid Status"
 	}
 }
 
 // NewAccountWithPasswordEncrypt is constructor. And it is encoded password by using bcrypt.
-func NewAccountWithPasswordEncrypt(loginId, email, plainPassword string, authority Authority) (*Account, error) {
-	hashed, err := bcrypt.GenerateFromPassword([]byte(plainPassword), config.PasswordHashCost)
+func NewAccountWithPasswordEncrypt(ctx context.Context, loginId, email, plainPassword string, authority Authority) (*Account, error) {
+	hashed, err := HashPassword(ctx, plainPassword)
 	if err != nil {
 		return nil, err
 	}
-	return &Account{LoginId: loginId, Email: email, Password: string(hashed), Authority: authority, Status: StatusActive}, nil
+	return &Account{LoginId: loginId, Email: email, Password: hashed, Authority: authority, Status: StatusActive, Version: 1}, nil
+}
+
+// HashPassword returns the bcrypt hash of a plain password for storing.
+// It is traced as a span, since the hashing is deliberately slow.
+func HashPassword(ctx context.Context, plainPassword string) (string, error) {
+	_, span := otel.Tracer(config.TracerName).Start(ctx, "bcrypt.hash")
+	defer span.End()
+	hashed, err := bcrypt.GenerateFromPassword([]byte(plainPassword), config.PasswordHashCost)
+	return string(hashed), err
+}
+
+// HashRestoreToken returns the hash of a plain restore token for storing and lookup.
+func HashRestoreToken(plain string) string {
+	return HashAccessToken(plain)
 }
 
 // TableName returns the table name of account struct and it is used by gorm.
@@ -72,22 +123,72 @@ func (a *Account) ToString() string {
 	return toString(a)
 }
 
-func (a *Account) CheckPassword(plainPassword string) bool {
-	if err := bcrypt.CompareHashAndPassword([]byte(a.Password), []byte(plainPassword)); err != nil {
-		a.BadAttempt++
-		if a.RemainAttempt() <= 0 {
-			a.Status = StatusInactive
+// CheckPassword compares a plain password with the hash. The bad attempts are counted by the auth service.
+// It is traced as a span, since the hashing is deliberately slow.
+func (a *Account) CheckPassword(ctx context.Context, plainPassword string) bool {
+	_, span := otel.Tracer(config.TracerName).Start(ctx, "bcrypt.compare")
+	defer span.End()
+	return bcrypt.CompareHashAndPassword([]byte(a.Password), []byte(plainPassword)) == nil
+}
+
+// ValidateLoginId checks the rules of the login id of a new account.
+// It consists of the letters, digits, '.', '_' and '-', and starts with a letter or digit.
+func ValidateLoginId(loginId string) error {
+	if loginId == "" {
+		return fmt.Errorf("loginId is required")
+	}
+	if len(loginId) > config.LoginIdMaxLength {
+		return fmt.Errorf("loginId must be at most %d characters", config.LoginIdMaxLength)
+	}
+	for i, r := range loginId {
+		isAlnum := r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+		if !isAlnum && (i == 0 || !strings.ContainsRune("._-", r)) {
+			return fmt.Errorf("loginId must consist of letters, digits, '.', '_' and '-', and start with a letter or digit")
 		}
-		return false
 	}
-	a.BadAttempt = 0
-	return true
+	return nil
+}
+
+// ValidatePassword checks the rules of a new password. bcrypt ignores the bytes after the maximum length.
+func ValidatePassword(password string) error {
+	if len(password) < config.PasswordMinLength {
+		return fmt.Errorf("password must be at least %d characters", config.PasswordMinLength)
+	}
+	if len(password) > config.PasswordMaxLength {
+		return fmt.Errorf("password must be at most %d characters", config.PasswordMaxLength)
+	}
+	return nil
+}
+
+// ValidateEmail checks that a given value is a plain email address, without the display name.
+func ValidateEmail(email string) error {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > config.EmailMaxLength {
+		return fmt.Errorf("email %s is not valid", email)
+	}
+	return nil
+}
+
+// PurgedLoginId returns the login id which a purged account is anonymised with.
+// It starts with '#', so it is rejected by ValidateLoginId and no account can take it before the purge.
+func PurgedLoginId(id uint) string {
+	return fmt.Sprintf("#deleted-%d", id)
+}
+
+// ETag returns the entity tag of the account, which changes whenever its version is incremented.
+// It is a strong tag, because every change of the representation increments the version.
+func (a *Account) ETag() string {
+	return fmt.Sprintf(`"account-%d-%d"`, a.ID, a.Version)
 }
 
 func (a *Account) IsActive() bool {
 	return a.Status == StatusActive
 }
 
+func (a *Account) IsPendingDeletion() bool {
+	return a.Status == StatusPendingDeletion
+}
+
 func (a *Account) RemainAttempt() int {
 	return config.MaxLoginAttempts - int(a.BadAttempt)
 }
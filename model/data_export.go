@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DataExport defines struct of the export of all personal data of an account.
+type DataExport struct {
+	gorm.Model
+	AccountID uint         `gorm:"index;not null" json:"accountId"`
+	Status    ExportStatus `json:"status"`
+	// FileKey is the storage key of the archive. It is empty until the export is ready.
+	FileKey     string     `json:"-"`
+	Size        int64      `json:"size"`
+	CompletedAt *time.Time `json:"completedAt"`
+	// ExpiresAt is the time when the archive is deleted.
+	ExpiresAt *time.Time `json:"expiresAt"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type ExportStatus uint
+
+const (
+	ExportStatusPending ExportStatus = iota + 1
+	ExportStatusReady
+	ExportStatusFailed
+	ExportStatusExpired
+)
+
+func (s ExportStatus) String() string {
+	switch s {
+	case ExportStatusPending:
+		return "Pending"
+	case ExportStatusReady:
+		return "Ready"
+	case ExportStatusFailed:
+		return "Failed"
+	case ExportStatusExpired:
+		return "Expired"
+	default:
+		return "Invalid Status"
+	}
+}
+
+// IsDownloadable returns whether the archive of the export can be downloaded at a given time.
+func (e *DataExport) IsDownloadable(now time.Time) bool {
+	return e.Status == ExportStatusReady && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
+
+// TableName returns the table name of data export struct and it is used by gorm.
+func (DataExport) TableName() string {
+	return "data_export"
+}
+
+// ToString is return string of object
+func (e *DataExport) ToString() string {
+	return toString(e)
+}
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/model"
+)
+
+type CreateAccessTokenDto struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+func NewCreateAccessTokenDto() *CreateAccessTokenDto {
+	return &CreateAccessTokenDto{}
+}
+
+func (l *CreateAccessTokenDto) ToString() (string, error) {
+	bytes, err := json.Marshal(l)
+	return string(bytes), err
+}
+
+func (l *CreateAccessTokenDto) Validate() ValidationErrors {
+	v := validation{}
+	if v.required("name", l.Name) {
+		v.maxLength("name", l.Name, config.AccessTokenNameMaxLength)
+	}
+	if len(l.Scopes) == 0 {
+		v.add("scopes", "at least one scope is required")
+	}
+	for _, scope := range l.Scopes {
+		if !model.IsValidScope(scope) {
+			v.add("scopes", fmt.Sprintf("scope %s is not valid", scope))
+		}
+	}
+	if l.ExpiresAt != nil && !l.ExpiresAt.After(time.Now()) {
+		v.add("expiresAt", "expiresAt must be in the future")
+	}
+	return v.result()
+}
+
+// CreatedAccessTokenDto is the response of creating an access token.
+// Token is the plain value and it can not be fetched again.
+type CreatedAccessTokenDto struct {
+	*model.AccessToken
+	Token string `json:"token"`
+}
@@ -1,6 +1,11 @@
 package dto
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/onetooler/bistory-backend/config"
+)
 
 type CreateAccountDto struct {
 	LoginId  string `json:"loginId"`
@@ -17,6 +22,14 @@ func (l *CreateAccountDto) ToString() (string, error) {
 	return string(bytes), err
 }
 
+func (l *CreateAccountDto) Validate() ValidationErrors {
+	v := validation{}
+	v.loginId("loginId", l.LoginId)
+	v.email("email", l.Email)
+	v.password("password", l.Password)
+	return v.result()
+}
+
 type LoginDto struct {
 	LoginId  string `json:"loginId"`
 	Email    string `json:"email"`
@@ -32,9 +45,45 @@ func (l *LoginDto) ToString() (string, error) {
 	return string(bytes), err
 }
 
+// Validate checks only the presence, since the accounts created before the rules must be able to log in.
+func (l *LoginDto) Validate() ValidationErrors {
+	v := validation{}
+	v.required("loginId", l.LoginId)
+	v.required("password", l.Password)
+	return v.result()
+}
+
+// SetupAdminDto creates the first admin account with the setup token printed to the log.
+type SetupAdminDto struct {
+	Token    string `json:"token"`
+	LoginId  string `json:"loginId"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func NewSetupAdminDto() *SetupAdminDto {
+	return &SetupAdminDto{}
+}
+
+func (l *SetupAdminDto) ToString() (string, error) {
+	bytes, err := json.Marshal(l)
+	return string(bytes), err
+}
+
+func (l *SetupAdminDto) Validate() ValidationErrors {
+	v := validation{}
+	v.required("token", l.Token)
+	v.loginId("loginId", l.LoginId)
+	v.email("email", l.Email)
+	v.password("password", l.Password)
+	return v.result()
+}
+
 type ChangeAccountPasswordDto struct {
 	OldPassword string `json:"oldPassword"`
 	NewPassword string `json:"NewPassword"`
+	// Version is the version of the account matched by If-Match, or zero to change it at any version.
+	Version uint `json:"-"`
 }
 
 func NewChangeAccountPasswordDto() *ChangeAccountPasswordDto {
@@ -46,14 +95,29 @@ func (l *ChangeAccountPasswordDto) ToString() (string, error) {
 	return string(bytes), err
 }
 
+func (l *ChangeAccountPasswordDto) Validate() ValidationErrors {
+	v := validation{}
+	v.required("oldPassword", l.OldPassword)
+	v.password("NewPassword", l.NewPassword)
+	return v.result()
+}
+
 type DeleteAccountDto struct {
 	Password string `json:"password"`
+	// Version is the version of the account matched by If-Match, or zero to delete it at any version.
+	Version uint `json:"-"`
 }
 
 func NewDeleteAccountDto() *DeleteAccountDto {
 	return &DeleteAccountDto{}
 }
 
+func (l *DeleteAccountDto) Validate() ValidationErrors {
+	v := validation{}
+	v.required("password", l.Password)
+	return v.result()
+}
+
 type FindLoginIdDto struct {
 	Email string `json:"email"`
 }
@@ -62,6 +126,12 @@ func NewFindLoginIdDto() *FindLoginIdDto {
 	return &FindLoginIdDto{}
 }
 
+func (l *FindLoginIdDto) Validate() ValidationErrors {
+	v := validation{}
+	v.email("email", l.Email)
+	return v.result()
+}
+
 type EmailVerificationTokenSendDto struct {
 	Email string `json:"email"`
 }
@@ -70,6 +140,12 @@ func NewEmailVerificationTokenSendDto() *EmailVerificationTokenSendDto {
 	return &EmailVerificationTokenSendDto{}
 }
 
+func (l *EmailVerificationTokenSendDto) Validate() ValidationErrors {
+	v := validation{}
+	v.email("email", l.Email)
+	return v.result()
+}
+
 type EmailVerificationTokenVerifyDto struct {
 	Token string `json:"token"`
 }
@@ -77,3 +153,58 @@ type EmailVerificationTokenVerifyDto struct {
 func NewEmailVerificationTokenVerifyDto() *EmailVerificationTokenVerifyDto {
 	return &EmailVerificationTokenVerifyDto{}
 }
+
+func (l *EmailVerificationTokenVerifyDto) Validate() ValidationErrors {
+	v := validation{}
+	v.required("token", l.Token)
+	return v.result()
+}
+
+// UpdateAccountProfileDto has the profile fields to update. A nil field is left unchanged.
+// Version must be the version of the account which the client has read.
+type UpdateAccountProfileDto struct {
+	DisplayName *string `json:"displayName"`
+	Locale      *string `json:"locale"`
+	TimeZone    *string `json:"timeZone"`
+	Bio         *string `json:"bio"`
+	Version     uint    `json:"version"`
+}
+
+func NewUpdateAccountProfileDto() *UpdateAccountProfileDto {
+	return &UpdateAccountProfileDto{}
+}
+
+func (l *UpdateAccountProfileDto) ToString() (string, error) {
+	bytes, err := json.Marshal(l)
+	return string(bytes), err
+}
+
+// Validate checks the presence and the lengths. The locale and the time zone are parsed by the account service,
+// and the version is checked by it, since it may be given by the If-Match header instead.
+func (l *UpdateAccountProfileDto) Validate() ValidationErrors {
+	v := validation{}
+	if l.DisplayName == nil && l.Locale == nil && l.TimeZone == nil && l.Bio == nil {
+		v.add("", "no profile field to update")
+	}
+	if l.DisplayName != nil {
+		v.maxLength("displayName", strings.TrimSpace(*l.DisplayName), config.DisplayNameMaxLength)
+	}
+	if l.Bio != nil {
+		v.maxLength("bio", *l.Bio, config.BioMaxLength)
+	}
+	return v.result()
+}
+
+type RestoreAccountDto struct {
+	Token string `json:"token"`
+}
+
+func NewRestoreAccountDto() *RestoreAccountDto {
+	return &RestoreAccountDto{}
+}
+
+func (l *RestoreAccountDto) Validate() ValidationErrors {
+	v := validation{}
+	v.required("token", l.Token)
+	return v.result()
+}
@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+// AvatarDto has the signed URLs of the avatar thumbnails. The URLs expire at ExpiresAt.
+type AvatarDto struct {
+	Large     string    `json:"large"`
+	Small     string    `json:"small"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+import "time"
+
+// ExportDownloadDto has the time-limited URL to download the archive of a data export.
+type ExportDownloadDto struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// UpdateLogLevelDto changes the log level of a component.
+// RevertAfter is a duration such as "15m", after which the previous level is restored. It is permanent if empty.
+type UpdateLogLevelDto struct {
+	Level       string `json:"level"`
+	RevertAfter string `json:"revertAfter"`
+}
+
+func NewUpdateLogLevelDto() *UpdateLogLevelDto {
+	return &UpdateLogLevelDto{}
+}
+
+func (l *UpdateLogLevelDto) ToString() (string, error) {
+	bytes, err := json.Marshal(l)
+	return string(bytes), err
+}
+
+func (l *UpdateLogLevelDto) Validate() ValidationErrors {
+	v := validation{}
+	if v.required("level", l.Level) {
+		if _, err := zapcore.ParseLevel(l.Level); err != nil {
+			v.add("level", err.Error())
+		}
+	}
+	if l.RevertAfter != "" {
+		if revertAfter, err := time.ParseDuration(l.RevertAfter); err != nil || revertAfter <= 0 {
+			v.add("revertAfter", "revertAfter must be a positive duration such as 15m")
+		}
+	}
+	return v.result()
+}
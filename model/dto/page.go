@@ -0,0 +1,11 @@
+package dto
+
+// Page is the envelope of a list endpoint.
+// Page is set by the page mode, and NextCursor is set by the cursor mode while more items remain.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	Size       int    `json:"size"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/onetooler/bistory-backend/model"
+)
+
+// FieldError is the validation error of a field of the request, named by its JSON name.
+// Field is empty if the error is of the whole request.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors are all the field errors of a request. It is returned by the binding of echo after the DTO is bound.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, fmt.Sprintf("%s: %s", err.Field, err.Message))
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Validatable is implemented by the DTOs of the request bodies. Validate returns nil if the DTO is valid.
+type Validatable interface {
+	Validate() ValidationErrors
+}
+
+// validation collects the field errors of a DTO.
+type validation struct {
+	errors ValidationErrors
+}
+
+// check adds the error of a field unless it is nil.
+func (v *validation) check(field string, err error) {
+	if err != nil {
+		v.add(field, err.Error())
+	}
+}
+
+func (v *validation) add(field string, message string) {
+	v.errors = append(v.errors, FieldError{Field: field, Message: message})
+}
+
+// required checks that a value is not empty, and returns false if it is.
+func (v *validation) required(field string, value string) bool {
+	if value == "" {
+		v.add(field, fmt.Sprintf("%s is required", field))
+		return false
+	}
+	return true
+}
+
+func (v *validation) maxLength(field string, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		v.add(field, fmt.Sprintf("%s must be at most %d characters", field, max))
+	}
+}
+
+// loginId, password and email check the values of a new account by the rules which the account service also applies.
+func (v *validation) loginId(field string, value string) {
+	if v.required(field, value) {
+		v.check(field, model.ValidateLoginId(value))
+	}
+}
+
+func (v *validation) password(field string, value string) {
+	if v.required(field, value) {
+		v.check(field, model.ValidatePassword(value))
+	}
+}
+
+func (v *validation) email(field string, value string) {
+	if v.required(field, value) {
+		v.check(field, model.ValidateEmail(value))
+	}
+}
+
+// result returns the collected errors, or nil if there is none.
+func (v *validation) result() ValidationErrors {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// IdempotencyKey defines struct of the request stored by its Idempotency-Key, with its response once it is completed.
+// It is used when Redis is disabled. The key is stored as the SHA-256 hash of the key scoped by the client and the path.
+type IdempotencyKey struct {
+	KeyHash     string    `gorm:"primarykey;size:64" json:"-"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Fingerprint string    `gorm:"not null" json:"fingerprint"`
+	Completed   bool      `json:"completed"`
+	Status      int       `json:"status"`
+	// Header is the JSON of the response headers replayed with the body.
+	Header    []byte    `json:"-"`
+	Body      []byte    `json:"-"`
+	ExpiresAt time.Time `gorm:"index" json:"expiresAt"`
+}
+
+// TableName returns the table name of idempotency key struct and it is used by gorm.
+func (IdempotencyKey) TableName() string {
+	return "idempotency_key"
+}
+
+// ToString is return string of object
+func (k *IdempotencyKey) ToString() string {
+	return toString(k)
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job defines struct of a background job. A job with a schedule runs repeatedly,
+// otherwise it runs once at NextRunAt and is retried on failure up to MaxAttempts.
+type Job struct {
+	gorm.Model
+	Name     string    `gorm:"uniqueIndex;not null" json:"name"`
+	Handler  string    `gorm:"not null" json:"handler"`
+	Schedule string    `json:"schedule,omitempty"`
+	Payload  string    `json:"payload,omitempty"`
+	Status   JobStatus `gorm:"index" json:"status"`
+	// NextRunAt is the time when the job runs next.
+	NextRunAt   time.Time `gorm:"index" json:"nextRunAt"`
+	Attempts    uint      `json:"attempts"`
+	MaxAttempts uint      `json:"maxAttempts"`
+	// LockedBy is the instance which is running the job. The lock expires at LockedUntil,
+	// so that the job is run again when the instance dies while running it.
+	LockedBy    string     `json:"lockedBy,omitempty"`
+	LockedUntil *time.Time `json:"lockedUntil,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	// Triggered is set by a trigger while the job is running, so that the next run is not overwritten at the end of the run.
+	Triggered bool `json:"-"`
+}
+
+type JobStatus uint
+
+const (
+	JobStatusScheduled JobStatus = iota + 1
+	JobStatusCompleted
+	JobStatusFailed
+)
+
+func (s JobStatus) String() string {
+	switch s {
+	case JobStatusScheduled:
+		return "Scheduled"
+	case JobStatusCompleted:
+		return "Completed"
+	case JobStatusFailed:
+		return "Failed"
+	default:
+		return "Invalid Status"
+	}
+}
+
+// IsRecurring returns whether the job runs by a cron schedule.
+func (j *Job) IsRecurring() bool {
+	return j.Schedule != ""
+}
+
+// TableName returns the table name of job struct and it is used by gorm.
+func (Job) TableName() string {
+	return "job"
+}
+
+// ToString is return string of object
+func (j *Job) ToString() string {
+	return toString(j)
+}
+
+// JobRun defines struct of an execution of a job.
+type JobRun struct {
+	ID         uint         `gorm:"primarykey" json:"id"`
+	JobID      uint         `gorm:"index;not null" json:"jobId"`
+	Instance   string       `json:"instance"`
+	Status     JobRunStatus `json:"status"`
+	StartedAt  time.Time    `json:"startedAt"`
+	FinishedAt *time.Time   `json:"finishedAt"`
+	Error      string       `json:"error,omitempty"`
+}
+
+type JobRunStatus uint
+
+const (
+	JobRunStatusRunning JobRunStatus = iota + 1
+	JobRunStatusSucceeded
+	JobRunStatusFailed
+)
+
+func (s JobRunStatus) String() string {
+	switch s {
+	case JobRunStatusRunning:
+		return "Running"
+	case JobRunStatusSucceeded:
+		return "Succeeded"
+	case JobRunStatusFailed:
+		return "Failed"
+	default:
+		return "Invalid Status"
+	}
+}
+
+// TableName returns the table name of job run struct and it is used by gorm.
+func (JobRun) TableName() string {
+	return "job_run"
+}
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// Role defines struct of role data. A role is a named set of permissions which is assigned to accounts.
+type Role struct {
+	gorm.Model
+	Name        string           `gorm:"unique;not null" json:"name"`
+	Description string           `json:"description"`
+	Permissions []RolePermission `json:"permissions"`
+}
+
+// RolePermission defines struct of the mapping between a role and a permission.
+type RolePermission struct {
+	ID         uint       `gorm:"primarykey" json:"-"`
+	RoleID     uint       `gorm:"uniqueIndex:idx_role_permission;not null" json:"-"`
+	Permission Permission `gorm:"uniqueIndex:idx_role_permission;not null" json:"permission"`
+}
+
+// Permission represents an action which is allowed on the resources of any account.
+// The owner of a resource is always allowed to access it without any permission.
+type Permission string
+
+const (
+	PermissionAccountRead   Permission = "account:read"
+	PermissionAccountWrite  Permission = "account:write"
+	PermissionAccountDelete Permission = "account:delete"
+	PermissionAccountExport Permission = "account:export"
+	PermissionTokenRead     Permission = "token:read"
+	PermissionTokenWrite    Permission = "token:write"
+	PermissionRoleManage    Permission = "role:manage"
+	PermissionSystemAdmin   Permission = "system:admin"
+)
+
+// AllPermissions is the list of the permissions which are defined.
+var AllPermissions = []Permission{
+	PermissionAccountRead,
+	PermissionAccountWrite,
+	PermissionAccountDelete,
+	PermissionAccountExport,
+	PermissionTokenRead,
+	PermissionTokenWrite,
+	PermissionRoleManage,
+	PermissionSystemAdmin,
+}
+
+// Constant about the roles which are seeded by the migration.
+const (
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+	RoleUser    = "user"
+)
+
+// DefaultRoles returns the roles which are seeded by the migration.
+func DefaultRoles() []Role {
+	return []Role{
+		newRole(RoleAdmin, "Administrator who can do everything.", AllPermissions...),
+		newRole(RoleSupport, "Support staff who can view accounts.", PermissionAccountRead, PermissionTokenRead),
+		newRole(RoleUser, "User who can manage only own account."),
+	}
+}
+
+func newRole(name, description string, permissions ...Permission) Role {
+	role := Role{Name: name, Description: description}
+	for _, p := range permissions {
+		role.Permissions = append(role.Permissions, RolePermission{Permission: p})
+	}
+	return role
+}
+
+// TableName returns the table name of role struct and it is used by gorm.
+func (Role) TableName() string {
+	return "role"
+}
+
+// TableName returns the table name of role permission struct and it is used by gorm.
+func (RolePermission) TableName() string {
+	return "role_permission"
+}
+
+// ToString is return string of object
+func (r *Role) ToString() string {
+	return toString(r)
+}
+
+// HasPermission judges whether the role has a given permission.
+func (r *Role) HasPermission(permission Permission) bool {
+	for _, p := range r.Permissions {
+		if p.Permission == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleName returns the name of the role which replaces the authority.
+func (a Authority) RoleName() string {
+	switch a {
+	case AuthorityAdmin:
+		return RoleAdmin
+	default:
+		return RoleUser
+	}
+}
+
+// RoleNames returns the names of the roles assigned to the account. Roles must be preloaded.
+func (a *Account) RoleNames() []string {
+	names := make([]string, 0, len(a.Roles))
+	for _, r := range a.Roles {
+		names = append(names, r.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Permissions returns all permissions granted by the roles of the account. Roles must be preloaded with permissions.
+func (a *Account) Permissions() []string {
+	set := map[string]bool{}
+	for _, r := range a.Roles {
+		for _, p := range r.Permissions {
+			set[string(p.Permission)] = true
+		}
+	}
+	permissions := make([]string, 0, len(set))
+	for p := range set {
+		permissions = append(permissions, p)
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// AccountRole defines struct of the assignment of a role to an account.
+type AccountRole struct {
+	AccountID uint `gorm:"primaryKey"`
+	RoleID    uint `gorm:"primaryKey"`
+}
+
+// TableName returns the table name of account role struct and it is used by gorm.
+func (AccountRole) TableName() string {
+	return "account_role"
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/util"
+)
+
+// SetupToken defines struct of the one-time token to create the first admin account.
+// It is issued at boot while no admin exists. The plain token is printed to the log only, and its SHA-256 hash is stored.
+type SetupToken struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	TokenHash string    `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewSetupToken is constructor. It returns the token and its plain value which is shown only once.
+func NewSetupToken(expiresAt time.Time) (*SetupToken, string) {
+	plain := util.RandomBase16String(config.SetupTokenLength)
+	return &SetupToken{TokenHash: HashSetupToken(plain), ExpiresAt: expiresAt}, plain
+}
+
+// HashSetupToken returns the hash of a plain setup token for storing and lookup.
+func HashSetupToken(plain string) string {
+	return HashAccessToken(plain)
+}
+
+// TableName returns the table name of setup token struct and it is used by gorm.
+func (SetupToken) TableName() string {
+	return "setup_token"
+}
+
+// ToString is return string of object
+func (t *SetupToken) ToString() string {
+	return toString(t)
+}
@@ -0,0 +1,152 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/service"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// prepareForAuthorizationTest registers all routes and returns the router with the accounts of the fixtures.
+func prepareForAuthorizationTest(t *testing.T) (*echo.Echo, container.Container) {
+	e, container := testutil.PrepareForControllerTest(false)
+	Init(e, container)
+	assert.Nil(t, testutil.LoadFixtures(container, "accounts.yml", "roles.yml"))
+	return e, container
+}
+
+// loginAs logs in the account of the fixtures and returns its id and the cookies of the session.
+func loginAs(t *testing.T, e *echo.Echo, container container.Container, loginId, password string) (uint, []*http.Cookie) {
+	account := model.Account{}
+	assert.Nil(t, container.GetRepository().Where("login_id = ?", loginId).First(&account).Error)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, testutil.NewJSONRequest(http.MethodPost, config.APIAuthLogin, &dto.LoginDto{LoginId: loginId, Password: password}))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	return account.ID, rec.Result().Cookies()
+}
+
+// routePath replaces the account id and the other parameters of a route path.
+func routePath(path string, accountId uint) string {
+	path = strings.Replace(path, ":"+config.APIAccountIdParam, fmt.Sprint(accountId), 1)
+	path = strings.Replace(path, ":"+config.APIAccountTokenIdParam, "1", 1)
+	path = strings.Replace(path, ":"+config.APIAccountExportIdParam, "1", 1)
+	return strings.Replace(path, ":"+config.APIAccountRoleNameParam, model.RoleAdmin, 1)
+}
+
+func serveAs(e *echo.Echo, cookies []*http.Cookie, method, path string) int {
+	req := testutil.NewJSONRequest(method, path, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestInit_OtherAccountFailure(t *testing.T) {
+	e, container := prepareForAuthorizationTest(t)
+	_, cookies := loginAs(t, e, container, "active1", "activePassword")
+	other, _ := loginAs(t, e, container, "active2", "activePassword")
+
+	routes := [][]string{
+		{http.MethodGet, config.APIAccountIdPath},
+		{http.MethodPost, config.APIAccountChangePassword},
+		{http.MethodPatch, config.APIAccountIdPath},
+		{http.MethodDelete, config.APIAccountIdPath},
+		{http.MethodGet, config.APIAccountTokens},
+		{http.MethodPost, config.APIAccountTokens},
+		{http.MethodDelete, config.APIAccountTokenIdPath},
+		{http.MethodGet, config.APIAccountAvatar},
+		{http.MethodPost, config.APIAccountAvatar},
+		{http.MethodDelete, config.APIAccountAvatar},
+		{http.MethodGet, config.APIAccountExports},
+		{http.MethodPost, config.APIAccountExports},
+		{http.MethodGet, config.APIAccountExportDownload},
+		{http.MethodGet, config.APIAccountRoles},
+	}
+	for _, route := range routes {
+		assert.Equal(t, http.StatusForbidden, serveAs(e, cookies, route[0], routePath(route[1], other)), route)
+	}
+}
+
+func TestInit_NoPermissionFailure(t *testing.T) {
+	e, container := prepareForAuthorizationTest(t)
+	own, cookies := loginAs(t, e, container, "active1", "activePassword")
+
+	routes := [][]string{
+		{http.MethodGet, config.APIRole},
+		{http.MethodPut, config.APIAccountRoleNamePath},
+		{http.MethodDelete, config.APIAccountRoleNamePath},
+		{http.MethodGet, config.APIAccount},
+		{http.MethodGet, config.APIAdminRoutes},
+	}
+	for _, route := range routes {
+		assert.Equal(t, http.StatusForbidden, serveAs(e, cookies, route[0], routePath(route[1], own)), route)
+	}
+}
+
+func TestInit_NoLoginFailure(t *testing.T) {
+	e, container := prepareForAuthorizationTest(t)
+	own, _ := loginAs(t, e, container, "active1", "activePassword")
+
+	assert.Equal(t, http.StatusUnauthorized, serveAs(e, nil, http.MethodGet, routePath(config.APIAccountIdPath, own)))
+	assert.Equal(t, http.StatusUnauthorized, serveAs(e, nil, http.MethodGet, config.APIRole))
+}
+
+func TestInit_SupportRole(t *testing.T) {
+	e, container := prepareForAuthorizationTest(t)
+	_, cookies := loginAs(t, e, container, "support1", "supportPassword")
+	other, _ := loginAs(t, e, container, "active1", "activePassword")
+
+	assert.Equal(t, http.StatusOK, serveAs(e, cookies, http.MethodGet, routePath(config.APIAccountIdPath, other)))
+	assert.Equal(t, http.StatusForbidden, serveAs(e, cookies, http.MethodDelete, routePath(config.APIAccountIdPath, other)))
+	assert.Equal(t, http.StatusOK, serveAs(e, cookies, http.MethodGet, routePath(config.APIAccountExports, other)))
+	assert.Equal(t, http.StatusForbidden, serveAs(e, cookies, http.MethodPost, routePath(config.APIAccountExports, other)))
+	assert.Equal(t, http.StatusForbidden, serveAs(e, cookies, http.MethodGet, routePath(config.APIAccountExportDownload, other)))
+}
+
+func TestInit_ReadScopeExportFailure(t *testing.T) {
+	e, container := prepareForAuthorizationTest(t)
+	own, _ := loginAs(t, e, container, "active1", "activePassword")
+	token, err := service.NewAccessTokenService(container).CreateAccessToken(own, &dto.CreateAccessTokenDto{Name: "script", Scopes: []string{model.ScopeAccountRead}}, nil)
+	assert.Nil(t, err)
+
+	req := testutil.NewJSONRequest(http.MethodPost, routePath(config.APIAccountExports, own), nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token.Token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestInit_RevokedRoleFailure(t *testing.T) {
+	e, container := prepareForAuthorizationTest(t)
+	support, cookies := loginAs(t, e, container, "support1", "supportPassword")
+	other, _ := loginAs(t, e, container, "active1", "activePassword")
+	assert.Equal(t, http.StatusOK, serveAs(e, cookies, http.MethodGet, routePath(config.APIAccountIdPath, other)))
+
+	assert.Nil(t, container.GetRepository().Where("account_id = ?", support).Delete(&model.AccountRole{}).Error)
+
+	assert.Equal(t, http.StatusForbidden, serveAs(e, cookies, http.MethodGet, routePath(config.APIAccountIdPath, other)))
+}
+
+func TestInit_LockedAccountFailure(t *testing.T) {
+	e, container := prepareForAuthorizationTest(t)
+	own, cookies := loginAs(t, e, container, "active1", "activePassword")
+	assert.Equal(t, http.StatusOK, serveAs(e, cookies, http.MethodGet, routePath(config.APIAccountIdPath, own)))
+
+	assert.Nil(t, container.GetRepository().Model(&model.Account{}).Where("id = ?", own).Update("status", model.StatusInactive).Error)
+
+	assert.Equal(t, http.StatusUnauthorized, serveAs(e, cookies, http.MethodGet, routePath(config.APIAccountIdPath, own)))
+}
@@ -1,24 +1,142 @@
 package routes
 
 import (
+	"net/http"
+	"os"
+	"strings"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/onetooler/bistory-backend/config"
 	"github.com/onetooler/bistory-backend/container"
 	"github.com/onetooler/bistory-backend/controller"
+	appmiddleware "github.com/onetooler/bistory-backend/middleware"
+	"github.com/onetooler/bistory-backend/model"
 
 	_ "github.com/onetooler/bistory-backend/docs" // for using echo-swagger
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
+// router registers the routes with their authorization policies in the API versions.
+type router struct {
+	e         *echo.Echo
+	container container.Container
+	table     appmiddleware.RouteTable
+	// versions are the API versions which the routes are registered in.
+	versions []string
+	docs     *versionDocs
+	// middlewares are applied to the routes after their authorization policies.
+	middlewares []echo.MiddlewareFunc
+}
+
+// add registers a route declared by the path under config.API in each version of the router.
+// The routes of config.APIVersion1 are also registered at the path without the version, for the clients before the versioning.
+// The responses of the deprecated versions have the deprecation headers.
+func (r *router) add(method, path string, policy appmiddleware.Policy, h echo.HandlerFunc) {
+	if !strings.HasPrefix(path, config.API+"/") {
+		r.e.Add(method, path, h, append([]echo.MiddlewareFunc{r.table.Declare(r.container, method, path, policy)}, r.middlewares...)...)
+		return
+	}
+
+	r.docs.record(method, path, r.versions)
+	for _, version := range r.versions {
+		deprecation, deprecated := r.container.GetConfig().API.Versions[version]
+		paths := []string{versionedPath(version, path)}
+		if version == config.APIVersion1 {
+			paths = append(paths, path)
+		}
+		for _, p := range paths {
+			middlewares := []echo.MiddlewareFunc{}
+			if deprecated {
+				middlewares = append(middlewares, appmiddleware.DeprecationMiddleware(deprecation))
+			}
+			middlewares = append(middlewares, r.table.Declare(r.container, method, p, policy))
+			middlewares = append(middlewares, r.middlewares...)
+			r.e.Add(method, p, h, middlewares...)
+		}
+	}
+}
+
+// Since returns the router which registers the routes in a given version and the later ones, for the routes added in it.
+func (r *router) Since(version string) *router {
+	return r.filter(func(i int) bool { return i >= versionIndex(version) })
+}
+
+// Until returns the router which registers the routes up to a given version, for the routes changed or removed after it.
+func (r *router) Until(version string) *router {
+	return r.filter(func(i int) bool { return i <= versionIndex(version) })
+}
+
+// Idempotent returns the router which registers the routes accepting the Idempotency-Key header, for the POST requests retried by the clients.
+func (r *router) Idempotent() *router {
+	idempotent := *r
+	idempotent.middlewares = append(append([]echo.MiddlewareFunc{}, r.middlewares...), appmiddleware.IdempotencyMiddleware(r.container))
+	return &idempotent
+}
+
+func (r *router) filter(include func(int) bool) *router {
+	filtered := *r
+	filtered.versions = []string{}
+	for _, version := range r.versions {
+		if include(versionIndex(version)) {
+			filtered.versions = append(filtered.versions, version)
+		}
+	}
+	return &filtered
+}
+
+func (r *router) GET(path string, policy appmiddleware.Policy, h echo.HandlerFunc) {
+	r.add(http.MethodGet, path, policy, h)
+}
+
+func (r *router) POST(path string, policy appmiddleware.Policy, h echo.HandlerFunc) {
+	r.add(http.MethodPost, path, policy, h)
+}
+
+func (r *router) PUT(path string, policy appmiddleware.Policy, h echo.HandlerFunc) {
+	r.add(http.MethodPut, path, policy, h)
+}
+
+func (r *router) PATCH(path string, policy appmiddleware.Policy, h echo.HandlerFunc) {
+	r.add(http.MethodPatch, path, policy, h)
+}
+
+func (r *router) DELETE(path string, policy appmiddleware.Policy, h echo.HandlerFunc) {
+	r.add(http.MethodDelete, path, policy, h)
+}
+
 // Init initialize the routing of this application.
+// Every route must declare its authorization policy, otherwise the application does not start.
+// The routes are registered in all API versions, unless they are registered by the router of Since or Until.
+// Only the routes registered by the router of Idempotent accept the Idempotency-Key header.
 func Init(e *echo.Echo, container container.Container) {
+	r := &router{
+		e:         e,
+		container: container,
+		table:     appmiddleware.NewRouteTable(),
+		versions:  config.APIVersions,
+		docs:      newVersionDocs(),
+	}
+
 	setErrorController(e, container)
-	setAuthController(e, container)
-	setAccountController(e, container)
-	setHealthController(e, container)
+	setAuthController(r)
+	setAccountController(r)
+	setAvatarController(r)
+	setFileController(r)
+	setDataExportController(r)
+	setAccessTokenController(r)
+	setRoleController(r)
+	setRouteController(r)
+	setLogLevelController(r)
+	setJobController(r)
+	setHealthController(r)
+
+	setSwagger(r)
 
-	setSwagger(container, e)
+	if err := r.table.Verify(e.Routes()); err != nil {
+		container.GetLogger().GetZapLogger().Errorf(err.Error())
+		os.Exit(config.ErrExitStatus)
+	}
 }
 
 func setErrorController(e *echo.Echo, container container.Container) {
@@ -27,31 +145,123 @@ func setErrorController(e *echo.Echo, container container.Container) {
 	e.Use(middleware.Recover())
 }
 
-func setAuthController(e *echo.Echo, container container.Container) {
-	auth := controller.NewAuthController(container)
-	e.GET(config.APIAuthLoginStatus, func(c echo.Context) error { return auth.GetLoginStatus(c) })
-	e.GET(config.APIAuthLoginAccount, func(c echo.Context) error { return auth.GetLoginAccount(c) })
-	e.POST(config.APIAuthLogin, func(c echo.Context) error { return auth.Login(c) })
-	e.POST(config.APIAuthLogout, func(c echo.Context) error { return auth.Logout(c) })
-	e.POST(config.APIAuthEmailVerificationTokenSend, func(c echo.Context) error { return auth.EmailVerificationTokenSend(c) })
+func setAuthController(r *router) {
+	auth := controller.NewAuthController(r.container)
+	r.GET(config.APIAuthLoginStatus, appmiddleware.Authenticated().WithScope(model.ScopeAccountRead).AllowPasswordChange(), func(c echo.Context) error { return auth.GetLoginStatus(c) })
+	r.GET(config.APIAuthLoginAccount, appmiddleware.Authenticated().WithScope(model.ScopeAccountRead).AllowPasswordChange(), func(c echo.Context) error { return auth.GetLoginAccount(c) })
+	r.POST(config.APIAuthLogin, appmiddleware.Public(), func(c echo.Context) error { return auth.Login(c) })
+	r.POST(config.APIAuthLogout, appmiddleware.Public(), func(c echo.Context) error { return auth.Logout(c) })
+	r.POST(config.APIAuthSetup, appmiddleware.Public(), func(c echo.Context) error { return auth.SetupAdmin(c) })
+	r.Idempotent().POST(config.APIAuthEmailVerificationTokenSend, appmiddleware.Public(), func(c echo.Context) error { return auth.EmailVerificationTokenSend(c) })
+	r.POST(config.APIAuthVerifyEmail, appmiddleware.Public(), func(c echo.Context) error { return auth.EmailVerificationTokenVerify(c) })
+}
+
+func setAccountController(r *router) {
+	account := controller.NewAccountController(r.container)
+	r.Idempotent().POST(config.APIAccount, appmiddleware.Public(), func(c echo.Context) error { return account.CreateAccount(c) })
+	r.GET(config.APIAccount, appmiddleware.RequirePermission(model.PermissionAccountRead), func(c echo.Context) error { return account.GetAccounts(c) })
+	r.GET(config.APIAccountIdPath,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountRead).WithScope(model.ScopeAccountRead),
+		func(c echo.Context) error { return account.GetAccount(c) })
+	r.POST(config.APIAccountChangePassword,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountWrite).WithScope(model.ScopeAccountWrite).AllowPasswordChange(),
+		func(c echo.Context) error { return account.ChangeAccountPassword(c) })
+	r.PATCH(config.APIAccountIdPath,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountWrite).WithScope(model.ScopeAccountWrite),
+		func(c echo.Context) error { return account.UpdateAccountProfile(c) })
+	r.DELETE(config.APIAccountIdPath,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountDelete).WithScope(model.ScopeAccountWrite),
+		func(c echo.Context) error { return account.DeleteAccount(c) })
+	r.Idempotent().POST(config.APIAccountFindLoginId, appmiddleware.Public(), func(c echo.Context) error { return account.FindLoginId(c) })
+	r.POST(config.APIAccountRestore, appmiddleware.Public(), func(c echo.Context) error { return account.RestoreAccount(c) })
+}
+
+func setAvatarController(r *router) {
+	avatar := controller.NewAvatarController(r.container)
+	r.GET(config.APIAccountAvatar,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountRead).WithScope(model.ScopeAccountRead),
+		func(c echo.Context) error { return avatar.GetAvatar(c) })
+	r.POST(config.APIAccountAvatar,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountWrite).WithScope(model.ScopeAccountWrite),
+		func(c echo.Context) error { return avatar.UploadAvatar(c) })
+	r.DELETE(config.APIAccountAvatar,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountWrite).WithScope(model.ScopeAccountWrite),
+		func(c echo.Context) error { return avatar.DeleteAvatar(c) })
+}
+
+func setDataExportController(r *router) {
+	export := controller.NewDataExportController(r.container)
+	r.GET(config.APIAccountExports,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountRead).WithScope(model.ScopeAccountRead),
+		func(c echo.Context) error { return export.GetExports(c) })
+	// the archive has all personal data of the account, so the other accounts need the permission to export it
+	r.POST(config.APIAccountExports,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountExport).WithScope(model.ScopeAccountWrite),
+		func(c echo.Context) error { return export.RequestExport(c) })
+	r.GET(config.APIAccountExportDownload,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountExport).WithScope(model.ScopeAccountRead),
+		func(c echo.Context) error { return export.DownloadExport(c) })
+}
+
+// setFileController registers the download of the files by signed URLs. The signature is the authorization.
+func setFileController(r *router) {
+	file := controller.NewFileController(r.container)
+	r.GET(config.APIFilesKeyPath, appmiddleware.Public(), func(c echo.Context) error { return file.GetFile(c) })
+}
+
+func setAccessTokenController(r *router) {
+	token := controller.NewAccessTokenController(r.container)
+	r.GET(config.APIAccountTokens,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionTokenRead).WithScope(model.ScopeTokenRead),
+		func(c echo.Context) error { return token.GetAccessTokens(c) })
+	r.POST(config.APIAccountTokens,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionTokenWrite).WithScope(model.ScopeTokenWrite),
+		func(c echo.Context) error { return token.CreateAccessToken(c) })
+	r.DELETE(config.APIAccountTokenIdPath,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionTokenWrite).WithScope(model.ScopeTokenWrite),
+		func(c echo.Context) error { return token.RevokeAccessToken(c) })
+}
+
+func setRoleController(r *router) {
+	role := controller.NewRoleController(r.container)
+	r.GET(config.APIRole, appmiddleware.RequirePermission(model.PermissionRoleManage), func(c echo.Context) error { return role.GetRoles(c) })
+	r.GET(config.APIAccountRoles,
+		appmiddleware.OwnerOrPermission(config.APIAccountIdParam, model.PermissionAccountRead).WithScope(model.ScopeAccountRead),
+		func(c echo.Context) error { return role.GetAccountRoles(c) })
+	r.PUT(config.APIAccountRoleNamePath, appmiddleware.RequirePermission(model.PermissionRoleManage), func(c echo.Context) error { return role.AssignRole(c) })
+	r.DELETE(config.APIAccountRoleNamePath, appmiddleware.RequirePermission(model.PermissionRoleManage), func(c echo.Context) error { return role.UnassignRole(c) })
+}
+
+func setRouteController(r *router) {
+	route := controller.NewRouteController(r.container, r.table)
+	r.GET(config.APIAdminRoutes, appmiddleware.RequirePermission(model.PermissionSystemAdmin), func(c echo.Context) error { return route.GetRoutes(c) })
+}
+
+func setLogLevelController(r *router) {
+	logLevel := controller.NewLogLevelController(r.container)
+	r.GET(config.APIAdminLogLevels, appmiddleware.RequirePermission(model.PermissionSystemAdmin), func(c echo.Context) error { return logLevel.GetLogLevels(c) })
+	r.PUT(config.APIAdminLogLevelComponentPath, appmiddleware.RequirePermission(model.PermissionSystemAdmin), func(c echo.Context) error { return logLevel.UpdateLogLevel(c) })
 }
 
-func setAccountController(e *echo.Echo, container container.Container) {
-	account := controller.NewAccountController(container)
-	e.POST(config.APIAccount, func(c echo.Context) error { return account.CreateAccount(c) })
-	e.GET(config.APIAccountIdPath, func(c echo.Context) error { return account.GetAccount(c) })
-	e.POST(config.APIAccountChangePassword, func(c echo.Context) error { return account.ChangeAccountPassword(c) })
-	e.DELETE(config.APIAccountIdPath, func(c echo.Context) error { return account.DeleteAccount(c) })
-	e.POST(config.APIAccountFindLoginId, func(c echo.Context) error { return account.FindLoginId(c) })
+func setJobController(r *router) {
+	job := controller.NewJobController(r.container)
+	r.GET(config.APIAdminJobs, appmiddleware.RequirePermission(model.PermissionSystemAdmin), func(c echo.Context) error { return job.GetJobs(c) })
+	r.GET(config.APIAdminJobRuns, appmiddleware.RequirePermission(model.PermissionSystemAdmin), func(c echo.Context) error { return job.GetJobRuns(c) })
+	r.POST(config.APIAdminJobTrigger, appmiddleware.RequirePermission(model.PermissionSystemAdmin), func(c echo.Context) error { return job.TriggerJob(c) })
 }
 
-func setHealthController(e *echo.Echo, container container.Container) {
-	health := controller.NewHealthController(container)
-	e.GET(config.APIHealth, func(c echo.Context) error { return health.GetHealthCheck(c) })
+func setHealthController(r *router) {
+	health := controller.NewHealthController(r.container)
+	r.GET(config.APIHealth, appmiddleware.Public(), func(c echo.Context) error { return health.GetHealthCheck(c) })
 }
 
-func setSwagger(container container.Container, e *echo.Echo) {
-	if container.GetConfig().Swagger.Enabled {
-		e.GET("/swagger/*", echoSwagger.WrapHandler)
+// setSwagger serves the document generated by swag at /swagger, and the document of each API version at /swagger/<version>.
+func setSwagger(r *router) {
+	if r.container.GetConfig().Swagger.Enabled {
+		r.GET("/swagger/*", appmiddleware.Public(), echoSwagger.WrapHandler)
+		for _, version := range config.APIVersions {
+			r.docs.register(version)
+			r.GET("/swagger/"+version+"/*", appmiddleware.Public(), echoSwagger.EchoWrapHandler(echoSwagger.InstanceName(version)))
+		}
 	}
 }
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	appmiddleware "github.com/onetooler/bistory-backend/middleware"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter_Idempotent(t *testing.T) {
+	e, container := testutil.PrepareForControllerTest(false)
+	r := &router{e: e, container: container, table: appmiddleware.NewRouteTable(), versions: config.APIVersions, docs: newVersionDocs()}
+	calls := 0
+	ok := func(c echo.Context) error {
+		calls++
+		return c.NoContent(http.StatusCreated)
+	}
+	r.Idempotent().POST(config.API+"/idempotent", appmiddleware.Public(), ok)
+	r.POST(config.API+"/other", appmiddleware.Public(), ok)
+
+	statuses := map[string]int{"/api/v2/idempotent": 1, "/api/v2/other": 2}
+	for path, expected := range statuses {
+		calls = 0
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest(http.MethodPost, path, nil)
+			req.Header.Set(config.HeaderIdempotencyKey, "key")
+			e.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		assert.Equal(t, expected, calls, path)
+	}
+}
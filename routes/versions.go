@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/docs"
+	"github.com/swaggo/swag"
+)
+
+// versionedPath returns the path of a route declared under config.API in a given version, such as /api/v1/account for /api/account.
+func versionedPath(version, path string) string {
+	return config.API + "/" + version + strings.TrimPrefix(path, config.API)
+}
+
+// versionIndex returns the order of a given version in config.APIVersions, or -1 if it is unknown.
+func versionIndex(version string) int {
+	for i, v := range config.APIVersions {
+		if v == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// versionDocs derives the swagger document of each API version from the document generated by swag.
+// The operations of the routes which are not registered in a version are removed from its document.
+type versionDocs struct {
+	// versions are the versions of the routes by "METHOD /path", whose parameters are replaced by {}.
+	versions map[string][]string
+}
+
+func newVersionDocs() *versionDocs {
+	return &versionDocs{versions: make(map[string][]string)}
+}
+
+func (d *versionDocs) record(method, path string, versions []string) {
+	d.versions[method+" "+normalizeDocPath(path)] = versions
+}
+
+// register registers the document of a given version to swag by the name of the version.
+func (d *versionDocs) register(version string) {
+	if swag.GetSwagger(version) == nil {
+		swag.Register(version, &versionDoc{docs: d, version: version})
+	}
+}
+
+type versionDoc struct {
+	docs    *versionDocs
+	version string
+}
+
+// ReadDoc returns the generated document whose base path is of the version, without the operations of the other versions.
+func (d *versionDoc) ReadDoc() string {
+	generated := docs.SwaggerInfo.ReadDoc()
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(generated), &doc); err != nil {
+		return generated
+	}
+
+	doc["basePath"] = versionedPath(d.version, config.API)
+	paths, _ := doc["paths"].(map[string]interface{})
+	for path, item := range paths {
+		operations, _ := item.(map[string]interface{})
+		for method := range operations {
+			versions, ok := d.docs.versions[strings.ToUpper(method)+" "+normalizeDocPath(config.API+path)]
+			if ok && !contains(versions, d.version) {
+				delete(operations, method)
+			}
+		}
+		if len(operations) == 0 {
+			delete(paths, path)
+		}
+	}
+
+	bytes, err := json.Marshal(doc)
+	if err != nil {
+		return generated
+	}
+	return string(bytes)
+}
+
+// normalizeDocPath replaces the parameters of the route path, such as :id and *, and of the swagger path, such as {id}, by {}.
+func normalizeDocPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || segment == "*" || strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = "{}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
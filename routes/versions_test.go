@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/onetooler/bistory-backend/config"
+	appmiddleware "github.com/onetooler/bistory-backend/middleware"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInit_VersionedRoutes(t *testing.T) {
+	e, container := testutil.PrepareForControllerTest(false)
+	deprecation := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	container.GetConfig().API.Versions = map[string]config.APIVersion{
+		config.APIVersion1: {Deprecation: deprecation, Sunset: sunset, Link: "https://example.com/migration"},
+	}
+	Init(e, container)
+
+	for _, path := range []string{config.APIHealth, "/api/v1/health"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, "@1767225600", rec.Header().Get(config.HeaderDeprecation), path)
+		assert.Equal(t, "Wed, 01 Jul 2026 00:00:00 GMT", rec.Header().Get(config.HeaderSunset), path)
+		assert.Equal(t, `<https://example.com/migration>; rel="deprecation"; type="text/html"`, rec.Header().Get(config.HeaderLink), path)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(config.HeaderDeprecation))
+	assert.Empty(t, rec.Header().Get(config.HeaderSunset))
+}
+
+func TestRouter_SinceAndUntil(t *testing.T) {
+	e, container := testutil.PrepareForControllerTest(false)
+	r := &router{e: e, container: container, table: appmiddleware.NewRouteTable(), versions: config.APIVersions, docs: newVersionDocs()}
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	r.Until(config.APIVersion1).GET(config.API+"/old", appmiddleware.Public(), ok)
+	r.Since(config.APIVersion2).GET(config.API+"/new", appmiddleware.Public(), ok)
+
+	statuses := map[string]int{
+		"/api/old":    http.StatusOK,
+		"/api/v1/old": http.StatusOK,
+		"/api/v2/old": http.StatusNotFound,
+		"/api/new":    http.StatusNotFound,
+		"/api/v1/new": http.StatusNotFound,
+		"/api/v2/new": http.StatusOK,
+	}
+	for path, status := range statuses {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, status, rec.Code, path)
+	}
+	assert.Nil(t, r.table.Verify(e.Routes()))
+}
+
+func TestVersionDoc_ReadDoc(t *testing.T) {
+	docs := newVersionDocs()
+	docs.record(http.MethodGet, config.APIHealth, []string{config.APIVersion2})
+	docs.record(http.MethodGet, config.APIAccountIdPath, []string{config.APIVersion1})
+
+	read := func(version string) map[string]map[string]interface{} {
+		doc := struct {
+			BasePath string                            `json:"basePath"`
+			Paths    map[string]map[string]interface{} `json:"paths"`
+		}{}
+		assert.Nil(t, json.Unmarshal([]byte((&versionDoc{docs: docs, version: version}).ReadDoc()), &doc))
+		assert.Equal(t, "/api/"+version, doc.BasePath)
+		return doc.Paths
+	}
+
+	v1 := read(config.APIVersion1)
+	assert.NotContains(t, v1, "/health")
+	assert.Contains(t, v1["/account/{accountId}"], "get")
+	assert.Contains(t, v1["/account/{accountId}"], "patch")
+
+	v2 := read(config.APIVersion2)
+	assert.Contains(t, v2, "/health")
+	assert.NotContains(t, v2["/account/{accountId}"], "get")
+	assert.Contains(t, v2["/account/{accountId}"], "patch")
+}
@@ -0,0 +1,267 @@
+package seed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// Fixture is the content of a fixture file, written in YAML or JSON.
+// The records are matched by their natural keys, so applying a fixture again does not duplicate them.
+// A new domain model is added as a field, and applied in apply after the models it refers to.
+type Fixture struct {
+	Accounts []AccountFixture `yaml:"accounts"`
+}
+
+// AccountFixture represents an account to seed, matched by the loginId.
+type AccountFixture struct {
+	LoginId  string `yaml:"loginId"`
+	Email    string `yaml:"email"`
+	Password string `yaml:"password"`
+	// Authority is "admin" or "user". It is "user" if omitted.
+	Authority string `yaml:"authority"`
+	// Status is "active", "inactive" or "pendingDeletion". It is "active" if omitted.
+	Status string `yaml:"status"`
+	// Roles are the names of the roles to assign. The role of the authority is assigned if omitted.
+	Roles       []string `yaml:"roles"`
+	DisplayName string   `yaml:"displayName"`
+	Locale      string   `yaml:"locale"`
+	TimeZone    string   `yaml:"timeZone"`
+	Bio         string   `yaml:"bio"`
+}
+
+// Mode is how a fixture is applied to the records which already exist.
+type Mode int
+
+const (
+	// CreateMissing creates the records which do not exist, and leaves the existing ones as they are.
+	// The changes made through the API, such as a password change or a lockout, are kept.
+	CreateMissing Mode = iota
+	// Overwrite creates the missing records and updates the existing ones to the fixture.
+	Overwrite
+)
+
+// fixtureExtensions are the extensions of the fixture files. The other files in the directory are ignored.
+var fixtureExtensions = []string{".yml", ".yaml", ".json"}
+
+// Init loads the fixtures at startup if seeding is enabled. Only the missing records are created.
+// They are read from the configured directory, or from the embedded fixtures of the environment if it is empty.
+func Init(container container.Container, embedded fs.FS) {
+	conf := container.GetConfig().Seed
+	if !conf.Enabled {
+		return
+	}
+
+	fsys := embedded
+	dir := path.Join(config.SeedFixturesPath, container.GetEnv())
+	if conf.Dir != "" {
+		fsys = os.DirFS(conf.Dir)
+		dir = "."
+	}
+	sub, err := fs.Sub(fsys, dir)
+	if err == nil {
+		err = Load(context.Background(), container, sub, CreateMissing)
+	}
+	if err != nil {
+		container.GetLogger().GetZapLogger().Errorf("Failed to load the fixtures: %s", err.Error())
+		return
+	}
+	container.GetLogger().GetZapLogger().Infof("Loaded the fixtures")
+}
+
+// Load applies the fixture files of a directory in the order of their names. A missing directory has no fixtures.
+func Load(ctx context.Context, container container.Container, fsys fs.FS, mode Mode) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && isFixtureFile(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if err := Apply(ctx, container, data, mode); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Apply applies a fixture in a transaction. The unknown fields are rejected to find the typos.
+func Apply(ctx context.Context, container container.Container, data []byte, mode Mode) error {
+	fixture := Fixture{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&fixture); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return infrastructure.InTransaction(ctx, container.GetRepository(), func(ctx context.Context) error {
+		return apply(ctx, container, &fixture, mode)
+	})
+}
+
+func apply(ctx context.Context, container container.Container, fixture *Fixture, mode Mode) error {
+	for i := range fixture.Accounts {
+		if err := applyAccount(ctx, container, &fixture.Accounts[i], mode); err != nil {
+			return fmt.Errorf("account %s: %w", fixture.Accounts[i].LoginId, err)
+		}
+	}
+	return nil
+}
+
+// applyAccount creates the account, or updates it to the fixture if the mode is Overwrite.
+// The password is hashed again only if it is changed.
+func applyAccount(ctx context.Context, container container.Container, fixture *AccountFixture, mode Mode) error {
+	if fixture.LoginId == "" || fixture.Email == "" {
+		return errors.New("loginId and email are required")
+	}
+	// the fixtures are also applied outside the develop environment, so their passwords must be as strong as the registered ones
+	if err := model.ValidatePassword(fixture.Password); err != nil {
+		return err
+	}
+	authority, err := parseAuthority(fixture.Authority)
+	if err != nil {
+		return err
+	}
+	status, err := parseStatus(fixture.Status)
+	if err != nil {
+		return err
+	}
+	roleNames := fixture.Roles
+	if len(roleNames) == 0 {
+		roleNames = []string{authority.RoleName()}
+	}
+
+	rep := container.GetRepository().WithContext(ctx)
+	accounts := infrastructure.NewRepo[model.Account](rep)
+	account, err := accounts.FindOne(infrastructure.Where("login_id = ?", fixture.LoginId))
+	if errors.Is(err, infrastructure.ErrNotFound) {
+		account, err = model.NewAccountWithPasswordEncrypt(ctx, fixture.LoginId, fixture.Email, fixture.Password, authority)
+		if err != nil {
+			return err
+		}
+		if err := accounts.Create(account); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if mode == CreateMissing {
+		return nil
+	}
+
+	fields := map[string]interface{}{
+		"email":                 fixture.Email,
+		"authority":             authority,
+		"status":                status,
+		"bad_attempt":           0,
+		"display_name":          fixture.DisplayName,
+		"locale":                fixture.Locale,
+		"time_zone":             fixture.TimeZone,
+		"bio":                   fixture.Bio,
+		"deletion_scheduled_at": nil,
+		"version":               gorm.Expr("version + 1"),
+	}
+	if status == model.StatusPendingDeletion {
+		if account.DeletionScheduledAt != nil {
+			fields["deletion_scheduled_at"] = account.DeletionScheduledAt
+		} else {
+			fields["deletion_scheduled_at"] = time.Now().AddDate(0, 0, deletionGraceDays(container))
+		}
+	}
+	if !account.CheckPassword(ctx, fixture.Password) {
+		hashed, err := model.HashPassword(ctx, fixture.Password)
+		if err != nil {
+			return err
+		}
+		fields["password"] = hashed
+	}
+	if err := rep.Model(&model.Account{}).Where("id = ?", account.ID).Updates(fields).Error; err != nil {
+		return err
+	}
+	return assignRoles(rep, account.ID, roleNames)
+}
+
+// assignRoles replaces the roles of the account with given ones.
+func assignRoles(rep infrastructure.Repository, accountId uint, roleNames []string) error {
+	roles := []model.Role{}
+	if err := rep.Where("name IN ?", roleNames).Find(&roles).Error; err != nil {
+		return err
+	}
+	if len(roles) != len(roleNames) {
+		return fmt.Errorf("unknown role in %s", strings.Join(roleNames, ", "))
+	}
+	if err := rep.Where("account_id = ?", accountId).Delete(&model.AccountRole{}).Error; err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if err := rep.Create(&model.AccountRole{AccountID: accountId, RoleID: role.ID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseAuthority(value string) (model.Authority, error) {
+	if value == "" {
+		return model.AuthorityUser, nil
+	}
+	for _, authority := range []model.Authority{model.AuthorityAdmin, model.AuthorityUser} {
+		if strings.EqualFold(authority.String(), value) {
+			return authority, nil
+		}
+	}
+	return 0, fmt.Errorf("authority %s is not valid", value)
+}
+
+func parseStatus(value string) (model.Status, error) {
+	if value == "" {
+		return model.StatusActive, nil
+	}
+	for _, status := range []model.Status{model.StatusActive, model.StatusInactive, model.StatusPendingDeletion} {
+		if strings.EqualFold(status.String(), value) {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("status %s is not valid", value)
+}
+
+func deletionGraceDays(container container.Container) int {
+	if days := container.GetConfig().Account.DeletionGraceDays; days > 0 {
+		return days
+	}
+	return config.AccountDeletionGraceDays
+}
+
+func isFixtureFile(name string) bool {
+	for _, ext := range fixtureExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
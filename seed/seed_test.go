@@ -0,0 +1,202 @@
+package seed
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/logger"
+	"github.com/onetooler/bistory-backend/migration"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newSeedTestContainer(t *testing.T) container.Container {
+	conf := &config.Config{}
+	conf.Database.Dialect = infrastructure.SQLITE
+	conf.Database.Host = filepath.Join(t.TempDir(), "test.db")
+	log := logger.NewLogger(zap.NewNop().Sugar(), conf)
+	rep := infrastructure.NewRepository(log, conf)
+	t.Cleanup(func() { _ = rep.Close() })
+	container := container.NewContainer(rep, nil, nil, nil, nil, nil, conf, nil, log, "test")
+	assert.Nil(t, migration.Up(container))
+	return container
+}
+
+func findAccount(t *testing.T, container container.Container, loginId string) *model.Account {
+	account := &model.Account{}
+	assert.Nil(t, container.GetRepository().Where("login_id = ?", loginId).Preload("Roles").First(account).Error)
+	return account
+}
+
+const accountsFixture = `
+accounts:
+  - loginId: alice
+    email: alice@example.com
+    password: alicePassword
+    displayName: Alice
+  - loginId: bob
+    email: bob@example.com
+    password: bobPassword
+    authority: admin
+    status: inactive
+  - loginId: carol
+    email: carol@example.com
+    password: carolPassword
+    status: pendingDeletion
+    roles: [support, user]
+`
+
+func TestApply_Accounts(t *testing.T) {
+	container := newSeedTestContainer(t)
+
+	assert.Nil(t, Apply(context.Background(), container, []byte(accountsFixture), Overwrite))
+
+	alice := findAccount(t, container, "alice")
+	assert.Equal(t, "alice@example.com", alice.Email)
+	assert.Equal(t, "Alice", alice.DisplayName)
+	assert.Equal(t, model.AuthorityUser, alice.Authority)
+	assert.Equal(t, model.StatusActive, alice.Status)
+	assert.Equal(t, []string{model.RoleUser}, alice.RoleNames())
+	assert.True(t, alice.CheckPassword(context.Background(), "alicePassword"))
+
+	bob := findAccount(t, container, "bob")
+	assert.Equal(t, model.StatusInactive, bob.Status)
+	assert.Equal(t, []string{model.RoleAdmin}, bob.RoleNames())
+
+	carol := findAccount(t, container, "carol")
+	assert.Equal(t, model.StatusPendingDeletion, carol.Status)
+	assert.NotNil(t, carol.DeletionScheduledAt)
+	assert.ElementsMatch(t, []string{model.RoleSupport, model.RoleUser}, carol.RoleNames())
+}
+
+func TestApply_Idempotent(t *testing.T) {
+	container := newSeedTestContainer(t)
+	assert.Nil(t, Apply(context.Background(), container, []byte(accountsFixture), Overwrite))
+	before := findAccount(t, container, "carol")
+
+	assert.Nil(t, Apply(context.Background(), container, []byte(accountsFixture), Overwrite))
+
+	var accounts, roles int64
+	container.GetRepository().Model(&model.Account{}).Count(&accounts)
+	container.GetRepository().Model(&model.AccountRole{}).Count(&roles)
+	assert.Equal(t, int64(3), accounts)
+	assert.Equal(t, int64(4), roles)
+
+	after := findAccount(t, container, "carol")
+	assert.Equal(t, before.Password, after.Password)
+	assert.Equal(t, before.DeletionScheduledAt.Unix(), after.DeletionScheduledAt.Unix())
+}
+
+func TestApply_UpdatesChangedFixture(t *testing.T) {
+	container := newSeedTestContainer(t)
+	assert.Nil(t, Apply(context.Background(), container, []byte(accountsFixture), Overwrite))
+	before := findAccount(t, container, "carol")
+
+	assert.Nil(t, Apply(context.Background(), container, []byte(`
+accounts:
+  - loginId: carol
+    email: carol@example.org
+    password: carolChanged
+`), Overwrite))
+
+	carol := findAccount(t, container, "carol")
+	assert.Equal(t, "carol@example.org", carol.Email)
+	assert.Equal(t, model.StatusActive, carol.Status)
+	assert.Nil(t, carol.DeletionScheduledAt)
+	assert.Equal(t, []string{model.RoleUser}, carol.RoleNames())
+	assert.True(t, carol.CheckPassword(context.Background(), "carolChanged"))
+	assert.Equal(t, before.Version+1, carol.Version)
+}
+
+func TestApply_CreateMissingKeepsExisting(t *testing.T) {
+	container := newSeedTestContainer(t)
+	assert.Nil(t, Apply(context.Background(), container, []byte(accountsFixture), CreateMissing))
+	// the account is changed through the API after it is seeded.
+	assert.Nil(t, container.GetRepository().Model(&model.Account{}).Where("login_id = ?", "alice").
+		Updates(map[string]interface{}{"status": model.StatusInactive, "bad_attempt": config.MaxLoginAttempts}).Error)
+
+	assert.Nil(t, Apply(context.Background(), container, []byte(`
+accounts:
+  - loginId: alice
+    email: alice@example.org
+    password: aliceChanged
+  - loginId: dave
+    email: dave@example.com
+    password: davePassword
+`), CreateMissing))
+
+	alice := findAccount(t, container, "alice")
+	assert.Equal(t, "alice@example.com", alice.Email)
+	assert.Equal(t, model.StatusInactive, alice.Status)
+	assert.Equal(t, uint(config.MaxLoginAttempts), alice.BadAttempt)
+	assert.True(t, alice.CheckPassword(context.Background(), "alicePassword"))
+	assert.Equal(t, model.StatusActive, findAccount(t, container, "dave").Status)
+}
+
+func TestApply_WeakPasswordFailure(t *testing.T) {
+	container := newSeedTestContainer(t)
+
+	err := Apply(context.Background(), container, []byte(`
+accounts:
+  - loginId: alice
+    email: alice@example.com
+    password: alice
+`), CreateMissing)
+	assert.NotNil(t, err)
+
+	var accounts int64
+	container.GetRepository().Model(&model.Account{}).Where("login_id = ?", "alice").Count(&accounts)
+	assert.Zero(t, accounts)
+}
+
+func TestApply_UnknownFieldFailure(t *testing.T) {
+	container := newSeedTestContainer(t)
+
+	err := Apply(context.Background(), container, []byte(`
+accounts:
+  - loginId: alice
+    email: alice@example.com
+    pasword: alicePassword
+`), Overwrite)
+	assert.NotNil(t, err)
+}
+
+func TestApply_UnknownRoleRollback(t *testing.T) {
+	container := newSeedTestContainer(t)
+
+	err := Apply(context.Background(), container, []byte(`
+accounts:
+  - loginId: alice
+    email: alice@example.com
+    password: alicePassword
+  - loginId: bob
+    email: bob@example.com
+    password: bobPassword
+    roles: [unknown]
+`), Overwrite)
+	assert.NotNil(t, err)
+
+	var count int64
+	container.GetRepository().Model(&model.Account{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestLoad_Directory(t *testing.T) {
+	container := newSeedTestContainer(t)
+	fsys := fstest.MapFS{
+		"01-accounts.yml": {Data: []byte(accountsFixture)},
+		"02-alice.json":   {Data: []byte(`{"accounts": [{"loginId": "alice", "email": "alice@example.com", "password": "alicePassword", "status": "inactive"}]}`)},
+		"README.md":       {Data: []byte("not a fixture")},
+	}
+
+	assert.Nil(t, Load(context.Background(), container, fsys, Overwrite))
+
+	// the files are applied in the order of their names
+	assert.Equal(t, model.StatusInactive, findAccount(t, container, "alice").Status)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+)
+
+// AccessTokenQuerySpec whitelists the fields to sort the access tokens by.
+var AccessTokenQuerySpec = infrastructure.QuerySpec{
+	Sorts:       map[string]string{"id": "id", "name": "name", "createdAt": "created_at"},
+	DefaultSort: "id",
+}
+
+// ErrScopeNotGranted is returned when an access token requests a scope which the caller's token does not have.
+var ErrScopeNotGranted = errors.New("scope can not be granted beyond the scopes of the access token")
+
+// AccessTokenService is a service for managing personal access tokens.
+type AccessTokenService interface {
+	CreateAccessToken(uint, *dto.CreateAccessTokenDto, *infrastructure.Account) (*dto.CreatedAccessTokenDto, error)
+	GetAccessTokens(uint, *infrastructure.Query) (*dto.Page[model.AccessToken], error)
+	RevokeAccessToken(uint, uint) error
+	DeleteExpiredAccessTokens(time.Time) (int64, error)
+}
+
+type accessTokenService struct {
+	container container.Container
+}
+
+// NewAccessTokenService is constructor.
+func NewAccessTokenService(container container.Container) AccessTokenService {
+	return &accessTokenService{container: container}
+}
+
+// CreateAccessToken creates an access token of the account for the caller.
+// If the caller is authenticated by an access token, the new token can only have the scopes of it, otherwise it returns ErrScopeNotGranted.
+// The caller is nil when the token is created by the application itself.
+func (a *accessTokenService) CreateAccessToken(accountId uint, createAccessTokenDto *dto.CreateAccessTokenDto, caller *infrastructure.Account) (*dto.CreatedAccessTokenDto, error) {
+	if err := a.validate(createAccessTokenDto); err != nil {
+		return nil, err
+	}
+	if caller != nil {
+		for _, scope := range createAccessTokenDto.Scopes {
+			if !caller.HasScope(scope) {
+				return nil, ErrScopeNotGranted
+			}
+		}
+	}
+
+	if _, err := infrastructure.NewRepo[model.Account](a.container.GetRepository()).FindByID(accountId); err != nil {
+		return nil, err
+	}
+
+	token, plain := model.NewAccessToken(accountId, createAccessTokenDto.Name, createAccessTokenDto.Scopes, createAccessTokenDto.ExpiresAt)
+	if err := a.accessTokens().Create(token); err != nil {
+		return nil, err
+	}
+
+	return &dto.CreatedAccessTokenDto{AccessToken: token, Token: plain}, nil
+}
+
+func (a *accessTokenService) GetAccessTokens(accountId uint, query *infrastructure.Query) (*dto.Page[model.AccessToken], error) {
+	return a.accessTokens().List(query, infrastructure.Where("account_id = ?", accountId))
+}
+
+// RevokeAccessToken revokes the access token of the account, or returns infrastructure.ErrNotFound.
+func (a *accessTokenService) RevokeAccessToken(accountId uint, tokenId uint) error {
+	return a.accessTokens().SoftDelete(tokenId, infrastructure.Where("account_id = ?", accountId))
+}
+
+// DeleteExpiredAccessTokens revokes the access tokens expired at a given time and returns the number of them.
+func (a *accessTokenService) DeleteExpiredAccessTokens(now time.Time) (int64, error) {
+	repo := a.container.GetRepository()
+
+	tx := repo.Where("expires_at IS NOT NULL AND expires_at <= ?", now).Delete(&model.AccessToken{})
+	return tx.RowsAffected, tx.Error
+}
+
+// accessTokens returns the typed repository of the access tokens.
+func (a *accessTokenService) accessTokens() *infrastructure.Repo[model.AccessToken] {
+	return infrastructure.NewRepo[model.AccessToken](a.container.GetRepository())
+}
+
+// validate checks the request again, since the service may be called without binding a request.
+func (a *accessTokenService) validate(createAccessTokenDto *dto.CreateAccessTokenDto) error {
+	if errs := createAccessTokenDto.Validate(); errs != nil {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAccessToken_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	savedAccount := createSuccessAccount(NewAccountService(container))
+	service := NewAccessTokenService(container)
+
+	expiresAt := time.Now().Add(time.Hour)
+	createDto := dto.CreateAccessTokenDto{
+		Name:      "script",
+		Scopes:    []string{model.ScopeAccountRead},
+		ExpiresAt: &expiresAt,
+	}
+	token, err := service.CreateAccessToken(savedAccount.ID, &createDto, nil)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, token.ID)
+	assert.True(t, strings.HasPrefix(token.Token, config.AccessTokenPrefix))
+	assert.True(t, strings.HasPrefix(token.Token, token.Prefix))
+
+	// only the hash is stored
+	data := model.AccessToken{}
+	container.GetRepository().First(&data, token.ID)
+	assert.Equal(t, model.HashAccessToken(token.Token), data.TokenHash)
+	assert.NotContains(t, data.TokenHash, token.Token)
+	assert.Equal(t, model.Scopes{model.ScopeAccountRead}, data.Scopes)
+	assert.Nil(t, data.LastUsedAt)
+}
+
+func TestCreateAccessToken_InvalidScopeFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	savedAccount := createSuccessAccount(NewAccountService(container))
+	service := NewAccessTokenService(container)
+
+	createDto := dto.CreateAccessTokenDto{
+		Name:   "script",
+		Scopes: []string{"account:everything"},
+	}
+	token, err := service.CreateAccessToken(savedAccount.ID, &createDto, nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, token)
+}
+
+func TestCreateAccessToken_ScopeNotGrantedFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	savedAccount := createSuccessAccount(NewAccountService(container))
+	service := NewAccessTokenService(container)
+
+	caller := &infrastructure.Account{Id: savedAccount.ID, AccessTokenId: 1, Scopes: []string{model.ScopeTokenWrite}}
+	createDto := dto.CreateAccessTokenDto{
+		Name:   "script",
+		Scopes: []string{model.ScopeTokenWrite, model.ScopeAccountWrite},
+	}
+	token, err := service.CreateAccessToken(savedAccount.ID, &createDto, caller)
+	assert.ErrorIs(t, err, ErrScopeNotGranted)
+	assert.Nil(t, token)
+
+	createDto.Scopes = []string{model.ScopeTokenWrite}
+	token, err = service.CreateAccessToken(savedAccount.ID, &createDto, caller)
+	assert.Nil(t, err)
+	assert.NotNil(t, token)
+}
+
+func TestCreateAccessToken_PastExpiryFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	savedAccount := createSuccessAccount(NewAccountService(container))
+	service := NewAccessTokenService(container)
+
+	expiresAt := time.Now().Add(-time.Hour)
+	createDto := dto.CreateAccessTokenDto{
+		Name:      "script",
+		Scopes:    []string{model.ScopeAccountRead},
+		ExpiresAt: &expiresAt,
+	}
+	token, err := service.CreateAccessToken(savedAccount.ID, &createDto, nil)
+	assert.NotNil(t, err)
+	assert.Nil(t, token)
+}
+
+func TestGetAccessTokens_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	savedAccount := createSuccessAccount(NewAccountService(container))
+	service := NewAccessTokenService(container)
+
+	for _, name := range []string{"first", "second"} {
+		_, err := service.CreateAccessToken(savedAccount.ID, &dto.CreateAccessTokenDto{Name: name, Scopes: []string{model.ScopeAccountRead}}, nil)
+		assert.Nil(t, err)
+	}
+
+	tokens, err := service.GetAccessTokens(savedAccount.ID, parseQuery(t, AccessTokenQuerySpec, ""))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), tokens.Total)
+	assert.Len(t, tokens.Items, 2)
+	assert.Equal(t, "first", tokens.Items[0].Name)
+	assert.Equal(t, "second", tokens.Items[1].Name)
+
+	tokens, err = service.GetAccessTokens(savedAccount.ID, parseQuery(t, AccessTokenQuerySpec, "sort=-name"))
+	assert.Nil(t, err)
+	assert.Equal(t, "second", tokens.Items[0].Name)
+}
+
+func TestRevokeAccessToken_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	savedAccount := createSuccessAccount(NewAccountService(container))
+	service := NewAccessTokenService(container)
+
+	token, _ := service.CreateAccessToken(savedAccount.ID, &dto.CreateAccessTokenDto{Name: "script", Scopes: []string{model.ScopeAccountRead}}, nil)
+	err := service.RevokeAccessToken(savedAccount.ID, token.ID)
+	assert.Nil(t, err)
+
+	tokens, err := service.GetAccessTokens(savedAccount.ID, parseQuery(t, AccessTokenQuerySpec, ""))
+	assert.Nil(t, err)
+	assert.Empty(t, tokens.Items)
+}
+
+func TestRevokeAccessToken_OtherAccountFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	savedAccount := createSuccessAccount(NewAccountService(container))
+	service := NewAccessTokenService(container)
+
+	token, _ := service.CreateAccessToken(savedAccount.ID, &dto.CreateAccessTokenDto{Name: "script", Scopes: []string{model.ScopeAccountRead}}, nil)
+	err := service.RevokeAccessToken(savedAccount.ID+1, token.ID)
+	assert.ErrorIs(t, err, infrastructure.ErrNotFound)
+}
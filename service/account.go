@@ -1,24 +1,51 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // for validating time zones without the zoneinfo of the system
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/onetooler/bistory-backend/config"
 	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
 	"github.com/onetooler/bistory-backend/model"
 	"github.com/onetooler/bistory-backend/model/dto"
-	"gorm.io/gorm/clause"
+	"github.com/onetooler/bistory-backend/util"
+	"golang.org/x/text/language"
+	"gorm.io/gorm"
 )
 
 // AccountService is a service for managing user account.
 type AccountService interface {
-	CreateAccount(*dto.CreateAccountDto) (*model.Account, error)
-	GetAccount(uint) (*model.Account, error)
-	ChangeAccountPassword(uint, *dto.ChangeAccountPasswordDto) (*model.Account, error)
-	DeleteAccount(uint, *dto.DeleteAccountDto) error
-	FindAccountByEmail(*dto.FindLoginIdDto) error
+	CreateAccount(context.Context, *dto.CreateAccountDto) (*model.Account, error)
+	CreateAdminAccount(context.Context, *dto.CreateAccountDto) (*model.Account, error)
+	GetAccount(context.Context, uint) (*model.Account, error)
+	GetAccounts(context.Context, *infrastructure.Query) (*dto.Page[model.Account], error)
+	ChangeAccountPassword(context.Context, uint, *dto.ChangeAccountPasswordDto) (*model.Account, error)
+	UpdateAccountProfile(context.Context, uint, *dto.UpdateAccountProfileDto) (*model.Account, error)
+	DeleteAccount(context.Context, uint, *dto.DeleteAccountDto) error
+	RestoreAccount(context.Context, *dto.RestoreAccountDto) (*model.Account, error)
+	PurgeAccounts(context.Context, time.Time) (int, error)
+	FindAccountByEmail(context.Context, *dto.FindLoginIdDto) error
+	UnlockAccount(context.Context, string) error
+	SetAccountPassword(context.Context, string, string) error
 }
 
+// AccountQuerySpec whitelists the fields to filter and sort the accounts by.
+var AccountQuerySpec = infrastructure.QuerySpec{
+	Filters:     map[string]string{"status": "status", "authority": "authority"},
+	Sorts:       map[string]string{"id": "id", "loginId": "login_id", "createdAt": "created_at"},
+	DefaultSort: "id",
+}
+
+// ErrVersionConflict is returned when the account has been updated after the client read it.
+var ErrVersionConflict = errors.New("account has been modified by another request")
+
 type accountService struct {
 	container container.Container
 }
@@ -28,28 +55,51 @@ func NewAccountService(container container.Container) AccountService {
 	return &accountService{container: container}
 }
 
-func (a *accountService) CreateAccount(createAccountDto *dto.CreateAccountDto) (*model.Account, error) {
-	// loginId validation
-	exists, err := a.existsByLoginId(createAccountDto.LoginId)
-	if err != nil {
+func (a *accountService) CreateAccount(ctx context.Context, createAccountDto *dto.CreateAccountDto) (*model.Account, error) {
+	return a.createAccount(ctx, createAccountDto, model.AuthorityUser)
+}
+
+// CreateAdminAccount creates the account which has the admin role, such as by the command line.
+func (a *accountService) CreateAdminAccount(ctx context.Context, createAccountDto *dto.CreateAccountDto) (*model.Account, error) {
+	return a.createAccount(ctx, createAccountDto, model.AuthorityAdmin)
+}
+
+func (a *accountService) createAccount(ctx context.Context, createAccountDto *dto.CreateAccountDto, authority model.Authority) (*model.Account, error) {
+	// loginId, email and password validation
+	if err := model.ValidateLoginId(createAccountDto.LoginId); err != nil {
 		return nil, err
 	}
-	if exists {
-		return nil, fmt.Errorf("loginId %s already exists", createAccountDto.LoginId)
+	if err := model.ValidateEmail(createAccountDto.Email); err != nil {
+		return nil, err
 	}
-
-	// password validation
 	if err := a.validatePassword(createAccountDto.Password); err != nil {
 		return nil, err
 	}
 
 	// create account
-	account, err := model.NewAccountWithPasswordEncrypt(createAccountDto.LoginId, createAccountDto.Email, createAccountDto.Password, model.AuthorityUser)
+	account, err := model.NewAccountWithPasswordEncrypt(ctx, createAccountDto.LoginId, createAccountDto.Email, createAccountDto.Password, authority)
 	if err != nil {
 		return nil, fmt.Errorf("create account failed: %s", err.Error())
 	}
 
-	err = a.create(account)
+	err = infrastructure.InTransaction(ctx, a.container.GetRepository(), func(ctx context.Context) error {
+		// loginId validation
+		exists, err := a.existsByLoginId(ctx, createAccountDto.LoginId)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("loginId %s %w", createAccountDto.LoginId, infrastructure.ErrAlreadyExists)
+		}
+
+		// the unique constraint rejects the account created concurrently after the check
+		if err := a.create(ctx, account); errors.Is(err, infrastructure.ErrAlreadyExists) {
+			return fmt.Errorf("loginId %s or email %w", createAccountDto.LoginId, err)
+		} else if err != nil {
+			return err
+		}
+		return NewRoleService(a.container).AssignRole(ctx, account.ID, account.Authority.RoleName())
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -57,25 +107,22 @@ func (a *accountService) CreateAccount(createAccountDto *dto.CreateAccountDto) (
 	return account, nil
 }
 
-func (a *accountService) GetAccount(id uint) (*model.Account, error) {
-	repo := a.container.GetRepository()
-
-	account := model.Account{}
-	tx := repo.First(&account, id)
-	if tx.Error != nil {
-		return nil, tx.Error
-	}
+// GetAccount returns the account of a given id, or infrastructure.ErrNotFound.
+func (a *accountService) GetAccount(ctx context.Context, id uint) (*model.Account, error) {
+	return a.accounts(ctx).FindByID(id)
+}
 
-	return &account, nil
+func (a *accountService) GetAccounts(ctx context.Context, query *infrastructure.Query) (*dto.Page[model.Account], error) {
+	return a.accounts(ctx).List(query)
 }
 
-func (a *accountService) ChangeAccountPassword(id uint, changeAccountPasswordDto *dto.ChangeAccountPasswordDto) (*model.Account, error) {
+func (a *accountService) ChangeAccountPassword(ctx context.Context, id uint, changeAccountPasswordDto *dto.ChangeAccountPasswordDto) (*model.Account, error) {
 	// OldPassword validation
-	account, err := a.GetAccount(id)
+	account, err := a.GetAccount(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	ok := account.CheckPassword(changeAccountPasswordDto.OldPassword)
+	ok := account.CheckPassword(ctx, changeAccountPasswordDto.OldPassword)
 	if !ok {
 		return nil, fmt.Errorf("old password is not valid")
 	}
@@ -85,79 +132,346 @@ func (a *accountService) ChangeAccountPassword(id uint, changeAccountPasswordDto
 		return nil, err
 	}
 
-	return a.updatePassword(id, changeAccountPasswordDto.NewPassword)
+	return a.updatePassword(ctx, account, changeAccountPasswordDto.NewPassword, changeAccountPasswordDto.Version)
 }
 
-func (a *accountService) DeleteAccount(id uint, deleteAccountDto *dto.DeleteAccountDto) error {
-	account, err := a.GetAccount(id)
+func (a *accountService) UpdateAccountProfile(ctx context.Context, id uint, updateAccountProfileDto *dto.UpdateAccountProfileDto) (*model.Account, error) {
+	fields, err := a.profileFields(updateAccountProfileDto)
+	if err != nil {
+		return nil, err
+	}
+	fields["version"] = gorm.Expr("version + 1")
+
+	repo := a.container.GetRepository().WithContext(ctx)
+	tx := repo.Model(&model.Account{}).Where("id = ? AND version = ?", id, updateAccountProfileDto.Version).Updates(fields)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		if _, err := a.primaryAccounts(ctx).FindByID(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrVersionConflict
+	}
+
+	return a.primaryAccounts(ctx).FindByID(id)
+}
+
+// DeleteAccount schedules the deletion of account after the grace period.
+// Until then, the account can be restored by logging in or by the restore token sent by email.
+func (a *accountService) DeleteAccount(ctx context.Context, id uint, deleteAccountDto *dto.DeleteAccountDto) error {
+	account, err := a.GetAccount(ctx, id)
 	if err != nil {
 		return err
 	}
-	ok := account.CheckPassword(deleteAccountDto.Password)
+	if account.IsPendingDeletion() {
+		return fmt.Errorf("account is already pending deletion")
+	}
+	ok := account.CheckPassword(ctx, deleteAccountDto.Password)
 	if !ok {
 		return fmt.Errorf("password is not valid")
 	}
 
-	if err := a.container.GetRepository().Delete(account).Error; err != nil {
+	token := util.RandomBase16String(config.AccountRestoreTokenLength)
+	scheduledAt := time.Now().AddDate(0, 0, a.deletionGraceDays())
+	err = a.updateAtVersion(ctx, account, deleteAccountDto.Version, map[string]interface{}{
+		"status":                model.StatusPendingDeletion,
+		"deletion_scheduled_at": scheduledAt,
+		"restore_token_hash":    model.HashRestoreToken(token),
+		"version":               gorm.Expr("version + 1"),
+	})
+	if err != nil {
 		return err
 	}
+
+	// the email is not sent if the caller rolls back the deletion
+	infrastructure.AfterCommit(ctx, func() { a.sendRestoreEmail(ctx, account, token, scheduledAt) })
 	return nil
 }
 
-func (a *accountService) FindAccountByEmail(findLoginIdDto *dto.FindLoginIdDto) error {
-	repo := a.container.GetRepository()
-	emailSender := a.container.GetEmailSender()
+// RestoreAccount cancels the deletion of the account of a given restore token.
+func (a *accountService) RestoreAccount(ctx context.Context, restoreAccountDto *dto.RestoreAccountDto) (*model.Account, error) {
+	account, err := a.accounts(ctx).FindOne(infrastructure.Where("restore_token_hash = ? AND status = ?", model.HashRestoreToken(restoreAccountDto.Token), model.StatusPendingDeletion))
+	if err != nil || restoreAccountDto.Token == "" {
+		return nil, fmt.Errorf("restore token is not valid")
+	}
+	err = infrastructure.InTransaction(ctx, a.container.GetRepository(), func(ctx context.Context) error {
+		if err := restoreAccount(a.container.GetRepository().WithContext(ctx), account); err != nil {
+			return err
+		}
+		account, err = a.GetAccount(ctx, account.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
 
-	account := model.Account{Email: findLoginIdDto.Email}
-	tx := repo.Where(&account).Take(&account)
+// PurgeAccounts anonymises the accounts whose deletion grace period has passed and deletes their related data.
+// It returns the number of purged accounts. The accounts which fail to be purged are logged and retried by the next run.
+func (a *accountService) PurgeAccounts(ctx context.Context, now time.Time) (int, error) {
+	repo := a.container.GetRepository().WithContext(ctx)
+
+	accounts := []model.Account{}
+	tx := repo.Where("status = ? AND deletion_scheduled_at <= ?", model.StatusPendingDeletion, now).Find(&accounts)
 	if tx.Error != nil {
-		return tx.Error
+		return 0, tx.Error
+	}
+
+	// an account which fails to be purged is skipped, so that it does not block the others
+	purged := 0
+	for i := range accounts {
+		if err := a.purge(ctx, &accounts[i]); err != nil {
+			a.container.GetLogger().GetZapLoggerFromContext(ctx).Errorf("Failed to purge the account %d: %s", accounts[i].ID, err.Error())
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func (a *accountService) FindAccountByEmail(ctx context.Context, findLoginIdDto *dto.FindLoginIdDto) error {
+	emailSender := a.container.GetEmailSender()
+
+	account, err := a.accounts(ctx).FindOne(infrastructure.Where("email = ?", findLoginIdDto.Email))
+	if err != nil {
+		return err
 	}
 	// TODO: Change to Constant
 	subject := "[Bistory] 아이디 찾기 결과"
-	return emailSender.SendEmail(account.Email, subject, config.FindLoginIdTemplate, account.LoginId)
+	return emailSender.SendEmail(ctx, account.Email, subject, config.FindLoginIdTemplate, account.LoginId)
 }
 
-// TODO: Need to review whether to change to ORM style call.
-const existsAccount = "SELECT EXISTS (SELECT 1 FROM account WHERE id = ?);"
+// UnlockAccount activates the account locked by the failed logins, and clears its counter.
+func (a *accountService) UnlockAccount(ctx context.Context, loginId string) error {
+	tx := a.container.GetRepository().WithContext(ctx).Model(&model.Account{}).
+		Where("login_id = ? AND status IN ?", loginId, []model.Status{model.StatusActive, model.StatusInactive}).
+		Updates(map[string]interface{}{"status": model.StatusActive, "bad_attempt": 0, "version": gorm.Expr("version + 1")})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return fmt.Errorf("account %s is not found or not lockable", loginId)
+	}
+	return nil
+}
 
-func (a *accountService) existsByLoginId(loginId string) (bool, error) {
-	repo := a.container.GetRepository()
+// SetAccountPassword replaces the password of the account without the old one, such as by the command line.
+func (a *accountService) SetAccountPassword(ctx context.Context, loginId string, password string) error {
+	if err := a.validatePassword(password); err != nil {
+		return err
+	}
+	account, err := a.accounts(ctx).FindOne(infrastructure.Where("login_id = ?", loginId))
+	if err != nil {
+		return err
+	}
+	_, err = a.updatePassword(ctx, account, password, 0)
+	return err
+}
 
-	exists := false
-	tx := repo.Raw(existsAccount, loginId).Scan(&exists)
+// accounts returns the typed repository of the accounts, whose operations are canceled with a given context.
+func (a *accountService) accounts(ctx context.Context) *infrastructure.Repo[model.Account] {
+	return infrastructure.NewRepo[model.Account](a.container.GetRepository().WithContext(ctx))
+}
 
-	return exists, tx.Error
+// primaryAccounts returns the typed repository of the accounts which reads from the primary database,
+// to return the account just updated.
+func (a *accountService) primaryAccounts(ctx context.Context) *infrastructure.Repo[model.Account] {
+	return infrastructure.NewRepo[model.Account](a.container.GetRepository().WithContext(ctx).Primary())
 }
 
-func (a *accountService) create(account *model.Account) error {
-	repo := a.container.GetRepository()
+func (a *accountService) existsByLoginId(ctx context.Context, loginId string) (bool, error) {
+	return a.accounts(ctx).Exists(infrastructure.Where("login_id = ?", loginId))
+}
 
-	if err := repo.Create(account).Error; err != nil {
-		return err
+func (a *accountService) create(ctx context.Context, account *model.Account) error {
+	return a.accounts(ctx).Create(account)
+}
+
+func (a *accountService) updatePassword(ctx context.Context, account *model.Account, password string, version uint) (*model.Account, error) {
+	hashed, err := model.HashPassword(ctx, password)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	if err := a.updateAtVersion(ctx, account, version, map[string]interface{}{"password": hashed, "must_change_password": false, "version": gorm.Expr("version + 1")}); err != nil {
+		return nil, err
+	}
+
+	return a.primaryAccounts(ctx).FindByID(account.ID)
+}
+
+// updateAtVersion updates the columns of the account only if it is still at a given version, or returns ErrVersionConflict.
+// A zero version updates the account at any version.
+func (a *accountService) updateAtVersion(ctx context.Context, account *model.Account, version uint, values map[string]interface{}) error {
+	if version == 0 {
+		return a.accounts(ctx).Update(account, values)
+	}
+	err := a.accounts(ctx).Update(account, values, infrastructure.Where("version = ?", version))
+	if errors.Is(err, infrastructure.ErrNotFound) {
+		return ErrVersionConflict
+	}
+	return err
 }
 
-func (a *accountService) updatePassword(id uint, password string) (*model.Account, error) {
-	repo := a.container.GetRepository()
+// profileFields validates the profile fields to update and returns them by column.
+func (a *accountService) profileFields(updateAccountProfileDto *dto.UpdateAccountProfileDto) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if v := updateAccountProfileDto.DisplayName; v != nil {
+		name := strings.TrimSpace(*v)
+		if utf8.RuneCountInString(name) > config.DisplayNameMaxLength {
+			return nil, fmt.Errorf("displayName must be at most %d characters", config.DisplayNameMaxLength)
+		}
+		if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+			return nil, fmt.Errorf("displayName must not contain control characters")
+		}
+		fields["display_name"] = name
+	}
+	if v := updateAccountProfileDto.Locale; v != nil {
+		locale := *v
+		if locale != "" {
+			tag, err := language.Parse(locale)
+			if err != nil {
+				return nil, fmt.Errorf("locale %s is not valid", locale)
+			}
+			locale = tag.String()
+		}
+		fields["locale"] = locale
+	}
+	if v := updateAccountProfileDto.TimeZone; v != nil {
+		if *v != "" {
+			if _, err := time.LoadLocation(*v); err != nil || *v == "Local" {
+				return nil, fmt.Errorf("timeZone %s is not valid", *v)
+			}
+		}
+		fields["time_zone"] = *v
+	}
+	if v := updateAccountProfileDto.Bio; v != nil {
+		if utf8.RuneCountInString(*v) > config.BioMaxLength {
+			return nil, fmt.Errorf("bio must be at most %d characters", config.BioMaxLength)
+		}
+		fields["bio"] = *v
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no profile field to update")
+	}
+	if updateAccountProfileDto.Version == 0 {
+		return nil, fmt.Errorf("version is required")
+	}
+	return fields, nil
+}
 
-	account := model.Account{}
-	account.ID = id
-	tx := repo.Model(&account).Clauses(clause.Returning{}).Update("password", password)
-	if tx.Error != nil {
-		return nil, tx.Error
+func (a *accountService) deletionGraceDays() int {
+	if days := a.container.GetConfig().Account.DeletionGraceDays; days > 0 {
+		return days
 	}
+	return config.AccountDeletionGraceDays
+}
 
-	return &account, nil
+// sendRestoreEmail sends the restore token of the account pending deletion.
+// The failure is only logged, because the account can also be restored by logging in.
+func (a *accountService) sendRestoreEmail(ctx context.Context, account *model.Account, token string, scheduledAt time.Time) {
+	restoreURL := ""
+	if base := a.container.GetConfig().Account.RestoreURL; base != "" {
+		restoreURL = base + "?token=" + token
+	}
+	body := map[string]string{
+		"LoginId":     account.LoginId,
+		"Token":       token,
+		"URL":         restoreURL,
+		"ScheduledAt": scheduledAt.Format("2006-01-02 15:04"),
+	}
+	// TODO: Change to Constant
+	subject := "[Bistory] 계정 삭제 예정 안내"
+	if err := a.container.GetEmailSender().SendEmail(ctx, account.Email, subject, config.AccountRestoreTemplate, body); err != nil {
+		a.container.GetLogger().GetZapLoggerFromContext(ctx).Errorf("Failed to send the restore email of account %d: %s", account.ID, err.Error())
+	}
 }
 
-func (a *accountService) validatePassword(password string) error {
-	if len(password) < 8 {
-		return fmt.Errorf("password must be at least 8 characters")
+// purge anonymises the account so that its loginId and email can be registered again, and deletes its related data.
+func (a *accountService) purge(ctx context.Context, account *model.Account) error {
+	return infrastructure.InTransaction(ctx, a.container.GetRepository(), func(ctx context.Context) error {
+		tx := a.container.GetRepository().WithContext(ctx)
+
+		exports := []model.DataExport{}
+		if err := tx.Where("account_id = ?", account.ID).Find(&exports).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("account_id = ?", account.ID).Unscoped().Delete(&model.DataExport{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("account_id = ?", account.ID).Unscoped().Delete(&model.AccessToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("account_id = ?", account.ID).Delete(&model.AccountRole{}).Error; err != nil {
+			return err
+		}
+		anonymised := tx.Model(&model.Account{}).Where("id = ? AND status = ?", account.ID, model.StatusPendingDeletion).Updates(map[string]interface{}{
+			"login_id":              model.PurgedLoginId(account.ID),
+			"email":                 fmt.Sprintf("deleted-%d@deleted.invalid", account.ID),
+			"password":              "",
+			"display_name":          "",
+			"locale":                "",
+			"time_zone":             "",
+			"bio":                   "",
+			"avatar":                "",
+			"restore_token_hash":    "",
+			"deletion_scheduled_at": nil,
+			"status":                model.StatusPurged,
+			"version":               gorm.Expr("version + 1"),
+		})
+		if anonymised.Error != nil {
+			return anonymised.Error
+		}
+		if anonymised.RowsAffected == 0 {
+			return fmt.Errorf("account %d has been restored", account.ID)
+		}
+		if err := tx.Delete(account).Error; err != nil {
+			return err
+		}
+
+		// the files are deleted only after the records are gone
+		infrastructure.AfterCommit(ctx, func() {
+			if account.Avatar != "" {
+				(&avatarService{container: a.container}).deleteFiles(account.Avatar)
+			}
+			for _, export := range exports {
+				if export.FileKey == "" {
+					continue
+				}
+				if err := a.container.GetStorage().Delete(export.FileKey); err != nil {
+					a.container.GetLogger().GetZapLoggerFromContext(ctx).Errorf("Failed to delete the export %d: %s", export.ID, err.Error())
+				}
+			}
+			a.container.GetLogger().GetZapLoggerFromContext(ctx).Infof("Purged the account %d", account.ID)
+		})
+		return nil
+	})
+}
+
+// restoreAccount cancels the deletion of a given account pending deletion.
+func restoreAccount(repo infrastructure.Repository, account *model.Account) error {
+	tx := repo.Model(&model.Account{}).Where("id = ? AND status = ?", account.ID, model.StatusPendingDeletion).Updates(map[string]interface{}{
+		"status":                model.StatusActive,
+		"deletion_scheduled_at": nil,
+		"restore_token_hash":    "",
+		"version":               gorm.Expr("version + 1"),
+	})
+	if tx.Error != nil {
+		return tx.Error
 	}
-	if len(password) > 72 {
-		return fmt.Errorf("password must be at most 72 characters")
+	if tx.RowsAffected == 0 {
+		return fmt.Errorf("account is not pending deletion")
 	}
+	account.Status = model.StatusActive
+	account.DeletionScheduledAt = nil
+	account.RestoreTokenHash = ""
+	account.Version++
 	return nil
 }
+
+// validatePassword checks the rules of a new password, which are shared with the validation of the requests.
+func (a *accountService) validatePassword(password string) error {
+	return model.ValidatePassword(password)
+}
@@ -1,9 +1,18 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	smtpmock "github.com/mocktools/go-smtp-mock/v2"
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
 	"github.com/onetooler/bistory-backend/model"
 	"github.com/onetooler/bistory-backend/model/dto"
 	"github.com/onetooler/bistory-backend/testutil"
@@ -20,7 +29,7 @@ func TestAccountCreate_Success(t *testing.T) {
 		Email:    "newTest@example.com",
 		Password: "newTestTest",
 	}
-	account, err := service.CreateAccount(&createDto)
+	account, err := service.CreateAccount(context.Background(), &createDto)
 	assert.Nil(t, err)
 
 	// auto-generated check
@@ -33,7 +42,7 @@ func TestAccountCreate_Success(t *testing.T) {
 	// equal check
 	assert.Equal(t, createDto.LoginId, account.LoginId)
 	assert.Equal(t, createDto.Email, account.Email)
-	assert.True(t, account.CheckPassword(createDto.Password))
+	assert.True(t, account.CheckPassword(context.Background(), createDto.Password))
 }
 
 func TestAccountCreate_WrongPasswordFailure(t *testing.T) {
@@ -45,17 +54,107 @@ func TestAccountCreate_WrongPasswordFailure(t *testing.T) {
 		Email:    "newTest@example.com",
 		Password: "newTest",
 	}
-	account, err := service.CreateAccount(&createDto)
+	account, err := service.CreateAccount(context.Background(), &createDto)
 	assert.NotNil(t, err)
 	assert.Nil(t, account)
 }
 
+func TestAccountCreate_InvalidLoginIdFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+
+	for _, loginId := range []string{"new test", "-newTest", "뉴테스트", strings.Repeat("a", config.LoginIdMaxLength+1)} {
+		createDto := dto.CreateAccountDto{
+			LoginId:  loginId,
+			Email:    "newTest@example.com",
+			Password: "newTestTest",
+		}
+		account, err := service.CreateAccount(context.Background(), &createDto)
+		assert.NotNil(t, err, loginId)
+		assert.Nil(t, account)
+	}
+}
+
+func TestAccountCreate_InvalidEmailFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+
+	for _, email := range []string{"newTest", "newTest@", "New Test <newTest@example.com>"} {
+		createDto := dto.CreateAccountDto{
+			LoginId:  "newTest",
+			Email:    email,
+			Password: "newTestTest",
+		}
+		account, err := service.CreateAccount(context.Background(), &createDto)
+		assert.EqualError(t, err, fmt.Sprintf("email %s is not valid", email))
+		assert.Nil(t, account)
+	}
+}
+
+func TestAccountCreate_DuplicateLoginIdFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	createSuccessAccount(service)
+
+	createDto := dto.CreateAccountDto{
+		LoginId:  "newTest",
+		Email:    "other@example.com",
+		Password: "newTestTest",
+	}
+	account, err := service.CreateAccount(context.Background(), &createDto)
+	assert.EqualError(t, err, "loginId newTest already exists")
+	assert.Nil(t, account)
+}
+
+func TestAccountCreate_DuplicateEmailFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	createSuccessAccount(service)
+
+	createDto := dto.CreateAccountDto{
+		LoginId:  "otherTest",
+		Email:    "newTest@example.com",
+		Password: "newTestTest",
+	}
+	account, err := service.CreateAccount(context.Background(), &createDto)
+	assert.True(t, errors.Is(err, infrastructure.ErrAlreadyExists))
+	assert.Nil(t, account)
+
+	exists, _ := infrastructure.NewRepo[model.Account](container.GetRepository()).Exists(infrastructure.Where("login_id = ?", "otherTest"))
+	assert.False(t, exists)
+}
+
+func TestAccountCreate_LoginIdSameAsIdSuccess(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+
+	// the loginId is not compared with the ids of the other accounts.
+	createDto := dto.CreateAccountDto{
+		LoginId:  fmt.Sprint(savedAccount.ID),
+		Email:    "other@example.com",
+		Password: "newTestTest",
+	}
+	account, err := service.CreateAccount(context.Background(), &createDto)
+	assert.Nil(t, err)
+	assert.Equal(t, createDto.LoginId, account.LoginId)
+}
+
+func TestAccountGet_NotFoundFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+
+	account, err := service.GetAccount(context.Background(), 9999)
+	assert.ErrorIs(t, err, infrastructure.ErrNotFound)
+	assert.Nil(t, account)
+}
+
 func TestAccountGet_Success(t *testing.T) {
 	container := testutil.PrepareForServiceTest(false)
 	service := NewAccountService(container)
 	savedAccount := createSuccessAccount(service)
 
-	account, err := service.GetAccount(savedAccount.ID)
+	account, err := service.GetAccount(context.Background(), savedAccount.ID)
 	assert.Nil(t, err)
 
 	account.CreatedAt = account.CreatedAt.Local()
@@ -67,7 +166,7 @@ func TestAccountGet_NotExistsIdFailure(t *testing.T) {
 	container := testutil.PrepareForServiceTest(false)
 	service := NewAccountService(container)
 
-	account, err := service.GetAccount(uint(999))
+	account, err := service.GetAccount(context.Background(), uint(999))
 	assert.NotNil(t, err)
 	assert.Nil(t, account)
 }
@@ -82,10 +181,13 @@ func TestChangeAccountPassword_Success(t *testing.T) {
 		OldPassword: "newTestTest",
 		NewPassword: "newTestTestTest",
 	}
-	account, err := service.ChangeAccountPassword(savedAccount.ID, &changeAccountPasswordDto)
+	account, err := service.ChangeAccountPassword(context.Background(), savedAccount.ID, &changeAccountPasswordDto)
 	assert.Nil(t, err)
 	assert.NotNil(t, account)
 	assert.NotEqual(t, savedAccount.UpdatedAt, account.UpdatedAt)
+	assert.Equal(t, savedAccount.Version+1, account.Version)
+	assert.True(t, account.CheckPassword(context.Background(), changeAccountPasswordDto.NewPassword))
+	assert.False(t, account.CheckPassword(context.Background(), changeAccountPasswordDto.OldPassword))
 }
 
 func TestChangeAccountPassword_WrongPasswordFailure(t *testing.T) {
@@ -98,9 +200,166 @@ func TestChangeAccountPassword_WrongPasswordFailure(t *testing.T) {
 		OldPassword: "newTestTest",
 		NewPassword: "new",
 	}
-	account, err := service.ChangeAccountPassword(savedAccount.ID, &changeAccountPasswordDto)
+	account, err := service.ChangeAccountPassword(context.Background(), savedAccount.ID, &changeAccountPasswordDto)
+	assert.NotNil(t, err)
+	assert.Nil(t, account)
+}
+
+func TestChangeAccountPassword_VersionConflictFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+
+	// the account has been updated since the client matched its version
+	changeAccountPasswordDto := dto.ChangeAccountPasswordDto{
+		OldPassword: "newTestTest",
+		NewPassword: "newTestTestTest",
+		Version:     savedAccount.Version + 1,
+	}
+	account, err := service.ChangeAccountPassword(context.Background(), savedAccount.ID, &changeAccountPasswordDto)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Nil(t, account)
+
+	account, err = service.GetAccount(context.Background(), savedAccount.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, savedAccount.Version, account.Version)
+	assert.True(t, account.CheckPassword(context.Background(), changeAccountPasswordDto.OldPassword))
+}
+
+func TestCreateAdminAccount_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+
+	createDto := dto.CreateAccountDto{
+		LoginId:  "newAdmin",
+		Email:    "newAdmin@example.com",
+		Password: "newAdminAdmin",
+	}
+	account, err := service.CreateAdminAccount(context.Background(), &createDto)
+	assert.Nil(t, err)
+	assert.Equal(t, model.AuthorityAdmin, account.Authority)
+
+	roles, err := NewRoleService(container).GetAccountRoles(context.Background(), account.ID)
+	assert.Nil(t, err)
+	assert.Len(t, roles, 1)
+	assert.Equal(t, model.RoleAdmin, roles[0].Name)
+}
+
+func TestUnlockAccount_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+
+	rep := container.GetRepository()
+	rep.Model(&model.Account{}).Where("id = ?", savedAccount.ID).
+		Updates(map[string]interface{}{"status": model.StatusInactive, "bad_attempt": 5})
+
+	err := service.UnlockAccount(context.Background(), savedAccount.LoginId)
+	assert.Nil(t, err)
+
+	account, _ := service.GetAccount(context.Background(), savedAccount.ID)
+	assert.Equal(t, model.StatusActive, account.Status)
+	assert.Equal(t, uint(0), account.BadAttempt)
+	assert.Equal(t, savedAccount.Version+1, account.Version)
+}
+
+func TestUnlockAccount_NotFoundFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+
+	err := service.UnlockAccount(context.Background(), "nobody")
 	assert.NotNil(t, err)
+}
+
+func TestSetAccountPassword_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+
+	err := service.SetAccountPassword(context.Background(), savedAccount.LoginId, "newTestTestTest")
+	assert.Nil(t, err)
+
+	account, _ := service.GetAccount(context.Background(), savedAccount.ID)
+	assert.True(t, account.CheckPassword(context.Background(), "newTestTestTest"))
+	assert.Equal(t, savedAccount.Version+1, account.Version)
+}
+
+func TestSetAccountPassword_WrongPasswordFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+
+	err := service.SetAccountPassword(context.Background(), savedAccount.LoginId, "new")
+	assert.NotNil(t, err)
+}
+
+func TestUpdateAccountProfile_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+
+	displayName := "  New Test  "
+	locale := "ko-kr"
+	timeZone := "Asia/Seoul"
+	updateDto := dto.UpdateAccountProfileDto{DisplayName: &displayName, Locale: &locale, TimeZone: &timeZone, Version: savedAccount.Version}
+	account, err := service.UpdateAccountProfile(context.Background(), savedAccount.ID, &updateDto)
+	assert.Nil(t, err)
+	assert.Equal(t, "New Test", account.DisplayName)
+	assert.Equal(t, "ko-KR", account.Locale)
+	assert.Equal(t, timeZone, account.TimeZone)
+	assert.Empty(t, account.Bio)
+	assert.Equal(t, savedAccount.Version+1, account.Version)
+
+	// fields absent from the request are left unchanged
+	bio := "hello"
+	updateDto = dto.UpdateAccountProfileDto{Bio: &bio, Version: account.Version}
+	account, err = service.UpdateAccountProfile(context.Background(), savedAccount.ID, &updateDto)
+	assert.Nil(t, err)
+	assert.Equal(t, "New Test", account.DisplayName)
+	assert.Equal(t, bio, account.Bio)
+	assert.Equal(t, savedAccount.Version+2, account.Version)
+}
+
+func TestUpdateAccountProfile_VersionConflictFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+
+	first, second := "first", "second"
+	_, err := service.UpdateAccountProfile(context.Background(), savedAccount.ID, &dto.UpdateAccountProfileDto{DisplayName: &first, Version: savedAccount.Version})
+	assert.Nil(t, err)
+
+	account, err := service.UpdateAccountProfile(context.Background(), savedAccount.ID, &dto.UpdateAccountProfileDto{DisplayName: &second, Version: savedAccount.Version})
+	assert.ErrorIs(t, err, ErrVersionConflict)
 	assert.Nil(t, account)
+
+	account, err = service.GetAccount(context.Background(), savedAccount.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, first, account.DisplayName)
+}
+
+func TestUpdateAccountProfile_InvalidFieldFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+
+	locale := "not a locale"
+	timeZone := "Mars/Olympus"
+	bio := strings.Repeat("a", config.BioMaxLength+1)
+	cases := []dto.UpdateAccountProfileDto{
+		{Version: savedAccount.Version},
+		{Locale: &locale, Version: savedAccount.Version},
+		{TimeZone: &timeZone, Version: savedAccount.Version},
+		{Bio: &bio, Version: savedAccount.Version},
+		{Bio: new(string)},
+	}
+	for _, updateDto := range cases {
+		account, err := service.UpdateAccountProfile(context.Background(), savedAccount.ID, &updateDto)
+		assert.NotNil(t, err)
+		assert.NotErrorIs(t, err, ErrVersionConflict)
+		assert.Nil(t, account)
+	}
 }
 
 func TestDeleteAccount_Success(t *testing.T) {
@@ -112,12 +371,169 @@ func TestDeleteAccount_Success(t *testing.T) {
 	dto := dto.DeleteAccountDto{
 		Password: "newTestTest",
 	}
-	err := service.DeleteAccount(savedAccount.ID, &dto)
+	err := service.DeleteAccount(context.Background(), savedAccount.ID, &dto)
+	assert.Nil(t, err)
+
+	account, err := service.GetAccount(context.Background(), savedAccount.ID)
 	assert.Nil(t, err)
+	assert.Equal(t, model.StatusPendingDeletion, account.Status)
+	assert.NotEmpty(t, account.RestoreTokenHash)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, config.AccountDeletionGraceDays), *account.DeletionScheduledAt, time.Minute)
+}
+
+func TestDeleteAccount_VersionConflictFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
 
-	account, err := service.GetAccount(savedAccount.ID)
+	err := service.DeleteAccount(context.Background(), savedAccount.ID, &dto.DeleteAccountDto{Password: "newTestTest", Version: savedAccount.Version + 1})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	account, err := service.GetAccount(context.Background(), savedAccount.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.StatusActive, account.Status)
+}
+
+func TestRestoreAccount_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+	assert.Nil(t, service.DeleteAccount(context.Background(), savedAccount.ID, &dto.DeleteAccountDto{Password: "newTestTest"}))
+	token := replaceRestoreToken(container, savedAccount.ID)
+
+	account, err := service.RestoreAccount(context.Background(), &dto.RestoreAccountDto{Token: token})
+	assert.Nil(t, err)
+	assert.Equal(t, model.StatusActive, account.Status)
+	assert.Nil(t, account.DeletionScheduledAt)
+	assert.Empty(t, account.RestoreTokenHash)
+
+	// the token can be used only once
+	account, err = service.RestoreAccount(context.Background(), &dto.RestoreAccountDto{Token: token})
+	assert.NotNil(t, err)
 	assert.Nil(t, account)
+}
+
+func TestRestoreAccount_LoginSuccess(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+	assert.Nil(t, service.DeleteAccount(context.Background(), savedAccount.ID, &dto.DeleteAccountDto{Password: "newTestTest"}))
+
+	account, err := NewAuthService(container).AuthenticateByLoginIdAndPassword(context.Background(), savedAccount.LoginId, "newTestTest")
+	assert.Nil(t, err)
+	assert.True(t, account.IsActive())
+
+	account, err = service.GetAccount(context.Background(), savedAccount.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.StatusActive, account.Status)
+	assert.Nil(t, account.DeletionScheduledAt)
+}
+
+func TestRestoreAccount_InvalidTokenFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+
+	service := NewAccountService(container)
+	account, err := service.RestoreAccount(context.Background(), &dto.RestoreAccountDto{Token: "invalid"})
+	assert.NotNil(t, err)
+	assert.Nil(t, account)
+}
+
+func TestPurgeAccounts_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+	_, err := NewAccessTokenService(container).CreateAccessToken(savedAccount.ID, &dto.CreateAccessTokenDto{Name: "script", Scopes: []string{model.ScopeAccountRead}}, nil)
+	assert.Nil(t, err)
+	_, err = newSyncDataExportService(container).RequestExport(context.Background(), savedAccount.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, service.DeleteAccount(context.Background(), savedAccount.ID, &dto.DeleteAccountDto{Password: "newTestTest"}))
+
+	// the grace period has not passed yet
+	purged, err := service.PurgeAccounts(context.Background(), time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, purged)
+
+	purged, err = service.PurgeAccounts(context.Background(), time.Now().AddDate(0, 0, config.AccountDeletionGraceDays+1))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = service.GetAccount(context.Background(), savedAccount.ID)
 	assert.NotNil(t, err)
+	tokens, err := NewAccessTokenService(container).GetAccessTokens(savedAccount.ID, parseQuery(t, AccessTokenQuerySpec, ""))
+	assert.Nil(t, err)
+	assert.Empty(t, tokens.Items)
+	exports, err := newSyncDataExportService(container).GetExports(context.Background(), savedAccount.ID, parseQuery(t, DataExportQuerySpec, ""))
+	assert.Nil(t, err)
+	assert.Empty(t, exports.Items)
+
+	// the loginId and the email can be registered again
+	account := createSuccessAccount(service)
+	assert.NotNil(t, account)
+	assert.NotEqual(t, savedAccount.ID, account.ID)
+}
+
+func TestPurgeAccounts_DeletedLoginIdRegistered(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+	assert.Nil(t, service.DeleteAccount(context.Background(), savedAccount.ID, &dto.DeleteAccountDto{Password: "newTestTest"}))
+	_, err := service.CreateAccount(context.Background(), &dto.CreateAccountDto{
+		LoginId:  fmt.Sprintf("deleted-%d", savedAccount.ID),
+		Email:    "deleted@example.com",
+		Password: "deletedPassword",
+	})
+	assert.Nil(t, err)
+
+	purged, err := service.PurgeAccounts(context.Background(), time.Now().AddDate(0, 0, config.AccountDeletionGraceDays+1))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, purged)
+}
+
+func TestPurgeAccounts_FailureSkipped(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+
+	service := NewAccountService(container)
+	first := createSuccessAccount(service)
+	second, err := service.CreateAccount(context.Background(), &dto.CreateAccountDto{LoginId: "second", Email: "second@example.com", Password: "secondPassword"})
+	assert.Nil(t, err)
+	assert.Nil(t, service.DeleteAccount(context.Background(), first.ID, &dto.DeleteAccountDto{Password: "newTestTest"}))
+	assert.Nil(t, service.DeleteAccount(context.Background(), second.ID, &dto.DeleteAccountDto{Password: "secondPassword"}))
+
+	// the purged login id can not be registered, so it is taken directly in the database to make the purge of the first account fail
+	_, err = service.CreateAccount(context.Background(), &dto.CreateAccountDto{LoginId: model.PurgedLoginId(first.ID), Email: "taken@example.com", Password: "takenPassword"})
+	assert.NotNil(t, err)
+	taken, _ := model.NewAccountWithPasswordEncrypt(context.Background(), model.PurgedLoginId(first.ID), "taken@example.com", "takenPassword", model.AuthorityUser)
+	assert.Nil(t, container.GetRepository().Create(taken).Error)
+
+	purged, err := service.PurgeAccounts(context.Background(), time.Now().AddDate(0, 0, config.AccountDeletionGraceDays+1))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = service.GetAccount(context.Background(), second.ID)
+	assert.NotNil(t, err)
+}
+
+func TestPurgeAccounts_RestoredAccountNotPurged(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+
+	service := NewAccountService(container)
+	savedAccount := createSuccessAccount(service)
+	assert.Nil(t, service.DeleteAccount(context.Background(), savedAccount.ID, &dto.DeleteAccountDto{Password: "newTestTest"}))
+	_, err := service.RestoreAccount(context.Background(), &dto.RestoreAccountDto{Token: replaceRestoreToken(container, savedAccount.ID)})
+	assert.Nil(t, err)
+
+	purged, err := service.PurgeAccounts(context.Background(), time.Now().AddDate(0, 0, config.AccountDeletionGraceDays+1))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, purged)
+
+	account, err := service.GetAccount(context.Background(), savedAccount.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, savedAccount.LoginId, account.LoginId)
 }
 
 func TestDeleteAccount_WrongPasswordFailure(t *testing.T) {
@@ -129,7 +545,7 @@ func TestDeleteAccount_WrongPasswordFailure(t *testing.T) {
 	dto := dto.DeleteAccountDto{
 		Password: "newTest",
 	}
-	err := service.DeleteAccount(savedAccount.ID, &dto)
+	err := service.DeleteAccount(context.Background(), savedAccount.ID, &dto)
 	assert.NotNil(t, err)
 }
 
@@ -150,7 +566,7 @@ func TestFindAccountByEmail_Success(t *testing.T) {
 	dto := dto.FindLoginIdDto{
 		Email: savedAccount.Email,
 	}
-	err = service.FindAccountByEmail(&dto)
+	err = service.FindAccountByEmail(context.Background(), &dto)
 	assert.Nil(t, err)
 	assert.Contains(t, mailServer.Messages()[1].MsgRequest(), savedAccount.LoginId)
 }
@@ -173,16 +589,141 @@ func TestFindAccountByEmail_NoExistMailFailure(t *testing.T) {
 	dto := dto.FindLoginIdDto{
 		Email: savedAccount.Email,
 	}
-	err = service.FindAccountByEmail(&dto)
+	err = service.FindAccountByEmail(context.Background(), &dto)
 	assert.NotNil(t, err)
 }
 
+// replaceRestoreToken replaces the restore token sent by email with a known one.
+func replaceRestoreToken(container container.Container, id uint) string {
+	token := "restore-token"
+	container.GetRepository().Model(&model.Account{}).Where("id = ?", id).Update("restore_token_hash", model.HashRestoreToken(token))
+	return token
+}
+
+func TestGetAccounts_Page(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	createAccounts(t, container, 24, time.Now())
+
+	var total int64
+	container.GetRepository().Model(&model.Account{}).Count(&total)
+
+	accounts, err := service.GetAccounts(context.Background(), parseQuery(t, AccountQuerySpec, "page=2&size=10&sort=-id"))
+	assert.Nil(t, err)
+	assert.Equal(t, total, accounts.Total)
+	assert.Equal(t, 2, accounts.Page)
+	assert.Equal(t, 10, accounts.Size)
+	assert.Empty(t, accounts.NextCursor)
+	assert.Len(t, accounts.Items, 10)
+	assert.Equal(t, uint(total)-10, accounts.Items[0].ID)
+	assert.Equal(t, uint(total)-19, accounts.Items[9].ID)
+}
+
+func TestGetAccounts_Filter(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	assert.Nil(t, testutil.LoadFixtures(container, "accounts.yml"))
+
+	accounts, err := service.GetAccounts(context.Background(), parseQuery(t, AccountQuerySpec, fmt.Sprintf("status=%d", model.StatusInactive)))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), accounts.Total)
+	assert.Equal(t, "inactive1", accounts.Items[0].LoginId)
+	assert.Equal(t, "inactive2", accounts.Items[1].LoginId)
+
+	accounts, err = service.GetAccounts(context.Background(), parseQuery(t, AccountQuerySpec, fmt.Sprintf("status=%d,%d", model.StatusInactive, model.StatusActive)))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), accounts.Total)
+
+	// the fields which are not whitelisted are ignored.
+	accounts, err = service.GetAccounts(context.Background(), parseQuery(t, AccountQuerySpec, "password=x"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), accounts.Total)
+}
+
+func TestGetAccounts_Cursor(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	// the same creation time makes the order depend on the tie-break by id.
+	createdAt := time.Now().Add(time.Hour)
+	created := createAccounts(t, container, 15, createdAt)
+
+	ids := []uint{}
+	cursor := ""
+	for pages := 0; ; pages++ {
+		accounts, err := service.GetAccounts(context.Background(), parseQuery(t, AccountQuerySpec, "size=4&sort=-createdAt&cursor="+cursor))
+		assert.Nil(t, err)
+		assert.Equal(t, 0, accounts.Page)
+		for _, account := range accounts.Items {
+			ids = append(ids, account.ID)
+		}
+		if pages == 0 {
+			// the new account is sorted before the cursor, so it does not shift the next pages.
+			createAccounts(t, container, 1, createdAt.Add(time.Hour))
+		}
+		if accounts.NextCursor == "" {
+			break
+		}
+		cursor = accounts.NextCursor
+	}
+
+	assert.Len(t, ids, len(created)+1)
+	for i := range created {
+		assert.Equal(t, created[len(created)-1-i].ID, ids[i])
+	}
+}
+
+func TestGetAccounts_InvalidQueryFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAccountService(container)
+	createAccounts(t, container, 3, time.Now())
+
+	accounts, err := service.GetAccounts(context.Background(), parseQuery(t, AccountQuerySpec, "size=2&cursor="))
+	assert.Nil(t, err)
+
+	for _, raw := range []string{
+		"sort=password",
+		"size=0",
+		fmt.Sprintf("size=%d", config.PageMaxSize+1),
+		"page=0",
+		"page=1&cursor=",
+		"cursor=invalid",
+		"sort=-id&cursor=" + accounts.NextCursor,
+		"status=active",
+	} {
+		params, _ := url.ParseQuery(raw)
+		query, err := infrastructure.ParseQuery(params, AccountQuerySpec)
+		if err == nil {
+			_, err = service.GetAccounts(context.Background(), query)
+		}
+		assert.ErrorIs(t, err, infrastructure.ErrInvalidQuery, raw)
+	}
+}
+
+func createAccounts(t *testing.T, container container.Container, n int, createdAt time.Time) []model.Account {
+	accounts := make([]model.Account, n)
+	for i := range accounts {
+		loginId := "list" + util.RandomBase16String(8)
+		accounts[i] = model.Account{LoginId: loginId, Email: loginId + "@example.com", Status: model.StatusActive, Version: 1}
+		accounts[i].CreatedAt = createdAt
+		assert.Nil(t, container.GetRepository().Create(&accounts[i]).Error)
+	}
+	return accounts
+}
+
+func parseQuery(t *testing.T, spec infrastructure.QuerySpec, raw string) *infrastructure.Query {
+	params, err := url.ParseQuery(raw)
+	assert.Nil(t, err)
+	query, err := infrastructure.ParseQuery(params, spec)
+	assert.Nil(t, err)
+	return query
+}
+
 func createSuccessAccount(service AccountService) *model.Account {
 	createDto := dto.CreateAccountDto{
 		LoginId:  "newTest",
 		Email:    "newTest@example.com",
 		Password: "newTestTest",
 	}
-	savedAccount, _ := service.CreateAccount(&createDto)
+	savedAccount, _ := service.CreateAccount(context.Background(), &createDto)
 	return savedAccount
 }
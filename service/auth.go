@@ -1,18 +1,28 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/onetooler/bistory-backend/config"
 	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
 	"github.com/onetooler/bistory-backend/model"
 	"github.com/onetooler/bistory-backend/util"
+	"gorm.io/gorm"
 )
 
+// errAccountNotActive is returned when the account is locked or deleted, including by a concurrent request.
+var errAccountNotActive = errors.New("account is not active")
+
+// loginStatuses are the statuses of the accounts which can log in.
+var loginStatuses = []model.Status{model.StatusActive, model.StatusPendingDeletion}
+
 // AuthService is a service for authentication.
 type AuthService interface {
-	AuthenticateByLoginIdAndPassword(loginId string, password string) (*model.Account, error)
-	EmailVerificationTokenSend(email string) (*string, error)
+	AuthenticateByLoginIdAndPassword(ctx context.Context, loginId string, password string) (*model.Account, error)
+	EmailVerificationTokenSend(ctx context.Context, email string) (*string, error)
 }
 
 type authService struct {
@@ -25,48 +35,127 @@ func NewAuthService(container container.Container) AuthService {
 }
 
 // AuthenticateByLoginIdAndPassword authenticates by using loginId and plain text password.
-func (a *authService) AuthenticateByLoginIdAndPassword(loginId string, password string) (*model.Account, error) {
-	account, err := a.findByLoginId(loginId)
+// An attempt is reserved before the password is compared, so the concurrent guesses can not exceed MaxLoginAttempts,
+// and the counter is reset when the password is matched.
+// The returned account has its roles and permissions loaded.
+func (a *authService) AuthenticateByLoginIdAndPassword(ctx context.Context, loginId string, password string) (*model.Account, error) {
+	repo := a.container.GetRepository().WithContext(ctx)
+
+	account, err := a.findByLoginId(repo, loginId)
 	if err != nil {
 		return nil, err
 	}
-	if !account.IsActive() {
-		return nil, fmt.Errorf("account is not active")
+	if !account.IsActive() && !account.IsPendingDeletion() {
+		return nil, errAccountNotActive
+	}
+	if err := reserveAttempt(repo, account); err != nil {
+		return nil, err
 	}
 
-	ok := account.CheckPassword(password)
-	a.container.GetRepository().Save(account) // save
-	if !ok {
+	if !account.CheckPassword(ctx, password) {
 		if account.RemainAttempt() > 0 {
 			return nil, fmt.Errorf("password not matched. remain attempt count is %d", account.RemainAttempt())
 		}
+		if err := deactivate(repo, account); err != nil {
+			return nil, err
+		}
 		return nil, fmt.Errorf("password not matched. account has been deactivated")
 	}
+	err = infrastructure.InTransaction(ctx, a.container.GetRepository(), func(ctx context.Context) error {
+		repo := a.container.GetRepository().WithContext(ctx)
+		if err := resetBadAttempt(repo, account); err != nil {
+			return err
+		}
+		// logging in cancels the deletion of the account
+		if account.IsPendingDeletion() {
+			return restoreAccount(repo, account)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return account, nil
 }
 
 // EmailVerificationTokenSend send token to email and return that token.
-func (a *authService) EmailVerificationTokenSend(email string) (*string, error) {
+func (a *authService) EmailVerificationTokenSend(ctx context.Context, email string) (*string, error) {
 	emailSender := a.container.GetEmailSender()
 	token := util.RandomBase16String(config.EmailVerificationTokenLength)
 	// TODO: Change to Constant
 	subject := "[Bistory] 이메일 인증 코드"
-	err := emailSender.SendEmail(email, subject, config.EmailVerificationTemplate, token)
+	err := emailSender.SendEmail(ctx, email, subject, config.EmailVerificationTemplate, token)
 	if err != nil {
 		return nil, err
 	}
 	return &token, nil
 }
 
-func (a *authService) findByLoginId(loginId string) (*model.Account, error) {
-	repo := a.container.GetRepository()
-
-	account := model.Account{LoginId: loginId}
-	tx := repo.First(&account)
+func (a *authService) findByLoginId(repo infrastructure.Repository, loginId string) (*model.Account, error) {
+	account := model.Account{}
+	tx := repo.Where(&model.Account{LoginId: loginId}).Preload("Roles.Permissions").First(&account)
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
 
 	return &account, nil
 }
+
+// reserveAttempt counts an attempt to log in to the account before its password is compared.
+// The counter is incremented by the database only while it is below MaxLoginAttempts,
+// so the attempts beyond the limit are refused even if the preceding ones are still being compared.
+// The version of the account is not incremented, because the counter is not a part of its representation.
+func reserveAttempt(repo infrastructure.Repository, account *model.Account) error {
+	return repo.Transaction(func(tx infrastructure.Repository) error {
+		result := tx.Model(&model.Account{}).Where("id = ? AND status IN ? AND bad_attempt < ?", account.ID, loginStatuses, config.MaxLoginAttempts).
+			UpdateColumn("bad_attempt", gorm.Expr("bad_attempt + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAccountNotActive
+		}
+		return tx.Model(&model.Account{}).Select("bad_attempt").Where("id = ?", account.ID).Scan(&account.BadAttempt).Error
+	})
+}
+
+// deactivate locks the account whose failed attempts have reached MaxLoginAttempts.
+// The account locked or deleted by a concurrent request is left as it is.
+func deactivate(repo infrastructure.Repository, account *model.Account) error {
+	result := repo.Model(&model.Account{}).Where("id = ? AND status = ?", account.ID, model.StatusActive).
+		UpdateColumns(map[string]interface{}{"status": model.StatusInactive, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		account.Status = model.StatusInactive
+		account.Version++
+	}
+	return nil
+}
+
+// resetBadAttempt clears the counter of the attempts after the password is matched.
+// It fails if the account is locked or its password is changed by a concurrent request after it is loaded.
+func resetBadAttempt(repo infrastructure.Repository, account *model.Account) error {
+	return repo.Transaction(func(tx infrastructure.Repository) error {
+		result := tx.Model(&model.Account{}).Where("id = ? AND status IN ? AND password = ?", account.ID, loginStatuses, account.Password).
+			UpdateColumn("bad_attempt", 0)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// MySQL does not count the row whose counter is already zero, so the conditions are checked again.
+			var count int64
+			if err := tx.Model(&model.Account{}).Where("id = ? AND status IN ? AND password = ?", account.ID, loginStatuses, account.Password).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return errAccountNotActive
+			}
+		}
+		account.BadAttempt = 0
+		return nil
+	})
+}
@@ -1,10 +1,12 @@
 package service
 
 import (
+	"context"
 	"testing"
 
 	smtpmock "github.com/mocktools/go-smtp-mock/v2"
 	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/logger"
 	"github.com/onetooler/bistory-backend/model"
 	"github.com/onetooler/bistory-backend/testutil"
 	"github.com/onetooler/bistory-backend/util"
@@ -15,24 +17,25 @@ func TestAuthenticateByLoginIdAndPassword_Success(t *testing.T) {
 	container := testutil.PrepareForServiceTest(false)
 
 	service := NewAuthService(container)
-	account, err := service.AuthenticateByLoginIdAndPassword("test", "test")
+	account, err := service.AuthenticateByLoginIdAndPassword(context.Background(), "test", "testPassword")
 	account.CreatedAt = account.CreatedAt.Local()
 	account.UpdatedAt = account.UpdatedAt.Local()
 
 	data := model.Account{LoginId: "test"}
-	container.GetRepository().First(&data)
+	container.GetRepository().Preload("Roles.Permissions").First(&data)
 	data.CreatedAt = data.CreatedAt.Local()
 	data.UpdatedAt = data.UpdatedAt.Local()
 
 	assert.Equal(t, data, *account)
 	assert.Nil(t, err)
+	assert.Equal(t, []string{model.RoleAdmin}, account.RoleNames())
 }
 
 func TestAuthenticateByLoginIdAndPassword_EntityNotFound(t *testing.T) {
 	container := testutil.PrepareForServiceTest(false)
 
 	service := NewAuthService(container)
-	account, err := service.AuthenticateByLoginIdAndPassword("abcde", "abcde")
+	account, err := service.AuthenticateByLoginIdAndPassword(context.Background(), "abcde", "abcde")
 
 	assert.Nil(t, account)
 	assert.NotNil(t, err)
@@ -42,7 +45,7 @@ func TestAuthenticateByLoginIdAndPassword_AuthenticationFailure(t *testing.T) {
 	container := testutil.PrepareForServiceTest(false)
 
 	service := NewAuthService(container)
-	account, err := service.AuthenticateByLoginIdAndPassword("test", "abcde")
+	account, err := service.AuthenticateByLoginIdAndPassword(context.Background(), "test", "abcde")
 
 	assert.Nil(t, account)
 	assert.NotNil(t, err)
@@ -53,11 +56,11 @@ func TestAuthenticateByLoginIdAndPassword_AuthenticationMaxFailure(t *testing.T)
 
 	service := NewAuthService(container)
 	for i := 0; i < config.MaxLoginAttempts; i++ {
-		account, err := service.AuthenticateByLoginIdAndPassword("test", "abcde")
+		account, err := service.AuthenticateByLoginIdAndPassword(context.Background(), "test", "abcde")
 		assert.Nil(t, account)
 		assert.NotNil(t, err)
 	}
-	account, err := service.AuthenticateByLoginIdAndPassword("test", "test")
+	account, err := service.AuthenticateByLoginIdAndPassword(context.Background(), "test", "testPassword")
 	assert.Nil(t, account)
 	assert.NotNil(t, err)
 }
@@ -76,8 +79,27 @@ func TestEmailVerificationTokenSend_Success(t *testing.T) {
 	service := NewAuthService(container)
 
 	testEmail := "testEmail@example.com"
-	token, err := service.EmailVerificationTokenSend(testEmail)
+	token, err := service.EmailVerificationTokenSend(context.Background(), testEmail)
 	assert.Nil(t, err)
 	assert.NotNil(t, token)
 	assert.Contains(t, mailServer.Messages()[1].MsgRequest(), *token)
 }
+
+func TestEmailVerificationTokenSend_RequestId(t *testing.T) {
+	mailServer := smtpmock.New(smtpmock.ConfigurationAttr{
+		LogToStdout:       true,
+		LogServerActivity: true,
+		PortNumber:        testutil.TestEmailServerPort,
+	})
+	err := mailServer.Start()
+	assert.Nil(t, err)
+	defer util.Check(mailServer.Stop)
+
+	container := testutil.PrepareForServiceTest(true)
+	service := NewAuthService(container)
+
+	ctx := logger.WithRequestId(context.Background(), "test-request-id")
+	_, err = service.EmailVerificationTokenSend(ctx, "testEmail@example.com")
+	assert.Nil(t, err)
+	assert.Contains(t, mailServer.Messages()[1].MsgRequest(), "X-Request-Id: test-request-id")
+}
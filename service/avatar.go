@@ -0,0 +1,204 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // for decoding the uploaded avatars
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/util"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+// ErrAvatarTooLarge is returned when the uploaded avatar exceeds config.AvatarMaxSize or config.AvatarMaxDimension.
+var ErrAvatarTooLarge = fmt.Errorf("avatar must be at most %d bytes and %dx%d pixels", config.AvatarMaxSize, config.AvatarMaxDimension, config.AvatarMaxDimension)
+
+// ErrUnsupportedAvatarType is returned when the uploaded avatar is not an image of the supported types.
+var ErrUnsupportedAvatarType = errors.New("avatar must be a png, jpeg, gif or webp image")
+
+// ErrAvatarNotFound is returned when the account has no avatar.
+var ErrAvatarNotFound = errors.New("avatar not found")
+
+var avatarContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// AvatarService is a service for managing the avatars of accounts.
+type AvatarService interface {
+	UploadAvatar(uint, io.Reader) (*dto.AvatarDto, error)
+	GetAvatar(uint) (*dto.AvatarDto, error)
+	DeleteAvatar(uint) error
+}
+
+type avatarService struct {
+	container container.Container
+}
+
+// NewAvatarService is constructor.
+func NewAvatarService(container container.Container) AvatarService {
+	return &avatarService{container: container}
+}
+
+// UploadAvatar resizes a given image to the fixed size thumbnails, stores them and replaces the previous avatar.
+func (a *avatarService) UploadAvatar(accountId uint, file io.Reader) (*dto.AvatarDto, error) {
+	account, err := a.findAccount(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := a.decode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	storage := a.container.GetStorage()
+	prefix := fmt.Sprintf("%s/%d/%s", config.AvatarStoragePrefix, accountId, util.RandomBase16String(16))
+	for _, size := range []int{config.AvatarSizeLarge, config.AvatarSizeSmall} {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, thumbnail(img, size)); err != nil {
+			return nil, err
+		}
+		if err := storage.Put(avatarKey(prefix, size), &buf, int64(buf.Len()), "image/png"); err != nil {
+			a.deleteFiles(prefix)
+			return nil, err
+		}
+	}
+
+	repo := a.container.GetRepository()
+	tx := repo.Model(&model.Account{}).Where("id = ?", accountId).
+		Updates(map[string]interface{}{"avatar": prefix, "version": gorm.Expr("version + 1")})
+	if tx.Error != nil {
+		a.deleteFiles(prefix)
+		return nil, tx.Error
+	}
+	if account.Avatar != "" {
+		a.deleteFiles(account.Avatar)
+	}
+
+	return a.signedURLs(prefix)
+}
+
+// GetAvatar returns the signed URLs of the avatar thumbnails.
+func (a *avatarService) GetAvatar(accountId uint) (*dto.AvatarDto, error) {
+	account, err := a.findAccount(accountId)
+	if err != nil {
+		return nil, err
+	}
+	if account.Avatar == "" {
+		return nil, ErrAvatarNotFound
+	}
+	return a.signedURLs(account.Avatar)
+}
+
+func (a *avatarService) DeleteAvatar(accountId uint) error {
+	account, err := a.findAccount(accountId)
+	if err != nil {
+		return err
+	}
+	if account.Avatar == "" {
+		return ErrAvatarNotFound
+	}
+
+	repo := a.container.GetRepository()
+	tx := repo.Model(&model.Account{}).Where("id = ?", accountId).
+		Updates(map[string]interface{}{"avatar": "", "version": gorm.Expr("version + 1")})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	a.deleteFiles(account.Avatar)
+	return nil
+}
+
+func (a *avatarService) findAccount(accountId uint) (*model.Account, error) {
+	account := model.Account{}
+	if err := a.container.GetRepository().First(&account, accountId).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// decode checks the size and the type of a given image before decoding it, so that a huge image is not decoded.
+func (a *avatarService) decode(file io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(io.LimitReader(file, config.AvatarMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > config.AvatarMaxSize {
+		return nil, ErrAvatarTooLarge
+	}
+	if !avatarContentTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedAvatarType
+	}
+
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedAvatarType
+	}
+	if imgConfig.Width > config.AvatarMaxDimension || imgConfig.Height > config.AvatarMaxDimension {
+		return nil, ErrAvatarTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedAvatarType
+	}
+	return img, nil
+}
+
+func (a *avatarService) signedURLs(prefix string) (*dto.AvatarDto, error) {
+	storage := a.container.GetStorage()
+	expiresAt := time.Now().Add(config.SignedURLLifetime)
+
+	large, err := storage.SignedURL(avatarKey(prefix, config.AvatarSizeLarge), config.SignedURLLifetime)
+	if err != nil {
+		return nil, err
+	}
+	small, err := storage.SignedURL(avatarKey(prefix, config.AvatarSizeSmall), config.SignedURLLifetime)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.AvatarDto{Large: large, Small: small, ExpiresAt: expiresAt}, nil
+}
+
+// deleteFiles deletes the thumbnails of an avatar. The failure is only logged because the avatar is not referred anymore.
+func (a *avatarService) deleteFiles(prefix string) {
+	for _, size := range []int{config.AvatarSizeLarge, config.AvatarSizeSmall} {
+		if err := a.container.GetStorage().Delete(avatarKey(prefix, size)); err != nil {
+			a.container.GetLogger().GetZapLogger().Errorf("Failed to delete the avatar %s: %s", prefix, err.Error())
+		}
+	}
+}
+
+func avatarKey(prefix string, size int) string {
+	return fmt.Sprintf("%s/%d.png", prefix, size)
+}
+
+// thumbnail crops the center square of a given image and scales it to size x size.
+func thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, image.Rect(x, y, x+side, y+side), draw.Over, nil)
+	return dst
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadAvatar_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAvatarService(container)
+	account := createSuccessAccount(NewAccountService(container))
+
+	avatar, err := service.UploadAvatar(account.ID, bytes.NewReader(newTestImage(t, 400, 300)))
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(avatar.Large, config.APIFiles))
+	assert.True(t, strings.HasPrefix(avatar.Small, config.APIFiles))
+
+	assert.Equal(t, config.AvatarSizeLarge, storedImageSize(t, container, avatar.Large))
+	assert.Equal(t, config.AvatarSizeSmall, storedImageSize(t, container, avatar.Small))
+
+	updated, _ := NewAccountService(container).GetAccount(context.Background(), account.ID)
+	assert.NotEmpty(t, updated.Avatar)
+	assert.Equal(t, account.Version+1, updated.Version)
+}
+
+func TestUploadAvatar_ReplaceSuccess(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAvatarService(container)
+	account := createSuccessAccount(NewAccountService(container))
+
+	first, err := service.UploadAvatar(account.ID, bytes.NewReader(newTestImage(t, 64, 64)))
+	assert.Nil(t, err)
+	second, err := service.UploadAvatar(account.ID, bytes.NewReader(newTestImage(t, 64, 64)))
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.Large, second.Large)
+
+	// the thumbnails of the previous avatar are deleted
+	_, err = container.GetStorage().Get(storageKey(t, first.Large))
+	assert.NotNil(t, err)
+}
+
+func TestUploadAvatar_UnsupportedTypeFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAvatarService(container)
+	account := createSuccessAccount(NewAccountService(container))
+
+	avatar, err := service.UploadAvatar(account.ID, strings.NewReader("<html>not an image</html>"))
+	assert.ErrorIs(t, err, ErrUnsupportedAvatarType)
+	assert.Nil(t, avatar)
+}
+
+func TestUploadAvatar_TooLargeFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAvatarService(container)
+	account := createSuccessAccount(NewAccountService(container))
+
+	avatar, err := service.UploadAvatar(account.ID, bytes.NewReader(make([]byte, config.AvatarMaxSize+1)))
+	assert.ErrorIs(t, err, ErrAvatarTooLarge)
+	assert.Nil(t, avatar)
+
+	avatar, err = service.UploadAvatar(account.ID, bytes.NewReader(newTestImage(t, config.AvatarMaxDimension+1, 1)))
+	assert.ErrorIs(t, err, ErrAvatarTooLarge)
+	assert.Nil(t, avatar)
+}
+
+func TestDeleteAvatar_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewAvatarService(container)
+	account := createSuccessAccount(NewAccountService(container))
+
+	uploaded, err := service.UploadAvatar(account.ID, bytes.NewReader(newTestImage(t, 64, 64)))
+	assert.Nil(t, err)
+
+	assert.Nil(t, service.DeleteAvatar(account.ID))
+
+	avatar, err := service.GetAvatar(account.ID)
+	assert.ErrorIs(t, err, ErrAvatarNotFound)
+	assert.Nil(t, avatar)
+	_, err = container.GetStorage().Get(storageKey(t, uploaded.Small))
+	assert.NotNil(t, err)
+}
+
+func newTestImage(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, x%height, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	assert.Nil(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// storageKey returns the key of the file of a signed URL.
+func storageKey(t *testing.T, signedURL string) string {
+	u, err := url.Parse(signedURL)
+	assert.Nil(t, err)
+	return strings.TrimPrefix(u.Path, config.APIFiles+"/")
+}
+
+func storedImageSize(t *testing.T, container container.Container, signedURL string) int {
+	file, err := container.GetStorage().Get(storageKey(t, signedURL))
+	assert.Nil(t, err)
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	assert.Nil(t, err)
+	assert.Equal(t, img.Bounds().Dx(), img.Bounds().Dy())
+	return img.Bounds().Dx()
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+)
+
+// ErrAdminExists is returned when the first admin account is set up after an admin exists.
+var ErrAdminExists = errors.New("admin account already exists")
+
+// ErrInvalidSetupToken is returned when the setup token is wrong, expired or already used.
+var ErrInvalidSetupToken = errors.New("setup token is invalid or expired")
+
+// BootstrapService is a service for creating the first admin account.
+type BootstrapService interface {
+	BootstrapAdmin(ctx context.Context) error
+	SetupAdmin(ctx context.Context, setupAdminDto *dto.SetupAdminDto) (*model.Account, error)
+}
+
+type bootstrapService struct {
+	container container.Container
+}
+
+// NewBootstrapService is constructor.
+func NewBootstrapService(container container.Container) BootstrapService {
+	return &bootstrapService{container: container}
+}
+
+// BootstrapAdmin creates the admin account of the configuration, whose password must be changed at the first login.
+// If it is not configured, a setup token is issued and printed to the log, replacing the ones issued before.
+// It does nothing once an admin exists, so it runs on every boot.
+func (b *bootstrapService) BootstrapAdmin(ctx context.Context) error {
+	exists, err := b.existsAdmin(ctx)
+	if err != nil || exists {
+		return err
+	}
+
+	admin := b.container.GetConfig().Bootstrap.Admin
+	if admin.LoginId != "" || admin.Email != "" || admin.Password != "" {
+		return b.createConfiguredAdmin(ctx)
+	}
+	return b.issueSetupToken(ctx)
+}
+
+// SetupAdmin creates the first admin account with the setup token. The token is deleted, so it is used only once.
+func (b *bootstrapService) SetupAdmin(ctx context.Context, setupAdminDto *dto.SetupAdminDto) (*model.Account, error) {
+	var account *model.Account
+	err := infrastructure.InTransaction(ctx, b.container.GetRepository(), func(ctx context.Context) error {
+		token, err := b.setupTokens(ctx).FindOne(infrastructure.Where("token_hash = ?", model.HashSetupToken(setupAdminDto.Token)))
+		if errors.Is(err, infrastructure.ErrNotFound) || (err == nil && !time.Now().Before(token.ExpiresAt)) {
+			return ErrInvalidSetupToken
+		} else if err != nil {
+			return err
+		}
+
+		// the token is deleted before the creation, so that only one of the concurrent requests uses it
+		tx := b.container.GetRepository().WithContext(ctx).Where("id = ?", token.ID).Delete(&model.SetupToken{})
+		if tx.Error != nil {
+			return tx.Error
+		}
+		if tx.RowsAffected == 0 {
+			return ErrInvalidSetupToken
+		}
+
+		exists, err := b.existsAdmin(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrAdminExists
+		}
+
+		account, err = NewAccountService(b.container).CreateAdminAccount(ctx, &dto.CreateAccountDto{
+			LoginId:  setupAdminDto.LoginId,
+			Email:    setupAdminDto.Email,
+			Password: setupAdminDto.Password,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.container.GetLogger().GetZapLogger().Infof("Created the first admin account %s by the setup token", account.LoginId)
+	return account, nil
+}
+
+func (b *bootstrapService) createConfiguredAdmin(ctx context.Context) error {
+	admin := b.container.GetConfig().Bootstrap.Admin
+	err := infrastructure.InTransaction(ctx, b.container.GetRepository(), func(ctx context.Context) error {
+		account, err := NewAccountService(b.container).CreateAdminAccount(ctx, &dto.CreateAccountDto{
+			LoginId:  admin.LoginId,
+			Email:    admin.Email,
+			Password: admin.Password,
+		})
+		if err != nil {
+			return err
+		}
+		return b.container.GetRepository().WithContext(ctx).Model(&model.Account{}).
+			Where("id = ?", account.ID).UpdateColumn("must_change_password", true).Error
+	})
+	// another instance booting at the same time has created it
+	if errors.Is(err, infrastructure.ErrAlreadyExists) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	b.container.GetLogger().GetZapLogger().Infof("Created the first admin account %s by the configuration", admin.LoginId)
+	return nil
+}
+
+func (b *bootstrapService) issueSetupToken(ctx context.Context) error {
+	lifetime := b.container.GetConfig().Bootstrap.SetupTokenLifetime
+	if lifetime <= 0 {
+		lifetime = config.SetupTokenLifetime
+	}
+	token, plain := model.NewSetupToken(time.Now().Add(lifetime))
+
+	err := infrastructure.InTransaction(ctx, b.container.GetRepository(), func(ctx context.Context) error {
+		if err := b.container.GetRepository().WithContext(ctx).Where("1 = 1").Delete(&model.SetupToken{}).Error; err != nil {
+			return err
+		}
+		return b.setupTokens(ctx).Create(token)
+	})
+	if err != nil {
+		return err
+	}
+	b.container.GetLogger().GetZapLogger().Warnf(
+		"No admin account exists. Create it by POST %s with the setup token %s, which expires at %s",
+		config.APIAuthSetup, plain, token.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// existsAdmin judges whether any account has the admin role.
+func (b *bootstrapService) existsAdmin(ctx context.Context) (bool, error) {
+	return infrastructure.NewRepo[model.Account](b.container.GetRepository().WithContext(ctx)).Exists(infrastructure.Where(
+		"EXISTS (SELECT 1 FROM account_role JOIN role ON role.id = account_role.role_id WHERE account_role.account_id = account.id AND role.name = ?)",
+		model.RoleAdmin))
+}
+
+func (b *bootstrapService) setupTokens(ctx context.Context) *infrastructure.Repo[model.SetupToken] {
+	return infrastructure.NewRepo[model.SetupToken](b.container.GetRepository().WithContext(ctx))
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootstrapAdmin_ConfiguredAdmin(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	removeAdmins(container)
+	admin := &container.GetConfig().Bootstrap.Admin
+	admin.LoginId = "admin"
+	admin.Email = "admin@example.com"
+	admin.Password = "adminAdmin"
+
+	service := NewBootstrapService(container)
+	assert.Nil(t, service.BootstrapAdmin(context.Background()))
+
+	account := model.Account{}
+	container.GetRepository().Where("login_id = ?", "admin").Preload("Roles").First(&account)
+	assert.True(t, account.MustChangePassword)
+	assert.Equal(t, []string{model.RoleAdmin}, account.RoleNames())
+
+	// the password change clears the flag
+	err := NewAccountService(container).SetAccountPassword(context.Background(), "admin", "newAdminAdmin")
+	assert.Nil(t, err)
+	container.GetRepository().Where("login_id = ?", "admin").First(&account)
+	assert.False(t, account.MustChangePassword)
+}
+
+func TestBootstrapAdmin_AdminExists(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	admin := &container.GetConfig().Bootstrap.Admin
+	admin.LoginId = "admin"
+	admin.Email = "admin@example.com"
+	admin.Password = "adminAdmin"
+
+	service := NewBootstrapService(container)
+	assert.Nil(t, service.BootstrapAdmin(context.Background()))
+
+	var count int64
+	container.GetRepository().Model(&model.Account{}).Where("login_id = ?", "admin").Count(&count)
+	assert.Equal(t, int64(0), count)
+	container.GetRepository().Model(&model.SetupToken{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestBootstrapAdmin_WrongPasswordFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	removeAdmins(container)
+	admin := &container.GetConfig().Bootstrap.Admin
+	admin.LoginId = "admin"
+	admin.Email = "admin@example.com"
+	admin.Password = "admin"
+
+	service := NewBootstrapService(container)
+	assert.NotNil(t, service.BootstrapAdmin(context.Background()))
+}
+
+func TestBootstrapAdmin_SetupToken(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	removeAdmins(container)
+
+	service := NewBootstrapService(container)
+	assert.Nil(t, service.BootstrapAdmin(context.Background()))
+	assert.Nil(t, service.BootstrapAdmin(context.Background()))
+
+	// the token issued before is replaced
+	tokens := []model.SetupToken{}
+	container.GetRepository().Find(&tokens)
+	assert.Len(t, tokens, 1)
+	assert.True(t, tokens[0].ExpiresAt.After(time.Now()))
+}
+
+func TestSetupAdmin_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	removeAdmins(container)
+	plain := createSetupToken(container, time.Now().Add(time.Hour))
+
+	service := NewBootstrapService(container)
+	account, err := service.SetupAdmin(context.Background(), newSetupAdminDto(plain))
+	assert.Nil(t, err)
+	assert.Equal(t, model.AuthorityAdmin, account.Authority)
+	assert.False(t, account.MustChangePassword)
+
+	_, err = service.SetupAdmin(context.Background(), newSetupAdminDto(plain))
+	assert.ErrorIs(t, err, ErrInvalidSetupToken)
+}
+
+func TestSetupAdmin_ExpiredTokenFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	removeAdmins(container)
+	plain := createSetupToken(container, time.Now().Add(-time.Minute))
+
+	service := NewBootstrapService(container)
+	account, err := service.SetupAdmin(context.Background(), newSetupAdminDto(plain))
+	assert.Nil(t, account)
+	assert.ErrorIs(t, err, ErrInvalidSetupToken)
+}
+
+func TestSetupAdmin_AdminExistsFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	plain := createSetupToken(container, time.Now().Add(time.Hour))
+
+	service := NewBootstrapService(container)
+	account, err := service.SetupAdmin(context.Background(), newSetupAdminDto(plain))
+	assert.Nil(t, account)
+	assert.ErrorIs(t, err, ErrAdminExists)
+}
+
+// removeAdmins unassigns the admin role from the test admin, so that no admin exists.
+func removeAdmins(container container.Container) {
+	container.GetRepository().Exec("DELETE FROM account_role")
+}
+
+func createSetupToken(container container.Container, expiresAt time.Time) string {
+	token, plain := model.NewSetupToken(expiresAt)
+	container.GetRepository().Create(token)
+	return plain
+}
+
+func newSetupAdminDto(token string) *dto.SetupAdminDto {
+	return &dto.SetupAdminDto{Token: token, LoginId: "admin", Email: "admin@example.com", Password: "adminAdmin"}
+}
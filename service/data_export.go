@@ -0,0 +1,325 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/util"
+)
+
+// ErrExportInProgress is returned when the account already has an export which is not finished.
+var ErrExportInProgress = errors.New("an export is already in progress")
+
+// ErrExportNotReady is returned when the archive of the export can not be downloaded.
+var ErrExportNotReady = errors.New("export is not ready or has expired")
+
+// DataExportQuerySpec whitelists the fields to filter and sort the exports by.
+var DataExportQuerySpec = infrastructure.QuerySpec{
+	Filters:     map[string]string{"status": "status"},
+	Sorts:       map[string]string{"id": "id", "createdAt": "created_at"},
+	DefaultSort: "-id",
+}
+
+// DataExportService is a service for exporting all personal data of an account.
+type DataExportService interface {
+	RequestExport(context.Context, uint) (*model.DataExport, error)
+	GetExports(context.Context, uint, *infrastructure.Query) (*dto.Page[model.DataExport], error)
+	GetExportDownload(context.Context, uint, uint) (*dto.ExportDownloadDto, error)
+	DeleteExpiredExports(context.Context, time.Time) (int, error)
+	BuildExport(context.Context, uint) error
+}
+
+// exportReadme describes the files of the archive, and the data which is not held by this application.
+const exportReadme = `account.json        the account, its profile and roles
+security.json       the state of the failed logins and the lock of the account
+sessions.json       the sessions which are logged in
+access_tokens.json  the personal access tokens, including the revoked ones, without their values
+data_exports.json   the exports of the personal data
+avatar/             the avatar thumbnails, if uploaded
+
+The history of the individual logins is not recorded, so only the current state of the failed logins is included.
+`
+
+// exportedSecurity is the login security state of the account in the archive.
+type exportedSecurity struct {
+	BadAttempt    uint `json:"badAttempt"`
+	RemainAttempt int  `json:"remainAttempt"`
+	// Locked is true if the account has been deactivated by the failed logins or by an administrator.
+	Locked             bool `json:"locked"`
+	MustChangePassword bool `json:"mustChangePassword"`
+}
+
+type dataExportService struct {
+	container container.Container
+}
+
+// NewDataExportService is constructor.
+func NewDataExportService(container container.Container) DataExportService {
+	return &dataExportService{container: container}
+}
+
+// RequestExport creates an export and enqueues the job to build its archive.
+// The account is notified by email when the archive is ready.
+func (d *dataExportService) RequestExport(ctx context.Context, accountId uint) (*model.DataExport, error) {
+	repo := d.container.GetRepository().WithContext(ctx)
+
+	account := model.Account{}
+	if err := repo.First(&account, accountId).Error; err != nil {
+		return nil, err
+	}
+
+	var pending int64
+	tx := repo.Model(&model.DataExport{}).Where("account_id = ? AND status = ?", accountId, model.ExportStatusPending).Count(&pending)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	if pending > 0 {
+		return nil, ErrExportInProgress
+	}
+
+	export := &model.DataExport{AccountID: accountId, Status: model.ExportStatusPending}
+	if err := repo.Create(export).Error; err != nil {
+		return nil, err
+	}
+	if _, err := d.container.GetScheduler().Enqueue(JobExportBuild, strconv.FormatUint(uint64(export.ID), 10), time.Now()); err != nil {
+		d.fail(ctx, export, err)
+		return nil, err
+	}
+	return export, nil
+}
+
+func (d *dataExportService) GetExports(ctx context.Context, accountId uint, query *infrastructure.Query) (*dto.Page[model.DataExport], error) {
+	return infrastructure.NewRepo[model.DataExport](d.container.GetRepository().WithContext(ctx)).List(query, infrastructure.Where("account_id = ?", accountId))
+}
+
+// GetExportDownload returns a time-limited URL to download the archive of the export.
+func (d *dataExportService) GetExportDownload(ctx context.Context, accountId uint, exportId uint) (*dto.ExportDownloadDto, error) {
+	repo := d.container.GetRepository().WithContext(ctx)
+
+	export := model.DataExport{}
+	if err := repo.Where("id = ? AND account_id = ?", exportId, accountId).Take(&export).Error; err != nil {
+		return nil, err
+	}
+	if !export.IsDownloadable(time.Now()) {
+		return nil, ErrExportNotReady
+	}
+	return d.downloadLink(&export)
+}
+
+// DeleteExpiredExports deletes the archives whose retention has passed. It returns the number of deleted archives.
+func (d *dataExportService) DeleteExpiredExports(ctx context.Context, now time.Time) (int, error) {
+	repo := d.container.GetRepository().WithContext(ctx)
+
+	exports := []model.DataExport{}
+	tx := repo.Where("status = ? AND expires_at <= ?", model.ExportStatusReady, now).Find(&exports)
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+
+	for i, export := range exports {
+		if err := d.container.GetStorage().Delete(export.FileKey); err != nil {
+			return i, err
+		}
+		tx := repo.Model(&export).Updates(map[string]interface{}{"status": model.ExportStatusExpired, "file_key": ""})
+		if tx.Error != nil {
+			return i, tx.Error
+		}
+	}
+	return len(exports), nil
+}
+
+// BuildExport collects the personal data into a zip archive, stores it and notifies the account.
+// The failure of building is recorded to the export, so that the job is not retried.
+func (d *dataExportService) BuildExport(ctx context.Context, exportId uint) error {
+	repo := d.container.GetRepository().WithContext(ctx)
+	logger := d.container.GetLogger().GetZapLoggerFromContext(ctx)
+
+	export := model.DataExport{}
+	if err := repo.First(&export, exportId).Error; err != nil {
+		return err
+	}
+	if export.Status != model.ExportStatusPending {
+		return nil
+	}
+	account := model.Account{}
+	if err := repo.Preload("Roles.Permissions").First(&account, export.AccountID).Error; err != nil {
+		d.fail(ctx, &export, err)
+		return nil
+	}
+
+	archive, err := d.archive(ctx, &account)
+	if err != nil {
+		d.fail(ctx, &export, err)
+		return nil
+	}
+
+	key := fmt.Sprintf("%s/%d/%s.zip", config.ExportStoragePrefix, account.ID, util.RandomBase16String(32))
+	if err := d.container.GetStorage().Put(key, bytes.NewReader(archive), int64(len(archive)), "application/zip"); err != nil {
+		d.fail(ctx, &export, err)
+		return nil
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(config.ExportRetention)
+	tx := repo.Model(&export).Updates(map[string]interface{}{
+		"status":       model.ExportStatusReady,
+		"file_key":     key,
+		"size":         len(archive),
+		"completed_at": now,
+		"expires_at":   expiresAt,
+	})
+	if tx.Error != nil {
+		_ = d.container.GetStorage().Delete(key)
+		d.fail(ctx, &export, tx.Error)
+		return nil
+	}
+	export.Status, export.FileKey, export.ExpiresAt = model.ExportStatusReady, key, &expiresAt
+
+	d.sendReadyEmail(ctx, &account, &export)
+	logger.Infof("Exported the data of the account %d", account.ID)
+	return nil
+}
+
+// archive returns the zip archive of all data held about a given account.
+func (d *dataExportService) archive(ctx context.Context, account *model.Account) ([]byte, error) {
+	repo := d.container.GetRepository().WithContext(ctx)
+
+	// the revoked tokens are included, because their records are still held.
+	tokens := []model.AccessToken{}
+	if err := repo.Where("account_id = ?", account.ID).Unscoped().Order("id").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	exports := []model.DataExport{}
+	if err := repo.Where("account_id = ?", account.ID).Order("id").Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	sessions, err := d.container.GetSession().GetSessions(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	security := exportedSecurity{
+		BadAttempt:         account.BadAttempt,
+		RemainAttempt:      account.RemainAttempt(),
+		Locked:             account.Status == model.StatusInactive,
+		MustChangePassword: account.MustChangePassword,
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	files := []struct {
+		name string
+		data any
+	}{
+		{"account.json", account},
+		{"security.json", security},
+		{"sessions.json", sessions},
+		{"access_tokens.json", tokens},
+		{"data_exports.json", exports},
+	}
+	for _, file := range files {
+		f, err := w.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	readme, err := w.Create("README.txt")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(readme, exportReadme); err != nil {
+		return nil, err
+	}
+	if account.Avatar != "" {
+		for _, size := range []int{config.AvatarSizeLarge, config.AvatarSizeSmall} {
+			if err := d.copyFile(w, avatarKey(account.Avatar, size), "avatar/"+path.Base(avatarKey(account.Avatar, size))); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *dataExportService) copyFile(w *zip.Writer, key, name string) error {
+	file, err := d.container.GetStorage().Get(key)
+	if err != nil {
+		return err
+	}
+	defer util.Check(file.Close)
+
+	f, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, file)
+	return err
+}
+
+func (d *dataExportService) fail(ctx context.Context, export *model.DataExport, cause error) {
+	logger := d.container.GetLogger().GetZapLoggerFromContext(ctx)
+	logger.Errorf("Failed to export the data of the account %d: %s", export.AccountID, cause.Error())
+	tx := d.container.GetRepository().WithContext(ctx).Model(export).Updates(map[string]interface{}{"status": model.ExportStatusFailed, "error": cause.Error()})
+	if tx.Error != nil {
+		logger.Errorf("Failed to update the export %d: %s", export.ID, tx.Error.Error())
+	}
+}
+
+// downloadLink returns the signed URL of the archive. It expires in config.ExportLinkLifetime or when the archive is deleted.
+func (d *dataExportService) downloadLink(export *model.DataExport) (*dto.ExportDownloadDto, error) {
+	expiresAt := time.Now().Add(config.ExportLinkLifetime)
+	if export.ExpiresAt.Before(expiresAt) {
+		expiresAt = *export.ExpiresAt
+	}
+	url, err := d.container.GetStorage().SignedURL(export.FileKey, time.Until(expiresAt))
+	if err != nil {
+		return nil, err
+	}
+	// the local storage returns the URL relative to this application.
+	if strings.HasPrefix(url, "/") {
+		url = strings.TrimSuffix(d.container.GetConfig().Server.BaseURL, "/") + url
+	}
+	return &dto.ExportDownloadDto{URL: url, ExpiresAt: expiresAt}, nil
+}
+
+// sendReadyEmail sends the download link of the archive. The failure is only logged,
+// because the link can also be fetched by the API.
+func (d *dataExportService) sendReadyEmail(ctx context.Context, account *model.Account, export *model.DataExport) {
+	logger := d.container.GetLogger().GetZapLoggerFromContext(ctx)
+
+	link, err := d.downloadLink(export)
+	if err != nil {
+		logger.Errorf("Failed to sign the export %d: %s", export.ID, err.Error())
+		return
+	}
+	body := map[string]string{
+		"LoginId":   account.LoginId,
+		"URL":       link.URL,
+		"ExpiresAt": link.ExpiresAt.Format("2006-01-02 15:04"),
+	}
+	// TODO: Change to Constant
+	subject := "[Bistory] 개인정보 내보내기 완료 안내"
+	if err := d.container.GetEmailSender().SendEmail(ctx, account.Email, subject, config.ExportReadyTemplate, body); err != nil {
+		logger.Errorf("Failed to send the export email of account %d: %s", account.ID, err.Error())
+	}
+}
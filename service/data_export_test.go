@@ -0,0 +1,156 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestExport_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := newSyncDataExportService(container)
+	account := createSuccessAccount(NewAccountService(container))
+	_, err := NewAccessTokenService(container).CreateAccessToken(account.ID, &dto.CreateAccessTokenDto{Name: "script", Scopes: []string{model.ScopeAccountRead}}, nil)
+	assert.Nil(t, err)
+	_, err = NewAvatarService(container).UploadAvatar(account.ID, bytes.NewReader(newTestImage(t, 64, 64)))
+	assert.Nil(t, err)
+
+	export, err := service.RequestExport(context.Background(), account.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, model.ExportStatusPending, export.Status)
+
+	exports, err := service.GetExports(context.Background(), account.ID, parseQuery(t, DataExportQuerySpec, "status=2"))
+	assert.Nil(t, err)
+	assert.Len(t, exports.Items, 1)
+	assert.Equal(t, model.ExportStatusReady, exports.Items[0].Status)
+	assert.NotZero(t, exports.Items[0].Size)
+
+	files := readArchive(t, container, exports.Items[0].FileKey)
+	assert.Contains(t, files, "avatar/256.png")
+	assert.Contains(t, files, "avatar/64.png")
+
+	exported := model.Account{}
+	assert.Nil(t, json.Unmarshal(files["account.json"], &exported))
+	assert.Equal(t, account.LoginId, exported.LoginId)
+	assert.Equal(t, []string{model.RoleUser}, exported.RoleNames())
+
+	security := exportedSecurity{}
+	assert.Nil(t, json.Unmarshal(files["security.json"], &security))
+	assert.Equal(t, exportedSecurity{RemainAttempt: config.MaxLoginAttempts}, security)
+	// CookieStore holds no session, so none is exported
+	assert.JSONEq(t, "[]", string(files["sessions.json"]))
+	assert.Contains(t, string(files["README.txt"]), "sessions.json")
+
+	tokens := []model.AccessToken{}
+	assert.Nil(t, json.Unmarshal(files["access_tokens.json"], &tokens))
+	assert.Len(t, tokens, 1)
+	stored := model.AccessToken{}
+	container.GetRepository().First(&stored, tokens[0].ID)
+	assert.NotContains(t, string(files["access_tokens.json"]), stored.TokenHash)
+}
+
+func TestRequestExport_InProgressFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewDataExportService(container)
+	account := createSuccessAccount(NewAccountService(container))
+
+	_, err := service.RequestExport(context.Background(), account.ID)
+	assert.Nil(t, err)
+
+	export, err := service.RequestExport(context.Background(), account.ID)
+	assert.ErrorIs(t, err, ErrExportInProgress)
+	assert.Nil(t, export)
+}
+
+func TestGetExportDownload_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := newSyncDataExportService(container)
+	account := createSuccessAccount(NewAccountService(container))
+
+	export, err := service.RequestExport(context.Background(), account.ID)
+	assert.Nil(t, err)
+
+	download, err := service.GetExportDownload(context.Background(), account.ID, export.ID)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(download.URL, config.APIFiles+"/"+config.ExportStoragePrefix))
+	assert.WithinDuration(t, time.Now().Add(config.ExportLinkLifetime), download.ExpiresAt, time.Minute)
+
+	// the export of another account
+	download, err = service.GetExportDownload(context.Background(), account.ID+1, export.ID)
+	assert.NotNil(t, err)
+	assert.Nil(t, download)
+}
+
+func TestDeleteExpiredExports_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := newSyncDataExportService(container)
+	account := createSuccessAccount(NewAccountService(container))
+
+	export, err := service.RequestExport(context.Background(), account.ID)
+	assert.Nil(t, err)
+
+	deleted, err := service.DeleteExpiredExports(context.Background(), time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, deleted)
+
+	exports, _ := service.GetExports(context.Background(), account.ID, parseQuery(t, DataExportQuerySpec, ""))
+	key := exports.Items[0].FileKey
+	deleted, err = service.DeleteExpiredExports(context.Background(), time.Now().Add(config.ExportRetention+time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, deleted)
+
+	_, err = service.GetExportDownload(context.Background(), account.ID, export.ID)
+	assert.ErrorIs(t, err, ErrExportNotReady)
+	_, err = container.GetStorage().Get(key)
+	assert.NotNil(t, err)
+}
+
+// syncDataExportService runs the job building the archive as soon as the export is requested.
+type syncDataExportService struct {
+	DataExportService
+	container container.Container
+}
+
+func (s *syncDataExportService) RequestExport(ctx context.Context, accountId uint) (*model.DataExport, error) {
+	export, err := s.DataExportService.RequestExport(ctx, accountId)
+	if err == nil {
+		s.container.GetScheduler().RunDue(ctx, time.Now())
+	}
+	return export, err
+}
+
+func newSyncDataExportService(container container.Container) DataExportService {
+	_ = RegisterJobs(container)
+	return &syncDataExportService{DataExportService: NewDataExportService(container), container: container}
+}
+
+func readArchive(t *testing.T, container container.Container, key string) map[string][]byte {
+	file, err := container.GetStorage().Get(key)
+	assert.Nil(t, err)
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	assert.Nil(t, err)
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.Nil(t, err)
+	files := map[string][]byte{}
+	for _, f := range reader.File {
+		r, err := f.Open()
+		assert.Nil(t, err)
+		files[f.Name], _ = io.ReadAll(r)
+		_ = r.Close()
+	}
+	return files
+}
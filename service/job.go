@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/model/dto"
+)
+
+// The handlers of the background jobs.
+const (
+	JobAccountPurge  = "account.purge"
+	JobExportBuild   = "export.build"
+	JobExportCleanup = "export.cleanup"
+	JobTokenCleanup  = "token.cleanup"
+	// JobIdempotencyCleanup deletes the expired idempotency keys stored in the database.
+	JobIdempotencyCleanup = "idempotency.cleanup"
+)
+
+// RegisterJobs registers the handlers of the background jobs and schedules the recurring ones.
+func RegisterJobs(container container.Container) error {
+	scheduler := container.GetScheduler()
+	logger := container.GetLogger().GetZapLogger()
+
+	scheduler.Register(JobAccountPurge, func(ctx context.Context, payload string) error {
+		purged, err := NewAccountService(container).PurgeAccounts(ctx, time.Now())
+		if purged > 0 {
+			logger.Infof("Purged %d accounts", purged)
+		}
+		return err
+	})
+	scheduler.Register(JobExportBuild, func(ctx context.Context, payload string) error {
+		exportId, err := strconv.ParseUint(payload, 10, 64)
+		if err != nil {
+			return err
+		}
+		return NewDataExportService(container).BuildExport(ctx, uint(exportId))
+	})
+	scheduler.Register(JobExportCleanup, func(ctx context.Context, payload string) error {
+		deleted, err := NewDataExportService(container).DeleteExpiredExports(ctx, time.Now())
+		if deleted > 0 {
+			logger.Infof("Deleted %d expired exports", deleted)
+		}
+		return err
+	})
+	scheduler.Register(JobTokenCleanup, func(ctx context.Context, payload string) error {
+		deleted, err := NewAccessTokenService(container).DeleteExpiredAccessTokens(time.Now())
+		if deleted > 0 {
+			logger.Infof("Deleted %d expired access tokens", deleted)
+		}
+		return err
+	})
+	scheduler.Register(JobIdempotencyCleanup, func(ctx context.Context, payload string) error {
+		deleted, err := container.GetIdempotencyStore().DeleteExpired(ctx, time.Now())
+		if deleted > 0 {
+			logger.Infof("Deleted %d expired idempotency keys", deleted)
+		}
+		return err
+	})
+
+	schedules := []struct{ name, spec string }{
+		{JobAccountPurge, "@hourly"},
+		{JobExportCleanup, "@hourly"},
+		{JobTokenCleanup, "@daily"},
+		{JobIdempotencyCleanup, "@hourly"},
+	}
+	for _, schedule := range schedules {
+		if err := scheduler.Schedule(schedule.name, schedule.spec, schedule.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JobQuerySpec whitelists the fields to filter and sort the jobs by.
+var JobQuerySpec = infrastructure.QuerySpec{
+	Filters:     map[string]string{"status": "status", "handler": "handler"},
+	Sorts:       map[string]string{"id": "id", "name": "name", "nextRunAt": "next_run_at"},
+	DefaultSort: "id",
+}
+
+// JobRunQuerySpec whitelists the fields to filter and sort the runs of a job by.
+var JobRunQuerySpec = infrastructure.QuerySpec{
+	Filters:     map[string]string{"status": "status"},
+	Sorts:       map[string]string{"id": "id", "startedAt": "started_at"},
+	DefaultSort: "-id",
+}
+
+// JobService is a service for managing the background jobs.
+type JobService interface {
+	GetJobs(*infrastructure.Query) (*dto.Page[model.Job], error)
+	GetJobRuns(uint, *infrastructure.Query) (*dto.Page[model.JobRun], error)
+	TriggerJob(uint) error
+}
+
+type jobService struct {
+	container container.Container
+}
+
+// NewJobService is constructor.
+func NewJobService(container container.Container) JobService {
+	return &jobService{container: container}
+}
+
+func (j *jobService) GetJobs(query *infrastructure.Query) (*dto.Page[model.Job], error) {
+	return infrastructure.NewRepo[model.Job](j.container.GetRepository()).List(query)
+}
+
+func (j *jobService) GetJobRuns(jobId uint, query *infrastructure.Query) (*dto.Page[model.JobRun], error) {
+	repo := j.container.GetRepository()
+
+	if _, err := infrastructure.NewRepo[model.Job](repo).FindByID(jobId); err != nil {
+		return nil, infrastructure.ErrJobNotFound
+	}
+	return infrastructure.NewRepo[model.JobRun](repo).List(query, infrastructure.Where("job_id = ?", jobId))
+}
+
+func (j *jobService) TriggerJob(jobId uint) error {
+	return j.container.GetScheduler().Trigger(jobId)
+}
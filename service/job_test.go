@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onetooler/bistory-backend/config"
+	"github.com/onetooler/bistory-backend/infrastructure"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterJobs_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+
+	assert.Nil(t, RegisterJobs(container))
+	// registering again on restart does not duplicate the recurring jobs.
+	assert.Nil(t, RegisterJobs(container))
+
+	jobs, err := NewJobService(container).GetJobs(parseQuery(t, JobQuerySpec, ""))
+	assert.Nil(t, err)
+	assert.Len(t, jobs.Items, 4)
+	for _, job := range jobs.Items {
+		assert.True(t, job.IsRecurring())
+		assert.Equal(t, model.JobStatusScheduled, job.Status)
+		assert.True(t, job.NextRunAt.After(time.Now()))
+	}
+}
+
+func TestRunDue_RecurringSuccess(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	scheduler := container.GetScheduler()
+
+	count := 0
+	scheduler.Register("test", func(ctx context.Context, payload string) error {
+		count++
+		return nil
+	})
+	assert.Nil(t, scheduler.Schedule("test", "@hourly", "test"))
+	job := findJob(t, container.GetRepository(), "test")
+
+	now := time.Now()
+	assert.Equal(t, 0, scheduler.RunDue(context.Background(), now))
+	assert.Equal(t, 1, scheduler.RunDue(context.Background(), job.NextRunAt))
+	assert.Equal(t, 1, count)
+
+	job = findJob(t, container.GetRepository(), "test")
+	assert.Equal(t, model.JobStatusScheduled, job.Status)
+	assert.True(t, job.NextRunAt.After(now))
+	assert.Empty(t, job.LockedBy)
+	assert.Nil(t, job.LockedUntil)
+
+	runs, err := NewJobService(container).GetJobRuns(job.ID, parseQuery(t, JobRunQuerySpec, ""))
+	assert.Nil(t, err)
+	assert.Len(t, runs.Items, 1)
+	assert.Equal(t, model.JobRunStatusSucceeded, runs.Items[0].Status)
+	assert.NotNil(t, runs.Items[0].FinishedAt)
+}
+
+func TestRunDue_OneOffSuccess(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	scheduler := container.GetScheduler()
+
+	var received string
+	scheduler.Register("test", func(ctx context.Context, payload string) error {
+		received = payload
+		return nil
+	})
+	job, err := scheduler.Enqueue("test", "payload", time.Now())
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, scheduler.RunDue(context.Background(), time.Now()))
+	assert.Equal(t, "payload", received)
+	assert.Equal(t, 0, scheduler.RunDue(context.Background(), time.Now()))
+
+	job = findJob(t, container.GetRepository(), job.Name)
+	assert.Equal(t, model.JobStatusCompleted, job.Status)
+}
+
+func TestRunDue_RetryFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	scheduler := container.GetScheduler()
+
+	scheduler.Register("test", func(ctx context.Context, payload string) error {
+		return errors.New("test error")
+	})
+	job, err := scheduler.Enqueue("test", "", time.Now())
+	assert.Nil(t, err)
+
+	now := time.Now()
+	for i := uint(1); i <= config.JobMaxAttempts; i++ {
+		assert.Equal(t, 1, scheduler.RunDue(context.Background(), now))
+		job = findJob(t, container.GetRepository(), job.Name)
+		assert.Equal(t, i, job.Attempts)
+		assert.Equal(t, "test error", job.LastError)
+		now = job.NextRunAt
+	}
+	assert.Equal(t, model.JobStatusFailed, job.Status)
+	assert.Equal(t, 0, scheduler.RunDue(context.Background(), now.Add(time.Hour)))
+
+	runs, err := NewJobService(container).GetJobRuns(job.ID, parseQuery(t, JobRunQuerySpec, ""))
+	assert.Nil(t, err)
+	assert.Len(t, runs.Items, int(config.JobMaxAttempts))
+	assert.Equal(t, model.JobRunStatusFailed, runs.Items[0].Status)
+	assert.Equal(t, "test error", runs.Items[0].Error)
+}
+
+func TestRunDue_PanicFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	scheduler := container.GetScheduler()
+
+	scheduler.Register("test", func(ctx context.Context, payload string) error {
+		panic("test panic")
+	})
+	job, err := scheduler.Enqueue("test", "", time.Now())
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, scheduler.RunDue(context.Background(), time.Now()))
+
+	job = findJob(t, container.GetRepository(), job.Name)
+	assert.Equal(t, uint(1), job.Attempts)
+	assert.Contains(t, job.LastError, "test panic")
+}
+
+func TestRunDue_ClaimedByAnotherInstance(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	scheduler := container.GetScheduler()
+	other := infrastructure.NewScheduler(container.GetLogger(), container.GetRepository())
+
+	count := 0
+	handler := func(ctx context.Context, payload string) error {
+		count++
+		// the job is locked by this instance while running.
+		assert.Equal(t, 0, other.RunDue(ctx, time.Now()))
+		return nil
+	}
+	scheduler.Register("test", handler)
+	other.Register("test", handler)
+	_, err := scheduler.Enqueue("test", "", time.Now())
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, scheduler.RunDue(context.Background(), time.Now()))
+	assert.Equal(t, 0, other.RunDue(context.Background(), time.Now()))
+	assert.Equal(t, 1, count)
+}
+
+func TestTriggerJob_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	scheduler := container.GetScheduler()
+	service := NewJobService(container)
+
+	count := 0
+	scheduler.Register("test", func(ctx context.Context, payload string) error {
+		count++
+		return nil
+	})
+	assert.Nil(t, scheduler.Schedule("test", "@daily", "test"))
+	job := findJob(t, container.GetRepository(), "test")
+
+	assert.Nil(t, service.TriggerJob(job.ID))
+	assert.Equal(t, 1, scheduler.RunDue(context.Background(), time.Now()))
+	assert.Equal(t, 1, count)
+}
+
+func TestTriggerJob_DuringRun(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	scheduler := container.GetScheduler()
+	service := NewJobService(container)
+	assert.Nil(t, scheduler.Schedule("test", "@daily", "test"))
+	job := findJob(t, container.GetRepository(), "test")
+
+	count := 0
+	scheduler.Register("test", func(ctx context.Context, payload string) error {
+		count++
+		if count == 1 {
+			assert.Nil(t, service.TriggerJob(job.ID))
+		}
+		return nil
+	})
+
+	assert.Nil(t, service.TriggerJob(job.ID))
+	assert.Equal(t, 1, scheduler.RunDue(context.Background(), time.Now()))
+	// the trigger during the run is not overwritten by the next run of the schedule.
+	assert.Equal(t, 1, scheduler.RunDue(context.Background(), time.Now()))
+	assert.Equal(t, 2, count)
+
+	job = findJob(t, container.GetRepository(), "test")
+	assert.Empty(t, job.LockedBy)
+	assert.True(t, job.NextRunAt.After(time.Now()))
+}
+
+func TestRunDue_LockTakenByAnotherInstance(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	scheduler := container.GetScheduler()
+	job, err := scheduler.Enqueue("test", "", time.Now())
+	assert.Nil(t, err)
+
+	scheduler.Register("test", func(ctx context.Context, payload string) error {
+		// another instance has claimed the job after the lock expired.
+		return container.GetRepository().Model(&model.Job{}).Where("id = ?", job.ID).Update("locked_by", "other").Error
+	})
+
+	assert.Equal(t, 1, scheduler.RunDue(context.Background(), time.Now()))
+
+	job = findJob(t, container.GetRepository(), job.Name)
+	assert.Equal(t, "other", job.LockedBy)
+	assert.Equal(t, model.JobStatusScheduled, job.Status)
+}
+
+func TestTriggerJob_NotFoundFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewJobService(container)
+
+	assert.ErrorIs(t, service.TriggerJob(9999), infrastructure.ErrJobNotFound)
+	_, err := service.GetJobRuns(9999, parseQuery(t, JobRunQuerySpec, ""))
+	assert.ErrorIs(t, err, infrastructure.ErrJobNotFound)
+}
+
+func findJob(t *testing.T, repo infrastructure.Repository, name string) *model.Job {
+	job := model.Job{}
+	assert.Nil(t, repo.Where(&model.Job{Name: name}).First(&job).Error)
+	return &job
+}
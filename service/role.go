@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onetooler/bistory-backend/container"
+	"github.com/onetooler/bistory-backend/model"
+)
+
+// RoleService is a service for managing roles and their assignments.
+type RoleService interface {
+	GetRoles(context.Context) ([]model.Role, error)
+	GetAccountRoles(context.Context, uint) ([]model.Role, error)
+	AssignRole(context.Context, uint, string) error
+	UnassignRole(context.Context, uint, string) error
+}
+
+type roleService struct {
+	container container.Container
+}
+
+// NewRoleService is constructor.
+func NewRoleService(container container.Container) RoleService {
+	return &roleService{container: container}
+}
+
+func (r *roleService) GetRoles(ctx context.Context) ([]model.Role, error) {
+	repo := r.container.GetRepository().WithContext(ctx)
+
+	roles := []model.Role{}
+	if err := repo.Preload("Permissions").Order("id").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleService) GetAccountRoles(ctx context.Context, accountId uint) ([]model.Role, error) {
+	repo := r.container.GetRepository().WithContext(ctx)
+
+	account := model.Account{}
+	if err := repo.Preload("Roles.Permissions").First(&account, accountId).Error; err != nil {
+		return nil, err
+	}
+	return account.Roles, nil
+}
+
+func (r *roleService) AssignRole(ctx context.Context, accountId uint, roleName string) error {
+	repo := r.container.GetRepository().WithContext(ctx)
+
+	account := model.Account{}
+	if err := repo.First(&account, accountId).Error; err != nil {
+		return err
+	}
+	role, err := r.findByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	assigned := int64(0)
+	if err := repo.Model(&model.AccountRole{}).Where(&model.AccountRole{AccountID: accountId, RoleID: role.ID}).Count(&assigned).Error; err != nil {
+		return err
+	}
+	if assigned > 0 {
+		return nil
+	}
+	return repo.Create(&model.AccountRole{AccountID: accountId, RoleID: role.ID}).Error
+}
+
+func (r *roleService) UnassignRole(ctx context.Context, accountId uint, roleName string) error {
+	repo := r.container.GetRepository().WithContext(ctx)
+
+	role, err := r.findByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+	tx := repo.Where(&model.AccountRole{AccountID: accountId, RoleID: role.ID}).Delete(&model.AccountRole{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return fmt.Errorf("role %s is not assigned", roleName)
+	}
+	return nil
+}
+
+func (r *roleService) findByName(ctx context.Context, name string) (*model.Role, error) {
+	repo := r.container.GetRepository().WithContext(ctx)
+
+	role := model.Role{}
+	if err := repo.Where(&model.Role{Name: name}).First(&role).Error; err != nil {
+		return nil, fmt.Errorf("role %s not found", name)
+	}
+	return &role, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onetooler/bistory-backend/migration"
+	"github.com/onetooler/bistory-backend/model"
+	"github.com/onetooler/bistory-backend/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRoles_Seeded(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewRoleService(container)
+
+	roles, err := service.GetRoles(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, roles, len(model.DefaultRoles()))
+	for _, role := range roles {
+		if role.Name == model.RoleAdmin {
+			assert.Len(t, role.Permissions, len(model.AllPermissions))
+		}
+		if role.Name == model.RoleSupport {
+			assert.True(t, role.HasPermission(model.PermissionAccountRead))
+			assert.False(t, role.HasPermission(model.PermissionAccountDelete))
+		}
+	}
+}
+
+func TestGetAccountRoles_MigratedFromAuthority(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewRoleService(container)
+
+	// the account created before the roles has only the legacy authority
+	admin, _ := model.NewAccountWithPasswordEncrypt(context.Background(), "legacy", "legacy@example.com", "legacyLegacy", model.AuthorityAdmin)
+	container.GetRepository().Create(admin)
+	assert.Nil(t, migration.Up(container))
+
+	roles, err := service.GetAccountRoles(context.Background(), admin.ID)
+	assert.Nil(t, err)
+	assert.Len(t, roles, 1)
+	assert.Equal(t, model.RoleAdmin, roles[0].Name)
+}
+
+func TestGetAccountRoles_CreatedAccountHasUserRole(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewRoleService(container)
+	savedAccount := createSuccessAccount(NewAccountService(container))
+
+	roles, err := service.GetAccountRoles(context.Background(), savedAccount.ID)
+	assert.Nil(t, err)
+	assert.Len(t, roles, 1)
+	assert.Equal(t, model.RoleUser, roles[0].Name)
+}
+
+func TestAssignRole_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewRoleService(container)
+	savedAccount := createSuccessAccount(NewAccountService(container))
+
+	err := service.AssignRole(context.Background(), savedAccount.ID, model.RoleSupport)
+	assert.Nil(t, err)
+	// assigning twice is no-op
+	err = service.AssignRole(context.Background(), savedAccount.ID, model.RoleSupport)
+	assert.Nil(t, err)
+
+	account := model.Account{}
+	container.GetRepository().Preload("Roles.Permissions").First(&account, savedAccount.ID)
+	assert.Equal(t, []string{model.RoleSupport, model.RoleUser}, account.RoleNames())
+	assert.Contains(t, account.Permissions(), string(model.PermissionAccountRead))
+	assert.NotContains(t, account.Permissions(), string(model.PermissionAccountDelete))
+}
+
+func TestAssignRole_UnknownRoleFailure(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewRoleService(container)
+	savedAccount := createSuccessAccount(NewAccountService(container))
+
+	err := service.AssignRole(context.Background(), savedAccount.ID, "superuser")
+	assert.NotNil(t, err)
+}
+
+func TestUnassignRole_Success(t *testing.T) {
+	container := testutil.PrepareForServiceTest(false)
+	service := NewRoleService(container)
+	savedAccount := createSuccessAccount(NewAccountService(container))
+
+	err := service.UnassignRole(context.Background(), savedAccount.ID, model.RoleUser)
+	assert.Nil(t, err)
+
+	roles, err := service.GetAccountRoles(context.Background(), savedAccount.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, roles)
+
+	err = service.UnassignRole(context.Background(), savedAccount.ID, model.RoleUser)
+	assert.NotNil(t, err)
+}
@@ -1,11 +1,15 @@
 package testutil
 
 import (
+	"context"
+	"embed"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path"
 	"strings"
 
 	"github.com/alicebob/miniredis/v2"
@@ -16,6 +20,11 @@ import (
 	"github.com/onetooler/bistory-backend/logger"
 	"github.com/onetooler/bistory-backend/middleware"
 	"github.com/onetooler/bistory-backend/migration"
+	"github.com/onetooler/bistory-backend/seed"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
@@ -47,9 +56,10 @@ func PrepareForControllerTest(useEmail bool) (*echo.Echo, container.Container) {
 
 	middleware.InitLoggerMiddleware(e, container)
 
-	migration.Init(container)
+	initDatabase(container)
 
 	middleware.InitSessionMiddleware(e, container)
+	middleware.InitValidationBinder(e)
 	return e, container
 }
 
@@ -68,7 +78,7 @@ func PrepareForServiceTest(useEmail bool) container.Container {
 	logger := initTestLogger()
 	container := initContainer(conf, logger)
 
-	migration.Init(container)
+	initDatabase(container)
 
 	return container
 }
@@ -81,20 +91,67 @@ func PrepareForLoggerTest() (*echo.Echo, container.Container, *observer.Observed
 	logger, observedLogs := initObservedLogger()
 	container := initContainer(conf, logger)
 
-	migration.Init(container)
+	initDatabase(container)
 
 	middleware.InitSessionMiddleware(e, container)
+	middleware.InitValidationBinder(e)
 	middleware.InitLoggerMiddleware(e, container)
 	return e, container, observedLogs
 }
 
+// PrepareForTracingTest func prepares the tracing of the routes and the database, recording the spans in memory.
+func PrepareForTracingTest() (*echo.Echo, container.Container, *tracetest.SpanRecorder) {
+	e := echo.New()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	conf := createBaseConfig()
+	conf.Tracing.Enabled = true
+	logger := initTestLogger()
+	container := initContainer(conf, logger)
+
+	initDatabase(container)
+
+	middleware.InitTracingMiddleware(e, container)
+	middleware.InitLoggerMiddleware(e, container)
+	middleware.InitSessionMiddleware(e, container)
+	middleware.InitValidationBinder(e)
+	return e, container, recorder
+}
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// LoadFixtures applies the fixture files in testutil/fixtures, such as "accounts.yml". The existing records are overwritten.
+func LoadFixtures(container container.Container, names ...string) error {
+	for _, name := range names {
+		data, err := fixtures.ReadFile(path.Join("fixtures", name))
+		if err != nil {
+			return err
+		}
+		if err := seed.Apply(context.Background(), container, data, seed.Overwrite); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// initDatabase creates the tables and the admin account of fixtures/admin.yml, which every test starts with.
+func initDatabase(container container.Container) {
+	migration.Init(container)
+	if err := LoadFixtures(container, "admin.yml"); err != nil {
+		panic(err)
+	}
+}
+
 func createBaseConfig() *config.Config {
 	conf := &config.Config{}
 	conf.Database.Dialect = "sqlite3"
 	conf.Database.Host = "file::memory:?cache=shared"
 	conf.Database.Migration = true
-	conf.Extension.MasterGenerator = true
-	conf.Log.RequestLogFormat = "${remote_ip} ${account_loginid} ${uri} ${method} ${status}"
+	conf.Log.RequestLogFormat = "${remote_ip} ${account_loginid} ${uri} ${method} ${status} ${request_id}"
 	return conf
 }
 
@@ -106,13 +163,19 @@ func initContainer(conf *config.Config, logger logger.Logger) container.Containe
 	templates := map[string]*template.Template{
 		config.FindLoginIdTemplate:       t,
 		config.EmailVerificationTemplate: t,
+		config.AccountRestoreTemplate:    t,
+		config.ExportReadyTemplate:       t,
+		config.TestEmailTemplate:         t,
 	}
 	emailSender := infrastructure.NewEmailSender(logger, conf, templates)
 
+	root, _ := os.MkdirTemp("", "bistory-storage-")
+	storage, _ := infrastructure.NewLocalStorage(root, "test")
+
 	messages := map[string]string{
 		"TestErr": "It's a test message.",
 	}
-	container := container.NewContainer(rep, sess, emailSender, conf, messages, logger, "test")
+	container := container.NewContainer(rep, sess, emailSender, storage, infrastructure.NewScheduler(logger, rep), infrastructure.NewIdempotencyStore(logger, conf, rep), conf, messages, logger, "test")
 	return container
 }
 
@@ -124,7 +187,7 @@ func initTestLogger() logger.Logger {
 	}
 	sugar := zap.Sugar()
 
-	logger := logger.NewLogger(sugar)
+	logger := logger.NewLogger(sugar, createBaseConfig())
 	logger.GetZapLogger().Infof("Success to read zap logger configuration")
 	_ = zap.Sync()
 	return logger
@@ -134,7 +197,7 @@ func initObservedLogger() (logger.Logger, *observer.ObservedLogs) {
 	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
 	sugar := zap.New(observedZapCore).Sugar()
 
-	logger := logger.NewLogger(sugar)
+	logger := logger.NewLogger(sugar, createBaseConfig())
 	return logger, observedLogs
 }
 